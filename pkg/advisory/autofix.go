@@ -0,0 +1,280 @@
+package advisory
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+)
+
+// AutoFixOptions configures the AutoFix operation.
+type AutoFixOptions struct {
+	// AdvisoryDocs is the Index of advisory documents on which to operate.
+	AdvisoryDocs *configs.Index[v2.Document]
+
+	// SelectedPackages is the set of packages to operate on. If empty, all packages
+	// will be operated on.
+	SelectedPackages map[string]struct{}
+
+	// AliasFinder is used to fill in missing aliases for advisories. If nil,
+	// missing aliases are not filled in.
+	AliasFinder AliasFinder
+
+	// RenameFile is used to rename advisory documents whose file names don't
+	// match their package names. Paths are relative to the root of the
+	// AdvisoryDocs index. If nil, documents are not renamed.
+	RenameFile func(oldPath, newPath string) error
+}
+
+// AutoFixChange describes a single change made by AutoFix.
+type AutoFixChange struct {
+	// Path is the path to the document that was changed, as it was before the
+	// change.
+	Path string
+
+	// AdvisoryID is the ID of the advisory that was changed. It's empty for
+	// changes that apply to the document as a whole.
+	AdvisoryID string
+
+	// Description explains what was changed.
+	Description string
+}
+
+func (c AutoFixChange) String() string {
+	if c.AdvisoryID == "" {
+		return fmt.Sprintf("%s: %s", c.Path, c.Description)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", c.Path, c.AdvisoryID, c.Description)
+}
+
+// AutoFix resolves mechanical problems in advisory data that would otherwise be
+// reported by Validate. It:
+//
+//   - fills in missing CVE and GHSA aliases (if an AliasFinder is provided),
+//   - sorts each advisory's aliases,
+//   - removes duplicate events (events with the same timestamp, type, and data),
+//   - sorts each advisory's events chronologically, and
+//   - renames documents whose file names don't match their package names (if
+//     RenameFile is provided).
+//
+// Documents are updated through the index, so formatting outside of the
+// changed sections is preserved. The changes made are returned.
+func AutoFix(ctx context.Context, opts AutoFixOptions) ([]AutoFixChange, error) {
+	log := clog.FromContext(ctx)
+
+	var changes []AutoFixChange
+
+	for _, entry := range opts.AdvisoryDocs.Select().Entries() {
+		doc := entry.Configuration()
+
+		if len(opts.SelectedPackages) > 0 {
+			if _, ok := opts.SelectedPackages[doc.Name()]; !ok {
+				// Skip this document, since it's not in the set of selected packages.
+				continue
+			}
+		}
+
+		advisories := make(v2.Advisories, 0, len(doc.Advisories))
+		var docChanges []AutoFixChange
+
+		for _, adv := range doc.Advisories {
+			fixed, descriptions, err := opts.autoFixAdvisory(ctx, adv)
+			if err != nil {
+				return nil, fmt.Errorf("fixing advisory %q in %q: %w", adv.ID, entry.Path(), err)
+			}
+
+			for _, d := range descriptions {
+				docChanges = append(docChanges, AutoFixChange{
+					Path:        entry.Path(),
+					AdvisoryID:  adv.ID,
+					Description: d,
+				})
+			}
+
+			advisories = append(advisories, fixed)
+		}
+
+		if len(docChanges) == 0 {
+			continue
+		}
+
+		log.Debug("applying fixes to advisory document", "path", entry.Path(), "changes", len(docChanges))
+
+		u := adv2.NewAdvisoriesSectionUpdater(func(_ v2.Document) (v2.Advisories, error) {
+			return advisories, nil
+		})
+		if err := opts.AdvisoryDocs.Select().WhereFilePath(entry.Path()).Update(ctx, u); err != nil {
+			return nil, fmt.Errorf("updating %q: %w", entry.Path(), err)
+		}
+
+		changes = append(changes, docChanges...)
+	}
+
+	renames, err := opts.autoFixDocumentNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, renames...)
+
+	return changes, nil
+}
+
+// autoFixAdvisory returns a fixed copy of the advisory, along with descriptions
+// of the changes made.
+func (opts AutoFixOptions) autoFixAdvisory(ctx context.Context, adv v2.Advisory) (v2.Advisory, []string, error) {
+	var descriptions []string
+
+	if opts.AliasFinder != nil {
+		missing, err := opts.findMissingAliases(ctx, adv)
+		if err != nil {
+			return v2.Advisory{}, nil, err
+		}
+
+		if len(missing) > 0 {
+			adv.Aliases = append(slices.Clone(adv.Aliases), missing...)
+			descriptions = append(descriptions, fmt.Sprintf("added missing aliases: %s", strings.Join(missing, ", ")))
+		}
+	}
+
+	if !sort.StringsAreSorted(adv.Aliases) {
+		adv.Aliases = slices.Clone(adv.Aliases)
+		sort.Strings(adv.Aliases)
+		descriptions = append(descriptions, "sorted aliases")
+	}
+
+	if deduped := dedupeEvents(adv.Events); len(deduped) != len(adv.Events) {
+		descriptions = append(descriptions, fmt.Sprintf("removed %d duplicate event(s)", len(adv.Events)-len(deduped)))
+		adv.Events = deduped
+	}
+
+	if !eventsAreSorted(adv.Events) {
+		adv.Events = adv.SortedEvents()
+		descriptions = append(descriptions, "sorted events chronologically")
+	}
+
+	return adv, descriptions, nil
+}
+
+// findMissingAliases returns the aliases of the advisory's existing aliases
+// that aren't yet in the advisory's alias set. This mirrors the alias set
+// completeness validation.
+func (opts AutoFixOptions) findMissingAliases(ctx context.Context, adv v2.Advisory) ([]string, error) {
+	var missing []string
+
+	add := func(id string) {
+		if id != "" && !adv.DescribesVulnerability(id) && !slices.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+
+	for _, a := range adv.Aliases {
+		switch {
+		case strings.HasPrefix(a, "CVE-"):
+			ghsas, err := opts.AliasFinder.GHSAsForCVE(ctx, a)
+			if err != nil {
+				return nil, fmt.Errorf("querying GHSA aliases for CVE %q: %w", a, err)
+			}
+			for _, ghsa := range ghsas {
+				add(ghsa)
+			}
+
+		case strings.HasPrefix(a, "GHSA-"):
+			cve, err := opts.AliasFinder.CVEForGHSA(ctx, a)
+			if err != nil {
+				return nil, fmt.Errorf("querying CVE alias for GHSA %q: %w", a, err)
+			}
+			add(cve)
+		}
+	}
+
+	return missing, nil
+}
+
+// dedupeEvents returns the events with any exact duplicates removed, keeping
+// the first occurrence of each event.
+func dedupeEvents(events []v2.Event) []v2.Event {
+	result := make([]v2.Event, 0, len(events))
+
+	for _, e := range events {
+		duplicate := slices.ContainsFunc(result, func(existing v2.Event) bool {
+			return time.Time(existing.Timestamp).Equal(time.Time(e.Timestamp)) &&
+				existing.Type == e.Type &&
+				reflect.DeepEqual(existing.Data, e.Data)
+		})
+		if !duplicate {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+func eventsAreSorted(events []v2.Event) bool {
+	return sort.SliceIsSorted(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+}
+
+// autoFixDocumentNames renames documents whose file names don't match their
+// package names. Documents are left alone if the correct file name is already
+// in use.
+func (opts AutoFixOptions) autoFixDocumentNames(ctx context.Context) ([]AutoFixChange, error) {
+	if opts.RenameFile == nil {
+		return nil, nil
+	}
+
+	log := clog.FromContext(ctx)
+
+	entries := opts.AdvisoryDocs.Select().Entries()
+	existingPaths := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		existingPaths[e.Path()] = struct{}{}
+	}
+
+	var changes []AutoFixChange
+
+	for _, e := range entries {
+		name := e.Configuration().Package.Name
+
+		if len(opts.SelectedPackages) > 0 {
+			if _, ok := opts.SelectedPackages[name]; !ok {
+				// Skip this document, since it's not in the set of selected packages.
+				continue
+			}
+		}
+
+		expectedPath := path.Join(path.Dir(e.Path()), name+".advisories.yaml")
+		if e.Path() == expectedPath {
+			continue
+		}
+
+		if _, ok := existingPaths[expectedPath]; ok {
+			log.Warn("not renaming document, since its expected file name is already in use", "path", e.Path(), "expectedPath", expectedPath)
+			continue
+		}
+
+		if err := opts.RenameFile(e.Path(), expectedPath); err != nil {
+			return nil, fmt.Errorf("renaming %q to %q: %w", e.Path(), expectedPath, err)
+		}
+
+		existingPaths[expectedPath] = struct{}{}
+		delete(existingPaths, e.Path())
+
+		changes = append(changes, AutoFixChange{
+			Path:        e.Path(),
+			Description: fmt.Sprintf("renamed to %q to match package name", expectedPath),
+		})
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,60 @@
+package advisory
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/testerfs"
+)
+
+func TestAutoFix(t *testing.T) {
+	ctx := context.Background()
+
+	fsys, err := testerfs.New(os.DirFS("testdata/autofix"))
+	require.NoError(t, err)
+
+	index, err := adv2.NewIndex(ctx, fsys)
+	require.NoError(t, err)
+
+	var renames [][2]string
+
+	changes, err := AutoFix(ctx, AutoFixOptions{
+		AdvisoryDocs: index,
+		AliasFinder: mockAliasFinder{
+			cveByGHSA: map[string]string{
+				"GHSA-2222-2222-2222": "CVE-2023-2222",
+				"GHSA-3333-3333-3333": "CVE-2023-3333",
+			},
+		},
+		RenameFile: func(oldPath, newPath string) error {
+			renames = append(renames, [2]string{oldPath, newPath})
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	expectedChanges := []AutoFixChange{
+		{Path: "ko.advisories.yaml", AdvisoryID: "CGA-2222-2222-2222", Description: "added missing aliases: CVE-2023-2222"},
+		{Path: "ko.advisories.yaml", AdvisoryID: "CGA-2222-2222-2222", Description: "sorted aliases"},
+		{Path: "ko.advisories.yaml", AdvisoryID: "CGA-2222-2222-2222", Description: "sorted events chronologically"},
+		{Path: "ko.advisories.yaml", AdvisoryID: "CGA-3333-3333-3333", Description: "sorted aliases"},
+		{Path: "ko.advisories.yaml", AdvisoryID: "CGA-3333-3333-3333", Description: "removed 1 duplicate event(s)"},
+		{Path: "wrong-name.advisories.yaml", Description: `renamed to "crane.advisories.yaml" to match package name`},
+	}
+	if diff := cmp.Diff(expectedChanges, changes); diff != "" {
+		t.Errorf("AutoFix() changes mismatch (-want +got):\n%s", diff)
+	}
+
+	expectedRenames := [][2]string{{"wrong-name.advisories.yaml", "crane.advisories.yaml"}}
+	if diff := cmp.Diff(expectedRenames, renames); diff != "" {
+		t.Errorf("AutoFix() renames mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := fsys.DiffAll(); diff != "" {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
@@ -37,7 +37,11 @@ func Create(ctx context.Context, req Request, opts CreateOptions) error {
 	switch count {
 	case 0:
 		// i.e. no advisories file for this package yet
-		return createAdvisoryConfig(ctx, opts.AdvisoryDocs, req)
+		if err := createAdvisoryConfig(ctx, opts.AdvisoryDocs, req); err != nil {
+			return err
+		}
+
+		return recordRequestProvenance(opts.AdvisoryDocs, req)
 
 	case 1:
 		// i.e. exactly one advisories file for this package
@@ -83,7 +87,7 @@ func Create(ctx context.Context, req Request, opts CreateOptions) error {
 			return fmt.Errorf("unable to update schema version for %q: %w", req.Package, err)
 		}
 
-		return nil
+		return recordRequestProvenance(opts.AdvisoryDocs, req)
 	}
 
 	return fmt.Errorf("cannot create advisory: found %d advisory documents for package %q", count, req.Package)
@@ -0,0 +1,432 @@
+package advisory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	vulnadvs "github.com/chainguard-dev/advisory-schema/pkg/vuln"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory/csaf"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/vuln"
+)
+
+// CSAFOptions contains the options for exporting advisory data as CSAF VEX
+// documents.
+type CSAFOptions struct {
+	// AdvisoryDocIndices is a list of indexes containing advisory documents. Data
+	// for the same package from multiple indices is merged (see
+	// MergeAdvisoryDocIndices), with indices listed earlier taking precedence.
+	AdvisoryDocIndices []*configs.Index[v2.Document]
+
+	// PublisherName is the name of the publisher of the CSAF documents, e.g.
+	// "Wolfi". It's also used as the vendor in each document's product tree.
+	PublisherName string
+
+	// PublisherNamespace is a URL that identifies the publisher, e.g.
+	// "https://wolfi.dev".
+	PublisherNamespace string
+
+	// PURLNamespace is the namespace used in the package URLs that identify
+	// products, e.g. "wolfi" in "pkg:apk/wolfi/ko".
+	PURLNamespace string
+}
+
+const (
+	csafSystemNameGHSA = "GitHub Security Advisory"
+	csafSystemNameCGA  = "Chainguard Security Advisory"
+	csafGeneratorName  = "wolfictl"
+)
+
+// ExportCSAF returns the advisory data as CSAF 2.0 VEX documents, one per
+// vulnerability. Advisories in different packages are considered to describe
+// the same vulnerability if they share a CVE ID (or, lacking one, a GHSA ID).
+//
+// Each package's status is determined by the latest event of its advisory:
+//
+//   - fixed: "fixed", for the fixed version of the package.
+//   - false-positive-determination: "known_not_affected", with a flag
+//     corresponding to the false positive type where there is one, and an
+//     impact statement.
+//   - true-positive-determination, pending-upstream-fix, fix-not-planned, and
+//     analysis-not-planned: "known_affected", with a remediation.
+//   - detection: "under_investigation".
+func ExportCSAF(ctx context.Context, opts CSAFOptions) ([]csaf.Document, error) {
+	docs, err := MergeAdvisoryDocIndices(ctx, opts.AdvisoryDocIndices, false)
+	if err != nil {
+		return nil, err
+	}
+
+	byVulnerability := make(map[string][]csafPackageAdvisory)
+	for _, doc := range docs {
+		for _, adv := range doc.Advisories {
+			if len(adv.Events) == 0 {
+				continue
+			}
+
+			key := csafVulnerabilityKey(adv)
+			byVulnerability[key] = append(byVulnerability[key], csafPackageAdvisory{
+				packageName: doc.Package.Name,
+				Advisory:    adv,
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(byVulnerability))
+	for k := range byVulnerability {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	csafDocs := make([]csaf.Document, 0, len(keys))
+	for _, key := range keys {
+		pkgAdvs := byVulnerability[key]
+		sort.SliceStable(pkgAdvs, func(i, j int) bool {
+			return pkgAdvs[i].packageName < pkgAdvs[j].packageName
+		})
+
+		csafDocs = append(csafDocs, opts.newCSAFDocument(key, pkgAdvs))
+	}
+
+	return csafDocs, nil
+}
+
+type csafPackageAdvisory struct {
+	v2.Advisory
+	packageName string
+}
+
+// csafVulnerabilityKey returns the ID that identifies the vulnerability
+// described by the advisory across packages: the first CVE alias, or else the
+// first GHSA alias, or else the advisory's own ID.
+func csafVulnerabilityKey(adv v2.Advisory) string {
+	aliases := make([]string, len(adv.Aliases))
+	copy(aliases, adv.Aliases)
+	sort.Strings(aliases)
+
+	for _, a := range aliases {
+		if vulnadvs.RegexCVE.MatchString(a) {
+			return a
+		}
+	}
+	for _, a := range aliases {
+		if vulnadvs.RegexGHSA.MatchString(a) {
+			return a
+		}
+	}
+
+	return adv.ID
+}
+
+func (opts CSAFOptions) newCSAFDocument(key string, pkgAdvs []csafPackageAdvisory) csaf.Document {
+	var (
+		first, last   time.Time
+		status        csaf.ProductStatus
+		flags         []csaf.Flag
+		threats       []csaf.Threat
+		remediations  []csaf.Remediation
+		packageBranch []csaf.Branch
+		seenPackages  = make(map[string]bool)
+		otherIDs      []string
+	)
+
+	for _, pa := range pkgAdvs {
+		latest := pa.Latest()
+		date := csafDate(latest.Timestamp)
+
+		productID := pa.packageName
+		branch := csaf.Branch{
+			Category: csaf.BranchCategoryProductName,
+			Name:     pa.packageName,
+		}
+
+		if fixed, ok := latest.Data.(v2.Fixed); ok && latest.Type == v2.EventTypeFixed {
+			productID = fmt.Sprintf("%s-%s", pa.packageName, fixed.FixedVersion)
+			branch.Branches = []csaf.Branch{{
+				Category: csaf.BranchCategoryProductVersion,
+				Name:     fixed.FixedVersion,
+				Product:  opts.csafProduct(productID, pa.packageName, fixed.FixedVersion),
+			}}
+		} else {
+			branch.Product = opts.csafProduct(productID, pa.packageName, "")
+		}
+
+		if seenPackages[pa.packageName] {
+			// Multiple advisories for the same package describe this vulnerability. The
+			// first one (by advisory ID) wins, so that the package only has one status,
+			// even if the advisories' product IDs differ (e.g. one is fixed).
+			continue
+		}
+		seenPackages[pa.packageName] = true
+		packageBranch = append(packageBranch, branch)
+
+		for _, id := range pa.VulnerabilityIDs() {
+			if id != key && !slices.Contains(otherIDs, id) {
+				otherIDs = append(otherIDs, id)
+			}
+		}
+
+		for _, e := range pa.Events {
+			t := time.Time(e.Timestamp)
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+
+		ids := []string{productID}
+
+		switch latest.Type {
+		case v2.EventTypeFixed:
+			status.Fixed = append(status.Fixed, productID)
+
+		case v2.EventTypeFalsePositiveDetermination:
+			status.KnownNotAffected = append(status.KnownNotAffected, productID)
+
+			fp, _ := latest.Data.(v2.FalsePositiveDetermination) //nolint:errcheck // The zero value is fine.
+			if label := csafFlagForFalsePositiveType(fp.Type); label != "" {
+				flags = append(flags, csaf.Flag{Label: label, Date: date, ProductIDs: ids})
+			}
+			threats = append(threats, csaf.Threat{
+				Category:   csaf.ThreatCategoryImpact,
+				Details:    csafDetails(fmt.Sprintf("%s is not affected (%s).", pa.packageName, fp.Type), fp.Note),
+				Date:       date,
+				ProductIDs: ids,
+			})
+
+		case v2.EventTypeTruePositiveDetermination:
+			status.KnownAffected = append(status.KnownAffected, productID)
+
+			tp, _ := latest.Data.(v2.TruePositiveDetermination) //nolint:errcheck // The zero value is fine.
+			remediations = append(remediations, csaf.Remediation{
+				Category:   csaf.RemediationCategoryNoneAvailable,
+				Details:    csafDetails("No fix is available yet.", tp.Note),
+				Date:       date,
+				ProductIDs: ids,
+			})
+
+		case v2.EventTypePendingUpstreamFix:
+			status.KnownAffected = append(status.KnownAffected, productID)
+
+			puf, _ := latest.Data.(v2.PendingUpstreamFix) //nolint:errcheck // The zero value is fine.
+			remediations = append(remediations, csaf.Remediation{
+				Category:   csaf.RemediationCategoryNoneAvailable,
+				Details:    csafDetails("A fix is pending upstream.", puf.Note),
+				Date:       date,
+				ProductIDs: ids,
+			})
+
+		case v2.EventTypeFixNotPlanned:
+			status.KnownAffected = append(status.KnownAffected, productID)
+
+			fnp, _ := latest.Data.(v2.FixNotPlanned) //nolint:errcheck // The zero value is fine.
+			remediations = append(remediations, csaf.Remediation{
+				Category:   csaf.RemediationCategoryNoFixPlanned,
+				Details:    csafDetails("A fix is not planned.", fnp.Note),
+				Date:       date,
+				ProductIDs: ids,
+			})
+
+		case v2.EventTypeAnalysisNotPlanned:
+			status.KnownAffected = append(status.KnownAffected, productID)
+
+			anp, _ := latest.Data.(v2.AnalysisNotPlanned) //nolint:errcheck // The zero value is fine.
+			remediations = append(remediations, csaf.Remediation{
+				Category:   csaf.RemediationCategoryNoFixPlanned,
+				Details:    csafDetails("Analysis is not planned.", anp.Note),
+				Date:       date,
+				ProductIDs: ids,
+			})
+
+		default:
+			status.UnderInvestigation = append(status.UnderInvestigation, productID)
+		}
+	}
+
+	sort.Strings(otherIDs)
+
+	v := csaf.Vulnerability{
+		Notes: []csaf.Note{{
+			Category: csaf.NoteCategoryGeneral,
+			Title:    "Status",
+			Text:     fmt.Sprintf("This document describes the status of %s in %s packages.", key, opts.PublisherName),
+		}},
+		ProductStatus: status,
+		Flags:         flags,
+		Threats:       threats,
+		Remediations:  remediations,
+	}
+
+	if vulnadvs.RegexCVE.MatchString(key) {
+		v.CVE = key
+	} else {
+		v.IDs = append(v.IDs, csafVulnerabilityID(key))
+	}
+	for _, id := range otherIDs {
+		if vulnadvs.RegexCVE.MatchString(id) {
+			// CSAF only allows a single CVE ID per vulnerability. Other CVE IDs are
+			// still linked as references below.
+			continue
+		}
+		v.IDs = append(v.IDs, csafVulnerabilityID(id))
+	}
+
+	for _, id := range append([]string{key}, otherIDs...) {
+		if u := vuln.URL(id); u != "" {
+			v.References = append(v.References, csaf.Reference{
+				Category: csaf.ReferenceCategoryExternal,
+				Summary:  id,
+				URL:      u,
+			})
+		}
+	}
+
+	return csaf.Document{
+		Document: csaf.DocumentMetadata{
+			Category:    csaf.CategoryVEX,
+			CSAFVersion: csaf.Version,
+			Publisher: csaf.Publisher{
+				Category:  csaf.PublisherCategoryVendor,
+				Name:      opts.PublisherName,
+				Namespace: opts.PublisherNamespace,
+			},
+			Title: fmt.Sprintf("%s VEX for %s", opts.PublisherName, key),
+			Tracking: csaf.Tracking{
+				ID:                 key,
+				Status:             csaf.TrackingStatusFinal,
+				Version:            "1",
+				InitialReleaseDate: csafDate(v2.Timestamp(first)),
+				CurrentReleaseDate: csafDate(v2.Timestamp(last)),
+				RevisionHistory: []csaf.Revision{{
+					Date:    csafDate(v2.Timestamp(last)),
+					Number:  "1",
+					Summary: "Generated from advisory data.",
+				}},
+				Generator: &csaf.Generator{
+					Engine: csaf.Engine{Name: csafGeneratorName},
+				},
+			},
+		},
+		ProductTree: csaf.ProductTree{
+			Branches: []csaf.Branch{{
+				Category: csaf.BranchCategoryVendor,
+				Name:     opts.PublisherName,
+				Branches: packageBranch,
+			}},
+		},
+		Vulnerabilities: []csaf.Vulnerability{v},
+	}
+}
+
+func (opts CSAFOptions) csafProduct(productID, packageName, version string) *csaf.FullProductName {
+	name := packageName
+	purl := fmt.Sprintf("pkg:apk/%s/%s", opts.PURLNamespace, packageName)
+	if version != "" {
+		name += " " + version
+		purl += "@" + version
+	}
+
+	return &csaf.FullProductName{
+		Name:      name,
+		ProductID: productID,
+		ProductIdentificationHelper: &csaf.ProductIdentificationHelper{
+			PURL: purl,
+		},
+	}
+}
+
+func csafVulnerabilityID(id string) csaf.VulnerabilityID {
+	systemName := csafSystemNameCGA
+	if vulnadvs.RegexGHSA.MatchString(id) {
+		systemName = csafSystemNameGHSA
+	}
+
+	return csaf.VulnerabilityID{SystemName: systemName, Text: id}
+}
+
+func csafFlagForFalsePositiveType(fpType string) string {
+	switch fpType {
+	case v2.FPTypeComponentVulnerabilityMismatch:
+		return csaf.FlagComponentNotPresent
+	case v2.FPTypeVulnerableCodeNotIncludedInPackage, v2.FPTypeVulnerableCodeVersionNotUsed:
+		return csaf.FlagVulnerableCodeNotPresent
+	case v2.FPTypeVulnerableCodeNotInExecutionPath:
+		return csaf.FlagVulnerableCodeNotInExecutePath
+	case v2.FPTypeVulnerableCodeCannotBeControlledByAdversary:
+		return csaf.FlagVulnerableCodeCannotBeControlledByAdversary
+	case v2.FPTypeInlineMitigationsExist:
+		return csaf.FlagInlineMitigationsAlreadyExist
+	}
+
+	// Notably, there's no flag for a contested vulnerability record, so the impact
+	// statement is the only justification.
+	return ""
+}
+
+func csafDetails(summary, note string) string {
+	if note == "" {
+		return summary
+	}
+
+	return summary + " " + note
+}
+
+func csafDate(t v2.Timestamp) string {
+	return time.Time(t).UTC().Format(time.RFC3339)
+}
+
+var csafUnsafeFileNameChars = regexp.MustCompile(`[^+\-a-z0-9]+`)
+
+// CSAFFileName returns the file name for the CSAF document, derived from its
+// tracking ID as required by the CSAF specification.
+func CSAFFileName(doc csaf.Document) string {
+	return csafUnsafeFileNameChars.ReplaceAllString(strings.ToLower(doc.Document.Tracking.ID), "_") + ".json"
+}
+
+// WriteCSAFDocuments writes each CSAF document to its own file in the given
+// directory, along with an index.txt file listing the documents' file names, as
+// expected of a CSAF provider's directory.
+func WriteCSAFDocuments(dir string, docs []csaf.Document) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating CSAF output directory: %w", err)
+	}
+
+	fileNames := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		name := CSAFFileName(doc)
+		fileNames = append(fileNames, name)
+
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("creating file for CSAF document %q: %w", doc.Document.Tracking.ID, err)
+		}
+
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(doc)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("encoding CSAF document %q to JSON: %w", doc.Document.Tracking.ID, err)
+		}
+	}
+
+	index := strings.Join(fileNames, "\n")
+	if index != "" {
+		index += "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.txt"), []byte(index), 0o644); err != nil { //nolint:gosec // The index is meant to be readable by all.
+		return fmt.Errorf("writing CSAF index: %w", err)
+	}
+
+	return nil
+}
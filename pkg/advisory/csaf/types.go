@@ -0,0 +1,160 @@
+// Package csaf defines the subset of the CSAF 2.0 document format
+// (https://docs.oasis-open.org/csaf/csaf/v2.0/csaf-v2.0.html) that's needed to
+// publish advisory data as VEX documents.
+package csaf
+
+const (
+	Version = "2.0"
+
+	CategoryVEX = "csaf_vex"
+
+	PublisherCategoryVendor = "vendor"
+
+	TrackingStatusFinal = "final"
+
+	BranchCategoryVendor         = "vendor"
+	BranchCategoryProductName    = "product_name"
+	BranchCategoryProductVersion = "product_version"
+
+	NoteCategoryGeneral = "general"
+
+	ThreatCategoryImpact = "impact"
+
+	RemediationCategoryVendorFix     = "vendor_fix"
+	RemediationCategoryNoFixPlanned  = "no_fix_planned"
+	RemediationCategoryNoneAvailable = "none_available"
+
+	ReferenceCategoryExternal = "external"
+)
+
+// Flag labels, used to justify a product's "known_not_affected" status.
+const (
+	FlagComponentNotPresent                         = "component_not_present"
+	FlagVulnerableCodeNotPresent                    = "vulnerable_code_not_present"
+	FlagVulnerableCodeCannotBeControlledByAdversary = "vulnerable_code_cannot_be_controlled_by_adversary"
+	FlagVulnerableCodeNotInExecutePath              = "vulnerable_code_not_in_execute_path"
+	FlagInlineMitigationsAlreadyExist               = "inline_mitigations_already_exist"
+)
+
+type Document struct {
+	Document        DocumentMetadata `json:"document"`
+	ProductTree     ProductTree      `json:"product_tree"`
+	Vulnerabilities []Vulnerability  `json:"vulnerabilities"`
+}
+
+type DocumentMetadata struct {
+	Category    string    `json:"category"`
+	CSAFVersion string    `json:"csaf_version"`
+	Publisher   Publisher `json:"publisher"`
+	Title       string    `json:"title"`
+	Tracking    Tracking  `json:"tracking"`
+}
+
+type Publisher struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type Tracking struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status"`
+	Version            string     `json:"version"`
+	InitialReleaseDate string     `json:"initial_release_date"`
+	CurrentReleaseDate string     `json:"current_release_date"`
+	RevisionHistory    []Revision `json:"revision_history"`
+	Generator          *Generator `json:"generator,omitempty"`
+}
+
+type Revision struct {
+	Date    string `json:"date"`
+	Number  string `json:"number"`
+	Summary string `json:"summary"`
+}
+
+type Generator struct {
+	Engine Engine `json:"engine"`
+}
+
+type Engine struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type ProductTree struct {
+	Branches []Branch `json:"branches"`
+}
+
+// Branch is a node in the product tree. It has either child Branches or a
+// Product, but not both.
+type Branch struct {
+	Category string           `json:"category"`
+	Name     string           `json:"name"`
+	Branches []Branch         `json:"branches,omitempty"`
+	Product  *FullProductName `json:"product,omitempty"`
+}
+
+type FullProductName struct {
+	Name                        string                       `json:"name"`
+	ProductID                   string                       `json:"product_id"`
+	ProductIdentificationHelper *ProductIdentificationHelper `json:"product_identification_helper,omitempty"`
+}
+
+type ProductIdentificationHelper struct {
+	PURL string `json:"purl,omitempty"`
+}
+
+type Vulnerability struct {
+	CVE           string            `json:"cve,omitempty"`
+	IDs           []VulnerabilityID `json:"ids,omitempty"`
+	Notes         []Note            `json:"notes"`
+	ProductStatus ProductStatus     `json:"product_status"`
+	Flags         []Flag            `json:"flags,omitempty"`
+	Threats       []Threat          `json:"threats,omitempty"`
+	Remediations  []Remediation     `json:"remediations,omitempty"`
+	References    []Reference       `json:"references,omitempty"`
+}
+
+type VulnerabilityID struct {
+	SystemName string `json:"system_name"`
+	Text       string `json:"text"`
+}
+
+type Note struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+	Title    string `json:"title,omitempty"`
+}
+
+type ProductStatus struct {
+	Fixed              []string `json:"fixed,omitempty"`
+	KnownAffected      []string `json:"known_affected,omitempty"`
+	KnownNotAffected   []string `json:"known_not_affected,omitempty"`
+	UnderInvestigation []string `json:"under_investigation,omitempty"`
+}
+
+type Flag struct {
+	Label      string   `json:"label"`
+	Date       string   `json:"date,omitempty"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+type Threat struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	Date       string   `json:"date,omitempty"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+type Remediation struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	Date       string   `json:"date,omitempty"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+type Reference struct {
+	Category string `json:"category"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
@@ -0,0 +1,173 @@
+package advisory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory/csaf"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+)
+
+func TestExportCSAF(t *testing.T) {
+	const expectedDir = "testdata/csaf/expected"
+
+	advisoryDocs, err := adv2.NewIndex(context.Background(), rwos.DirFS("testdata/csaf/advisories"))
+	if err != nil {
+		t.Fatalf("unable to create advisory docs index: %v", err)
+	}
+
+	docs, err := ExportCSAF(context.Background(), CSAFOptions{
+		AdvisoryDocIndices: []*configs.Index[v2.Document]{advisoryDocs},
+		PublisherName:      "Wolfi",
+		PublisherNamespace: "https://wolfi.dev",
+		PURLNamespace:      "wolfi",
+	})
+	if err != nil {
+		t.Fatalf("ExportCSAF() error = %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if *update {
+		outputDir = expectedDir
+	}
+
+	if err := WriteCSAFDocuments(outputDir, docs); err != nil {
+		t.Fatalf("WriteCSAFDocuments() error = %v", err)
+	}
+
+	expectedFiles, err := os.ReadDir(expectedDir)
+	if err != nil {
+		t.Fatalf("reading expected directory: %v", err)
+	}
+	actualFiles, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("reading output directory: %v", err)
+	}
+	if len(actualFiles) != len(expectedFiles) {
+		t.Errorf("wrote %d files, want %d", len(actualFiles), len(expectedFiles))
+	}
+
+	for _, f := range expectedFiles {
+		expected, err := os.ReadFile(filepath.Join(expectedDir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := os.ReadFile(filepath.Join(outputDir, f.Name()))
+		if err != nil {
+			t.Errorf("expected file %s was not written: %v", f.Name(), err)
+			continue
+		}
+
+		if diff := cmp.Diff(string(expected), string(actual)); diff != "" {
+			t.Errorf("unexpected content for %s (-want +got):\n%s", f.Name(), diff)
+		}
+	}
+}
+
+func TestCSAFFlagForFalsePositiveType(t *testing.T) {
+	cases := []struct {
+		fpType   string
+		expected string
+	}{
+		{v2.FPTypeVulnerableCodeNotIncludedInPackage, csaf.FlagVulnerableCodeNotPresent},
+		{v2.FPTypeVulnerableCodeVersionNotUsed, csaf.FlagVulnerableCodeNotPresent},
+		{v2.FPTypeVulnerableCodeNotInExecutionPath, csaf.FlagVulnerableCodeNotInExecutePath},
+		{v2.FPTypeVulnerableCodeCannotBeControlledByAdversary, csaf.FlagVulnerableCodeCannotBeControlledByAdversary},
+		{v2.FPTypeInlineMitigationsExist, csaf.FlagInlineMitigationsAlreadyExist},
+		{v2.FPTypeComponentVulnerabilityMismatch, csaf.FlagComponentNotPresent},
+		{v2.FPTypeVulnerabilityRecordAnalysisContested, ""},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.fpType, func(t *testing.T) {
+			if got := csafFlagForFalsePositiveType(tt.fpType); got != tt.expected {
+				t.Errorf("csafFlagForFalsePositiveType(%q) = %q, want %q", tt.fpType, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewCSAFDocument_DuplicateAdvisories(t *testing.T) {
+	day := func(d int) v2.Timestamp {
+		return v2.Timestamp(time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC))
+	}
+
+	opts := CSAFOptions{PublisherName: "Wolfi", PublisherNamespace: "https://wolfi.dev", PURLNamespace: "wolfi"}
+	doc := opts.newCSAFDocument("CVE-2024-1234", []csafPackageAdvisory{
+		{
+			packageName: "ko",
+			Advisory: v2.Advisory{
+				ID:      "CGA-2222-2222-2222",
+				Aliases: []string{"CVE-2024-1234"},
+				Events:  []v2.Event{{Timestamp: day(2), Type: v2.EventTypeDetection}},
+			},
+		},
+		{
+			// A second advisory for the same package contributes nothing.
+			packageName: "ko",
+			Advisory: v2.Advisory{
+				ID:      "CGA-3333-3333-3333",
+				Aliases: []string{"CVE-2024-1234", "GHSA-2222-2222-2222"},
+				Events:  []v2.Event{{Timestamp: day(1), Type: v2.EventTypeDetection}},
+			},
+		},
+	})
+
+	if got, want := doc.Document.Tracking.InitialReleaseDate, csafDate(day(2)); got != want {
+		t.Errorf("InitialReleaseDate = %q, want %q", got, want)
+	}
+
+	v := doc.Vulnerabilities[0]
+	for _, id := range v.IDs {
+		if id.Text == "GHSA-2222-2222-2222" {
+			t.Errorf("unexpected vulnerability ID %q from duplicate advisory", id.Text)
+		}
+	}
+	for _, ref := range v.References {
+		if ref.Summary == "GHSA-2222-2222-2222" {
+			t.Errorf("unexpected reference %q from duplicate advisory", ref.Summary)
+		}
+	}
+}
+
+func TestNewCSAFDocument_DuplicateAdvisoriesWithDifferentStatuses(t *testing.T) {
+	ts := v2.Timestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := CSAFOptions{PublisherName: "Wolfi", PublisherNamespace: "https://wolfi.dev", PURLNamespace: "wolfi"}
+	doc := opts.newCSAFDocument("CVE-2024-1234", []csafPackageAdvisory{
+		{
+			packageName: "ko",
+			Advisory: v2.Advisory{
+				ID:      "CGA-2222-2222-2222",
+				Aliases: []string{"CVE-2024-1234"},
+				Events:  []v2.Event{{Timestamp: ts, Type: v2.EventTypeTruePositiveDetermination, Data: v2.TruePositiveDetermination{}}},
+			},
+		},
+		{
+			// The second advisory's product ID differs, but the package still only
+			// gets the first advisory's status.
+			packageName: "ko",
+			Advisory: v2.Advisory{
+				ID:      "CGA-3333-3333-3333",
+				Aliases: []string{"CVE-2024-1234"},
+				Events:  []v2.Event{{Timestamp: ts, Type: v2.EventTypeFixed, Data: v2.Fixed{FixedVersion: "1.2.3-r0"}}},
+			},
+		},
+	})
+
+	status := doc.Vulnerabilities[0].ProductStatus
+	if diff := cmp.Diff([]string{"ko"}, status.KnownAffected); diff != "" {
+		t.Errorf("unexpected known affected products (-want +got):\n%s", diff)
+	}
+	if len(status.Fixed) != 0 {
+		t.Errorf("unexpected fixed products %v", status.Fixed)
+	}
+}
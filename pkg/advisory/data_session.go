@@ -23,9 +23,11 @@ type DataSession struct {
 	tempDir          string
 	repo             *git.Repository
 	workingBranch    string
-	distro           distro.Distro
+	baseBranch       string
+	baseCommit       plumbing.Hash
+	remote           DataSessionRemote
+	reviewRequester  ReviewRequester
 	index            *configs.Index[v2.Document]
-	githubClient     *github.Client
 	modified         bool
 	modifiedPackages []string
 }
@@ -33,12 +35,26 @@ type DataSession struct {
 type DataSessionOptions struct {
 	Distro       distro.Distro
 	GitHubClient *github.Client
+
+	// Remote is the repository from which advisory data is cloned and to which
+	// changes are pushed. If nil, the distro's advisories repository on GitHub is
+	// used.
+	Remote DataSessionRemote
+
+	// ReviewRequester submits the session's changes for review. If nil, a pull
+	// request is opened on GitHub using GitHubClient.
+	ReviewRequester ReviewRequester
 }
 
 // NewDataSession initializes a new advisory data session for the specified
 // distro and returns a reference to the session. This call will retrieve the
 // data and manage it in a local temp directory until the session is closed. The
 // session should be closed by calling Close() when it is no longer needed.
+//
+// By default, the session uses the distro's advisories repository on GitHub.
+// Callers can specify a different Remote and ReviewRequester in the options,
+// for example to work against a local bare repository and to produce a patch
+// series instead of a pull request.
 func NewDataSession(ctx context.Context, opts DataSessionOptions) (*DataSession, error) {
 	// create temp directory
 	tempDir, err := os.MkdirTemp("", "wolfictl-advisory-data-session-*")
@@ -46,22 +62,31 @@ func NewDataSession(ctx context.Context, opts DataSessionOptions) (*DataSession,
 		return nil, fmt.Errorf("creating temp dir: %w", err)
 	}
 	ds := &DataSession{
-		tempDir: tempDir,
-		distro:  opts.Distro,
+		tempDir:         tempDir,
+		remote:          opts.Remote,
+		reviewRequester: opts.ReviewRequester,
 	}
 
-	ds.githubClient = opts.GitHubClient
+	if ds.remote == nil {
+		ds.remote = GitHubRemote{Distro: opts.Distro}
+	}
 
-	gitURL := opts.Distro.Absolute.AdvisoriesHTTPSCloneURL()
+	if ds.reviewRequester == nil {
+		ds.reviewRequester = GitHubReviewRequester{
+			Client: opts.GitHubClient,
+			Owner:  opts.Distro.Absolute.DistroRepoOwner,
+			Repo:   opts.Distro.Absolute.DistroAdvisoriesRepo,
+		}
+	}
 
-	gitAuth, err := wgit.GetGitAuth(gitURL)
+	gitAuth, err := ds.remote.Auth()
 	if err != nil {
 		return nil, fmt.Errorf("getting git auth: %w", err)
 	}
 
 	// clone advisories repo
 	repo, err := git.PlainCloneContext(ctx, tempDir, false, &git.CloneOptions{
-		URL:  gitURL,
+		URL:  ds.remote.URL(),
 		Auth: gitAuth,
 	})
 	if err != nil {
@@ -69,6 +94,14 @@ func NewDataSession(ctx context.Context, opts DataSessionOptions) (*DataSession,
 	}
 	ds.repo = repo
 
+	// remember where we started, so that we can describe our changes later
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("getting HEAD of cloned advisories repo: %w", err)
+	}
+	ds.baseBranch = head.Name().Short()
+	ds.baseCommit = head.Hash()
+
 	// checkout a new branch
 	u := uuid.New()
 	branchName := fmt.Sprintf("wolfictl-data-session-%s", u)
@@ -144,16 +177,60 @@ func (ds *DataSession) Update(ctx context.Context, req Request) error {
 // Append creates a new event for an advisory if the advisory already exists, or
 // creates a new advisory with the event if the advisory does not already exist.
 func (ds *DataSession) Append(ctx context.Context, req Request) error {
-	packageSelection := ds.index.Select().WhereName(req.Package)
-	if packageSelection.Len() == 0 {
+	if !ds.advisoryExists(req) {
 		return ds.Create(ctx, req)
 	}
 
-	if _, exists := packageSelection.Configurations()[0].Advisories.GetByAnyVulnerability(req.VulnerabilityIDs()...); !exists {
-		return ds.Create(ctx, req)
+	return ds.Update(ctx, req)
+}
+
+// AppendAll applies each of the requests in the same way as Append, but
+// records all of the resulting changes in a single commit with the given
+// message.
+func (ds *DataSession) AppendAll(ctx context.Context, reqs []Request, message string) error {
+	if len(reqs) == 0 {
+		return nil
 	}
 
-	return ds.Update(ctx, req)
+	for _, req := range reqs {
+		var err error
+		if ds.advisoryExists(req) {
+			err = Update(ctx, req, UpdateOptions{
+				AdvisoryDocs: ds.index,
+			})
+		} else {
+			err = Create(ctx, req, CreateOptions{
+				AdvisoryDocs: ds.index,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("applying advisory request for %q: %w", req.Package, err)
+		}
+	}
+
+	err := ds.commitChanges(message, reqs)
+	if err != nil {
+		return fmt.Errorf("committing advisory changes: %w", err)
+	}
+
+	ds.modified = true
+	for _, req := range reqs {
+		ds.modifiedPackages = append(ds.modifiedPackages, req.Package)
+	}
+
+	return nil
+}
+
+// advisoryExists returns true if the session's advisory data already has an
+// advisory for the request's package and vulnerability.
+func (ds DataSession) advisoryExists(req Request) bool {
+	packageSelection := ds.index.Select().WhereName(req.Package)
+	if packageSelection.Len() == 0 {
+		return false
+	}
+
+	_, exists := packageSelection.Configurations()[0].Advisories.GetByAnyVulnerability(req.VulnerabilityIDs()...)
+	return exists
 }
 
 // Dir returns the path to the temporary directory where the session's advisory
@@ -176,14 +253,13 @@ func (ds DataSession) Modified() bool {
 // Push pushes the changes made during the session to the remote advisories
 // repository.
 func (ds DataSession) Push(ctx context.Context) error {
-	gitURL := ds.distro.Absolute.AdvisoriesHTTPSCloneURL()
-	gitAuth, err := wgit.GetGitAuth(gitURL)
+	gitAuth, err := ds.remote.Auth()
 	if err != nil {
 		return fmt.Errorf("getting git auth: %w", err)
 	}
 
 	err = ds.repo.PushContext(ctx, &git.PushOptions{
-		RemoteURL: gitURL,
+		RemoteURL: ds.remote.URL(),
 		Auth:      gitAuth,
 	})
 	if err != nil {
@@ -193,38 +269,36 @@ func (ds DataSession) Push(ctx context.Context) error {
 	return nil
 }
 
-// OpenPullRequest opens a pull request for the changes made during the session.
+// OpenPullRequest submits the changes made during the session for review,
+// using the session's ReviewRequester. By default, this opens a pull request on
+// GitHub.
 func (ds DataSession) OpenPullRequest(ctx context.Context) (*PullRequest, error) {
-	slices.Sort(ds.modifiedPackages)
-	compact := slices.Compact(ds.modifiedPackages)
-	newPullRequest := github.NewPullRequest{
-		Title:               github.String(fmt.Sprintf("Add advisory data for %s", strings.Join(compact, ", "))),
-		Body:                github.String(pullRequestBody),
-		Head:                github.String(ds.workingBranch),
-		Base:                github.String("main"),
-		MaintainerCanModify: github.Bool(true),
-	}
-
-	pullRequest, _, err := ds.githubClient.PullRequests.Create(
-		ctx,
-		ds.distro.Absolute.DistroRepoOwner,
-		ds.distro.Absolute.DistroAdvisoriesRepo,
-		&newPullRequest,
-	)
+	modifiedPackages := slices.Clone(ds.modifiedPackages)
+	slices.Sort(modifiedPackages)
+	compact := slices.Compact(modifiedPackages)
+
+	pr, err := ds.reviewRequester.RequestReview(ctx, ReviewRequest{
+		Repository: ds.repo,
+		HeadBranch: ds.workingBranch,
+		BaseBranch: ds.baseBranch,
+		BaseCommit: ds.baseCommit,
+		Title:      fmt.Sprintf("Add advisory data for %s", strings.Join(compact, ", ")),
+		Body:       pullRequestBody,
+		Push:       ds.Push,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("creating pull request on GitHub: %w", err)
-	}
-
-	pr := PullRequest{
-		URL: pullRequest.GetHTMLURL(),
+		return nil, fmt.Errorf("requesting review: %w", err)
 	}
 
-	return &pr, nil
+	return pr, nil
 }
 
 const pullRequestBody = "This PR was created using the `wolfictl adv guide` command."
 
+// PullRequest is a reference to a DataSession's changes submitted for review.
 type PullRequest struct {
+	// URL is where the review can be found. For GitHub pull requests, this is the
+	// pull request's web URL.
 	URL string
 }
 
@@ -237,15 +311,31 @@ func (ds DataSession) commit(_ context.Context, req Request, operation string) e
 		req.AdvisoryID,
 	)
 
+	return ds.commitChanges(commitMessage, []Request{req})
+}
+
+// commitChanges creates a single commit in the advisory repo that includes the
+// changes made for all of the given requests.
+func (ds DataSession) commitChanges(commitMessage string, reqs []Request) error {
 	wt, err := ds.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("getting worktree: %w", err)
 	}
-	err = wt.AddGlob(fmt.Sprintf("%s.advisories.yaml", req.Package))
-	if err != nil {
-		return fmt.Errorf("staging changes: %w", err)
+	for _, req := range reqs {
+		err = wt.AddGlob(fmt.Sprintf("%s.advisories.yaml", req.Package))
+		if err != nil {
+			return fmt.Errorf("staging changes: %w", err)
+		}
+		if req.Provenance != nil {
+			_, err = wt.Add(ProvenancePath(req.Package))
+			if err != nil {
+				return fmt.Errorf("staging provenance changes: %w", err)
+			}
+		}
 	}
-	_, err = wt.Commit(commitMessage, &git.CommitOptions{})
+	_, err = wt.Commit(commitMessage, &git.CommitOptions{
+		Author: wgit.GetGitAuthorSignature(),
+	})
 	if err != nil {
 		return fmt.Errorf("creating commit: %w", err)
 	}
@@ -0,0 +1,259 @@
+package advisory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-github/v58/github"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+	wgit "github.com/wolfi-dev/wolfictl/pkg/git"
+)
+
+// DataSessionRemote is the git repository from which a DataSession retrieves
+// advisory data, and to which the session's changes are pushed.
+type DataSessionRemote interface {
+	// URL returns the URL used to clone from and push to the remote.
+	URL() string
+
+	// Auth returns the authentication method to use for the remote, or nil if no
+	// authentication is needed.
+	Auth() (transport.AuthMethod, error)
+}
+
+// GitHubRemote is a DataSessionRemote for the distro's advisories repository
+// on GitHub, accessed over HTTPS.
+type GitHubRemote struct {
+	Distro distro.Distro
+}
+
+func (r GitHubRemote) URL() string {
+	return r.Distro.Absolute.AdvisoriesHTTPSCloneURL()
+}
+
+func (r GitHubRemote) Auth() (transport.AuthMethod, error) {
+	auth, err := wgit.GetGitAuth(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	// Avoid returning a non-nil interface that holds a nil pointer.
+	if auth == nil {
+		return nil, nil
+	}
+
+	return auth, nil
+}
+
+// LocalRemote is a DataSessionRemote for a git repository (typically a bare
+// repository) on the local filesystem.
+type LocalRemote struct {
+	// Path is the filesystem path to the repository.
+	Path string
+}
+
+func (r LocalRemote) URL() string {
+	p, err := filepath.Abs(r.Path)
+	if err != nil {
+		p = r.Path
+	}
+
+	return "file://" + filepath.ToSlash(p)
+}
+
+func (r LocalRemote) Auth() (transport.AuthMethod, error) {
+	return nil, nil
+}
+
+// ReviewRequest describes the changes made during a DataSession that should be
+// submitted for review.
+type ReviewRequest struct {
+	// Repository is the session's local git repository.
+	Repository *git.Repository
+
+	// HeadBranch is the branch containing the session's changes.
+	HeadBranch string
+
+	// BaseBranch is the branch the changes should be merged into.
+	BaseBranch string
+
+	// BaseCommit is the commit from which HeadBranch diverged.
+	BaseCommit plumbing.Hash
+
+	// Title and Body describe the changes for reviewers.
+	Title, Body string
+
+	// Push pushes HeadBranch to the session's remote, for ReviewRequesters that
+	// need the changes to be on the remote.
+	Push func(ctx context.Context) error
+}
+
+// ReviewRequester submits a DataSession's changes for review, for example by
+// opening a pull request.
+type ReviewRequester interface {
+	// RequestReview submits the changes described by the ReviewRequest for review,
+	// and returns a reference to where the review can be found.
+	RequestReview(ctx context.Context, req ReviewRequest) (*PullRequest, error)
+}
+
+// GitHubReviewRequester is a ReviewRequester that pushes the session's changes
+// and then opens a pull request for them on GitHub.
+type GitHubReviewRequester struct {
+	Client *github.Client
+
+	// Owner and Repo identify the GitHub repository in which to open the pull
+	// request.
+	Owner, Repo string
+}
+
+func (r GitHubReviewRequester) RequestReview(ctx context.Context, req ReviewRequest) (*PullRequest, error) {
+	if err := req.Push(ctx); err != nil {
+		return nil, err
+	}
+
+	newPullRequest := github.NewPullRequest{
+		Title:               github.String(req.Title),
+		Body:                github.String(req.Body),
+		Head:                github.String(req.HeadBranch),
+		Base:                github.String(req.BaseBranch),
+		MaintainerCanModify: github.Bool(true),
+	}
+
+	pullRequest, _, err := r.Client.PullRequests.Create(ctx, r.Owner, r.Repo, &newPullRequest)
+	if err != nil {
+		return nil, fmt.Errorf("creating pull request on GitHub: %w", err)
+	}
+
+	return &PullRequest{
+		URL: pullRequest.GetHTMLURL(),
+	}, nil
+}
+
+// PatchSeriesReviewRequester is a ReviewRequester that writes the session's
+// commits as a series of patch files (in the format produced by "git
+// format-patch") to a directory, instead of opening a pull request. This
+// supports review workflows that operate on patches rather than on pushed
+// branches, so the session's remote is never pushed to.
+type PatchSeriesReviewRequester struct {
+	// Dir is the directory to which patch files are written. It is created if it
+	// doesn't exist.
+	Dir string
+}
+
+func (r PatchSeriesReviewRequester) RequestReview(_ context.Context, req ReviewRequest) (*PullRequest, error) {
+	commits, err := commitsSince(req.Repository, req.BaseCommit)
+	if err != nil {
+		return nil, fmt.Errorf("finding commits to include in patch series: %w", err)
+	}
+
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating patch series directory: %w", err)
+	}
+
+	for i, c := range commits {
+		patch, err := formatPatch(c, i+1, len(commits))
+		if err != nil {
+			return nil, fmt.Errorf("formatting patch for commit %s: %w", c.Hash, err)
+		}
+
+		name := fmt.Sprintf("%04d-%s.patch", i+1, patchFileSlug(c.Message))
+		if err := os.WriteFile(filepath.Join(r.Dir, name), []byte(patch), 0o644); err != nil { //nolint:gosec
+			return nil, fmt.Errorf("writing patch file %q: %w", name, err)
+		}
+	}
+
+	dir, err := filepath.Abs(r.Dir)
+	if err != nil {
+		dir = r.Dir
+	}
+
+	return &PullRequest{
+		URL: "file://" + filepath.ToSlash(dir),
+	}, nil
+}
+
+// commitsSince returns the commits reachable from HEAD but not from base, in
+// the order they were made. Only linear history is supported, which is what a
+// DataSession produces.
+func commitsSince(repo *git.Repository, base plumbing.Hash) ([]*object.Commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("getting HEAD: %w", err)
+	}
+
+	var commits []*object.Commit
+
+	c, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("getting HEAD commit: %w", err)
+	}
+
+	for c.Hash != base {
+		commits = append([]*object.Commit{c}, commits...)
+
+		if c.NumParents() == 0 {
+			return nil, fmt.Errorf("base commit %s is not an ancestor of HEAD", base)
+		}
+
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("getting parent of commit %s: %w", c.Hash, err)
+		}
+		c = parent
+	}
+
+	return commits, nil
+}
+
+// formatPatch renders the given commit as an mbox-formatted patch, suitable for
+// use with "git am".
+func formatPatch(c *object.Commit, n, total int) (string, error) {
+	parent, err := c.Parent(0)
+	if err != nil {
+		return "", fmt.Errorf("getting parent commit: %w", err)
+	}
+
+	patch, err := parent.Patch(c)
+	if err != nil {
+		return "", fmt.Errorf("computing diff: %w", err)
+	}
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "From %s Mon Sep 17 00:00:00 2001\n", c.Hash)
+	fmt.Fprintf(sb, "From: %s <%s>\n", c.Author.Name, c.Author.Email)
+	fmt.Fprintf(sb, "Date: %s\n", c.Author.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"))
+	fmt.Fprintf(sb, "Subject: [PATCH %d/%d] %s\n\n", n, total, subject)
+	if body = strings.TrimSpace(body); body != "" {
+		fmt.Fprintf(sb, "%s\n\n", body)
+	}
+	fmt.Fprintf(sb, "---\n%s\n", patch.Stats().String())
+	sb.WriteString(patch.String())
+	sb.WriteString("-- \nwolfictl\n\n")
+
+	return sb.String(), nil
+}
+
+var patchFileSlugUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+
+// patchFileSlug returns a file-name-safe rendering of the commit message's
+// subject line, following the conventions of "git format-patch".
+func patchFileSlug(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	slug := strings.Trim(patchFileSlugUnsafeChars.ReplaceAllString(subject, "-"), "-.")
+
+	const maxLen = 52
+	if len(slug) > maxLen {
+		slug = strings.TrimRight(slug[:maxLen], "-.")
+	}
+
+	return slug
+}
@@ -0,0 +1,173 @@
+package advisory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSession_LocalRemoteAndPatchSeries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("GIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jane@doe.org")
+
+	bareDir := setupBareAdvisoriesRepo(t)
+	patchDir := filepath.Join(t.TempDir(), "patches")
+
+	ds, err := NewDataSession(ctx, DataSessionOptions{
+		Remote:          LocalRemote{Path: bareDir},
+		ReviewRequester: PatchSeriesReviewRequester{Dir: patchDir},
+	})
+	require.NoError(t, err)
+	defer ds.Close()
+
+	err = ds.Create(ctx, Request{
+		Package: "ko",
+		Aliases: []string{"CVE-2023-1234"},
+		Event: v2.Event{
+			Timestamp: v2.Timestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			Type:      v2.EventTypeDetection,
+			Data: v2.Detection{
+				Type: v2.DetectionTypeManual,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, ds.Modified())
+
+	pr, err := ds.OpenPullRequest(ctx)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pr.URL, "file://"))
+
+	bareRepo, err := git.PlainOpen(bareDir)
+	require.NoError(t, err)
+	_, err = bareRepo.Reference(plumbing.NewBranchReferenceName(ds.workingBranch), true)
+	assert.ErrorIs(t, err, plumbing.ErrReferenceNotFound, "a patch series shouldn't push to the remote")
+
+	require.NoError(t, ds.Push(ctx))
+	_, err = bareRepo.Reference(plumbing.NewBranchReferenceName(ds.workingBranch), true)
+	assert.NoError(t, err, "working branch should have been pushed to the local remote")
+
+	patchFiles, err := filepath.Glob(filepath.Join(patchDir, "*.patch"))
+	require.NoError(t, err)
+	require.Len(t, patchFiles, 1)
+	assert.Equal(t, "0001-ko-create-advisory.patch", filepath.Base(patchFiles[0]))
+
+	patch, err := os.ReadFile(patchFiles[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(patch), "From: Jane Doe <jane@doe.org>")
+	assert.Contains(t, string(patch), "Subject: [PATCH 1/1] ko: create advisory")
+	assert.Contains(t, string(patch), "+++ b/ko.advisories.yaml")
+}
+
+func TestGitHubReviewRequester_PushesFirst(t *testing.T) {
+	pushErr := errors.New("push failed")
+
+	// The pull request isn't opened when the changes can't be pushed, so no
+	// GitHub client is needed.
+	_, err := GitHubReviewRequester{}.RequestReview(context.Background(), ReviewRequest{
+		Push: func(context.Context) error { return pushErr },
+	})
+	assert.ErrorIs(t, err, pushErr)
+}
+
+func TestDataSession_AppendAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("GIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jane@doe.org")
+
+	ds, err := NewDataSession(ctx, DataSessionOptions{
+		Remote: LocalRemote{Path: setupBareAdvisoriesRepo(t)},
+	})
+	require.NoError(t, err)
+	defer ds.Close()
+
+	detection := v2.Event{
+		Timestamp: v2.Timestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Type:      v2.EventTypeDetection,
+		Data: v2.Detection{
+			Type: v2.DetectionTypeManual,
+		},
+	}
+
+	err = ds.AppendAll(ctx, []Request{
+		{Package: "ko", Aliases: []string{"CVE-2023-1234"}, Event: detection},
+		{Package: "ko", Aliases: []string{"CVE-2023-5678"}, Event: detection},
+		{Package: "crane", Aliases: []string{"CVE-2023-1234"}, Event: detection},
+	}, "watch: 3 detection(s)")
+	require.NoError(t, err)
+	assert.True(t, ds.Modified())
+
+	head, err := ds.repo.Head()
+	require.NoError(t, err)
+	commit, err := ds.repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, "watch: 3 detection(s)", commit.Message)
+	require.Equal(t, 1, commit.NumParents())
+	parent, err := commit.Parent(0)
+	require.NoError(t, err)
+	assert.Equal(t, ds.baseCommit, parent.Hash, "all requests should be recorded in a single commit")
+
+	ko, err := ds.Index().Select().WhereName("ko").First()
+	require.NoError(t, err)
+	assert.Len(t, ko.Configuration().Advisories, 2)
+}
+
+func TestPatchFileSlug(t *testing.T) {
+	cases := []struct {
+		message  string
+		expected string
+	}{
+		{"ko: create advisory CGA-2222-2222-2222", "ko-create-advisory-CGA-2222-2222-2222"},
+		{"ko: update advisory \n\nmore detail", "ko-update-advisory"},
+		{strings.Repeat("a", 60), strings.Repeat("a", 52)},
+	}
+
+	for _, tt := range cases {
+		assert.Equal(t, tt.expected, patchFileSlug(tt.message))
+	}
+}
+
+// setupBareAdvisoriesRepo creates a bare git repository with a single commit,
+// and returns the path to the repository.
+func setupBareAdvisoriesRepo(t *testing.T) string {
+	t.Helper()
+
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "README.md"), []byte("advisories\n"), 0o600))
+
+	wt, err := src.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("README.md")
+	require.NoError(t, err)
+	_, err = wt.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	bareDir := t.TempDir()
+	_, err = git.PlainClone(bareDir, true, &git.CloneOptions{URL: srcDir})
+	require.NoError(t, err)
+
+	return bareDir
+}
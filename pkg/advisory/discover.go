@@ -38,6 +38,10 @@ type DiscoverOptions struct {
 
 	// VulnEvents is a channel of events that occur during vulnerability discovery.
 	VulnEvents chan<- interface{}
+
+	// Provenance, if set, is recorded as the provenance of each advisory that
+	// Discover creates.
+	Provenance *Provenance
 }
 
 // Discover searches for new vulnerabilities that match packages in a config
@@ -107,9 +111,10 @@ func (opts DiscoverOptions) discoverMatchesForPackage(ctx context.Context, pkg s
 	for i := range matches {
 		match := matches[i]
 		err := Create(ctx, Request{
-			Package: pkg,
-			Aliases: []string{match.Vulnerability.ID},
-			Event:   advisoryEventForNewDiscovery(match),
+			Package:    pkg,
+			Aliases:    []string{match.Vulnerability.ID},
+			Event:      advisoryEventForNewDiscovery(match),
+			Provenance: opts.Provenance,
 		}, CreateOptions{opts.AdvisoryDocs})
 		if err != nil {
 			return err
@@ -0,0 +1,243 @@
+package advisory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/melange/pkg/config"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/versions"
+	"github.com/wolfi-dev/wolfictl/pkg/vuln"
+)
+
+// DiscoverFixesOptions is the set of options for the DiscoverFixes function.
+type DiscoverFixesOptions struct {
+	// SelectedPackages is a list of packages to include in search. If empty, all
+	// packages with advisories will be included in search.
+	SelectedPackages []string
+
+	// BuildCfgs is the Index of build configurations used to determine each
+	// package's current version.
+	BuildCfgs *configs.Index[config.Configuration]
+
+	// AdvisoryDocs is the Index of advisory documents to search for advisories
+	// that are still open.
+	AdvisoryDocs *configs.Index[v2.Document]
+
+	// APKIndexes are the APKINDEXes of the distro's package repository, used to
+	// determine which versions of each package have been published.
+	APKIndexes []*apk.APKIndex
+
+	// VulnerabilityDetector is used to find the affected version range for each
+	// open advisory's vulnerability.
+	VulnerabilityDetector vuln.Detector
+
+	// CurrentTime is used as the timestamp for proposed "fixed" events.
+	CurrentTime v2.Timestamp
+
+	// Provenance, if set, is attached to each proposed request.
+	Provenance *Provenance
+}
+
+// openAdvisoryEventTypes are the latest event types that mark an advisory as
+// being eligible for automatic fix detection.
+var openAdvisoryEventTypes = []string{
+	v2.EventTypeDetection,
+	v2.EventTypePendingUpstreamFix,
+}
+
+// DiscoverFixes finds advisories whose latest event is "detection" or
+// "pending-upstream-fix" and for which the package's current version is no
+// longer included in the vulnerable version range reported by the
+// VulnerabilityDetector. For each such advisory, it returns a Request that
+// adds a "fixed" event, using the earliest version of the package published in
+// the APKINDEXes for which the package is no longer vulnerable. Versions that
+// exist only in a build configuration are never proposed.
+//
+// DiscoverFixes does not modify any advisory data. Callers can apply the
+// returned Requests using a Putter or a DataSession.
+func DiscoverFixes(ctx context.Context, opts DiscoverFixesOptions) ([]Request, error) {
+	if opts.CurrentTime.IsZero() {
+		return nil, fmt.Errorf("current time must be set")
+	}
+
+	selection := opts.AdvisoryDocs.Select()
+	if len(opts.SelectedPackages) > 0 {
+		selection = selection.Where(func(e configs.Entry[v2.Document]) bool {
+			return slices.Contains(opts.SelectedPackages, (*e.Configuration()).Name())
+		})
+	}
+
+	var reqs []Request
+
+	for _, doc := range selection.Configurations() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pkgReqs, err := opts.discoverFixesForDocument(ctx, doc)
+		if err != nil {
+			return nil, fmt.Errorf("discovering fixes for %q: %w", doc.Name(), err)
+		}
+
+		reqs = append(reqs, pkgReqs...)
+	}
+
+	return reqs, nil
+}
+
+func (opts DiscoverFixesOptions) discoverFixesForDocument(ctx context.Context, doc v2.Document) ([]Request, error) {
+	log := clog.FromContext(ctx).With("package", doc.Name())
+
+	var openAdvs []v2.Advisory
+	for _, adv := range doc.Advisories {
+		if slices.Contains(openAdvisoryEventTypes, adv.Latest().Type) {
+			openAdvs = append(openAdvs, adv)
+		}
+	}
+
+	if len(openAdvs) == 0 {
+		return nil, nil
+	}
+
+	buildCfgEntry, err := opts.BuildCfgs.Select().WhereName(doc.Name()).First()
+	if err != nil {
+		log.Warn("no build configuration found for package, skipping fix discovery")
+		return nil, nil
+	}
+	buildCfg := buildCfgEntry.Configuration()
+	currentVersion := fmt.Sprintf("%s-r%d", buildCfg.Package.Version, buildCfg.Package.Epoch)
+
+	matches, err := opts.VulnerabilityDetector.VulnerabilitiesForPackage(ctx, doc.Name())
+	if err != nil {
+		return nil, fmt.Errorf("finding vulnerabilities: %w", err)
+	}
+
+	// Only published versions are candidates, since a version that exists only
+	// in the build configuration can't be installed by anyone yet.
+	candidateVersions := opts.publishedVersions(doc.Name())
+
+	var reqs []Request
+
+	for _, adv := range openAdvs {
+		ranges := affectedVersionRanges(adv, matches)
+		if len(ranges) == 0 {
+			// Without a known affected range, we have no evidence that the vulnerability
+			// has been fixed.
+			log.Debug("no affected version range found for advisory, skipping", "advisory", adv.ID)
+			continue
+		}
+
+		if isAffected(upstreamVersion(currentVersion), ranges) {
+			continue
+		}
+
+		fixedVersion := firstUnaffectedVersion(candidateVersions, ranges)
+		if fixedVersion == "" {
+			continue
+		}
+
+		log.Info("found fixed version for open advisory", "advisory", adv.ID, "fixedVersion", fixedVersion)
+
+		reqs = append(reqs, Request{
+			Package:    doc.Name(),
+			AdvisoryID: adv.ID,
+			Aliases:    adv.Aliases,
+			Event: v2.Event{
+				Timestamp: opts.CurrentTime,
+				Type:      v2.EventTypeFixed,
+				Data: v2.Fixed{
+					FixedVersion: fixedVersion,
+				},
+			},
+			Provenance: opts.Provenance,
+		})
+	}
+
+	return reqs, nil
+}
+
+// publishedVersions returns the versions of the given package found in the
+// APKINDEXes, sorted from oldest to newest.
+func (opts DiscoverFixesOptions) publishedVersions(packageName string) []string {
+	versionSet := map[string]struct{}{}
+
+	for _, apkindex := range opts.APKIndexes {
+		if apkindex == nil {
+			continue
+		}
+
+		for _, pkg := range apkindex.Packages {
+			if pkg.Name == packageName {
+				versionSet[pkg.Version] = struct{}{}
+			}
+		}
+	}
+
+	vs := make([]string, 0, len(versionSet))
+	for v := range versionSet {
+		vs = append(vs, v)
+	}
+
+	sort.Sort(sort.Reverse(versions.ByLatestStrings(vs)))
+
+	return vs
+}
+
+// firstUnaffectedVersion returns the earliest version in the given sorted list
+// of versions such that it and every later version are outside all the given
+// affected ranges. If the latest version is still affected, an empty string is
+// returned.
+func firstUnaffectedVersion(sortedVersions []string, ranges []vuln.VersionRange) string {
+	fixed := ""
+
+	for i := len(sortedVersions) - 1; i >= 0; i-- {
+		v := sortedVersions[i]
+		if isAffected(upstreamVersion(v), ranges) {
+			break
+		}
+
+		fixed = v
+	}
+
+	return fixed
+}
+
+func isAffected(upstream string, ranges []vuln.VersionRange) bool {
+	for _, vr := range ranges {
+		if vr.Includes(upstream) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// affectedVersionRanges returns the version ranges from the given matches that
+// pertain to the given advisory's vulnerability.
+func affectedVersionRanges(adv v2.Advisory, matches []vuln.Match) []vuln.VersionRange {
+	var ranges []vuln.VersionRange
+	for _, m := range matches {
+		if adv.DescribesVulnerability(m.Vulnerability.ID) {
+			ranges = append(ranges, m.CPEFound.VersionRange)
+		}
+	}
+
+	return ranges
+}
+
+// upstreamVersion strips the APK epoch (e.g. "-r2") from the given full
+// package version.
+func upstreamVersion(fullVersion string) string {
+	if i := strings.LastIndex(fullVersion, "-r"); i != -1 {
+		return fullVersion[:i]
+	}
+
+	return fullVersion
+}
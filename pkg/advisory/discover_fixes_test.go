@@ -0,0 +1,189 @@
+package advisory
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/google/go-cmp/cmp"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	buildconfigs "github.com/wolfi-dev/wolfictl/pkg/configs/build"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+	"github.com/wolfi-dev/wolfictl/pkg/vuln"
+)
+
+func TestDiscoverFixes(t *testing.T) {
+	ctx := context.Background()
+	now := v2.Timestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	advisoryDocs, err := adv2.NewIndex(ctx, memfs.New(os.DirFS("testdata/discover_fixes/advisories")))
+	if err != nil {
+		t.Fatalf("unable to create advisory docs index: %v", err)
+	}
+
+	buildCfgs, err := buildconfigs.NewIndex(ctx, memfs.New(os.DirFS("testdata/discover_fixes/build")))
+	if err != nil {
+		t.Fatalf("unable to create build configs index: %v", err)
+	}
+
+	published := []*apk.Package{
+		{Name: "ko", Version: "0.14.0-r0"},
+		{Name: "ko", Version: "0.15.0-r0"},
+		{Name: "ko", Version: "0.15.1-r0"},
+		{Name: "crane", Version: "0.19.0-r0"},
+	}
+
+	detector := mockDetector{
+		matchesByPackage: map[string][]vuln.Match{
+			"ko": {
+				newMockMatch("CVE-2023-1111", vuln.VersionRange{VersionRangeUpper: "0.15.1"}),
+				newMockMatch("CVE-2023-2222", vuln.VersionRange{VersionRangeUpper: "0.15.2"}),
+				newMockMatch("CVE-2023-3333", vuln.VersionRange{VersionRangeLower: "0.1.0", VersionRangeLowerInclusive: true, VersionRangeUpper: "0.16.0"}),
+			},
+		},
+	}
+
+	cases := []struct {
+		name             string
+		selectedPackages []string
+		packages         []*apk.Package
+		expected         []Request
+	}{
+		{
+			name:             "proposes fixed events for open advisories no longer affected",
+			selectedPackages: []string{"ko"},
+			packages:         append(slices.Clone(published), &apk.Package{Name: "ko", Version: "0.15.2-r0"}),
+			expected: []Request{
+				{
+					Package:    "ko",
+					AdvisoryID: "CGA-2222-2222-2222",
+					Aliases:    []string{"CVE-2023-1111"},
+					Event: v2.Event{
+						Timestamp: now,
+						Type:      v2.EventTypeFixed,
+						Data:      v2.Fixed{FixedVersion: "0.15.1-r0"},
+					},
+				},
+				{
+					Package:    "ko",
+					AdvisoryID: "CGA-3333-3333-3333",
+					Aliases:    []string{"CVE-2023-2222"},
+					Event: v2.Event{
+						Timestamp: now,
+						Type:      v2.EventTypeFixed,
+						Data:      v2.Fixed{FixedVersion: "0.15.2-r0"},
+					},
+				},
+			},
+		},
+		{
+			name:             "versions only in the build configuration are not proposed",
+			selectedPackages: []string{"ko"},
+			packages:         published,
+			expected: []Request{
+				{
+					Package:    "ko",
+					AdvisoryID: "CGA-2222-2222-2222",
+					Aliases:    []string{"CVE-2023-1111"},
+					Event: v2.Event{
+						Timestamp: now,
+						Type:      v2.EventTypeFixed,
+						Data:      v2.Fixed{FixedVersion: "0.15.1-r0"},
+					},
+				},
+			},
+		},
+		{
+			name:             "no proposals without a known affected range",
+			selectedPackages: []string{"crane"},
+			packages:         published,
+			expected:         nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := DiscoverFixes(ctx, DiscoverFixesOptions{
+				SelectedPackages:      tt.selectedPackages,
+				BuildCfgs:             buildCfgs,
+				AdvisoryDocs:          advisoryDocs,
+				APKIndexes:            []*apk.APKIndex{{Packages: tt.packages}},
+				VulnerabilityDetector: detector,
+				CurrentTime:           now,
+			})
+			if err != nil {
+				t.Fatalf("DiscoverFixes() error = %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, reqs); diff != "" {
+				t.Errorf("DiscoverFixes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFirstUnaffectedVersion(t *testing.T) {
+	versions := []string{"1.0.0-r0", "1.1.0-r0", "1.1.0-r1", "1.2.0-r0"}
+
+	cases := []struct {
+		name     string
+		ranges   []vuln.VersionRange
+		expected string
+	}{
+		{
+			name:     "fixed in a middle version",
+			ranges:   []vuln.VersionRange{{VersionRangeUpper: "1.1.0"}},
+			expected: "1.1.0-r0",
+		},
+		{
+			name:     "still affected",
+			ranges:   []vuln.VersionRange{{VersionRangeUpper: "1.2.0", VersionRangeUpperInclusive: true}},
+			expected: "",
+		},
+		{
+			name:     "regression in a later version",
+			ranges:   []vuln.VersionRange{{SingleVersion: "1.0.0"}, {SingleVersion: "1.1.0"}},
+			expected: "1.2.0-r0",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstUnaffectedVersion(versions, tt.ranges); got != tt.expected {
+				t.Errorf("firstUnaffectedVersion() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+type mockDetector struct {
+	matchesByPackage map[string][]vuln.Match
+}
+
+func (d mockDetector) VulnerabilitiesForPackages(ctx context.Context, packages ...string) (map[string][]vuln.Match, error) {
+	result := make(map[string][]vuln.Match)
+	for _, pkg := range packages {
+		matches, err := d.VulnerabilitiesForPackage(ctx, pkg)
+		if err != nil {
+			return nil, err
+		}
+		result[pkg] = matches
+	}
+
+	return result, nil
+}
+
+func (d mockDetector) VulnerabilitiesForPackage(_ context.Context, pkg string) ([]vuln.Match, error) {
+	return d.matchesByPackage[pkg], nil
+}
+
+func newMockMatch(vulnID string, vr vuln.VersionRange) vuln.Match {
+	return vuln.Match{
+		CPEFound:      vuln.CPE{VersionRange: vr},
+		Vulnerability: vuln.Vulnerability{ID: vulnID},
+	}
+}
@@ -200,6 +200,12 @@ func (p FSPutter) UpsertToFile(advFileName string, request Request) (string, err
 		return "", fmt.Errorf("closing advisory file %q: %w", advFileName, err)
 	}
 
+	if request.Provenance != nil && !request.Event.IsZero() {
+		if err := RecordProvenance(p.fsys, request.Package, advisory.ID, request.Event, *request.Provenance); err != nil {
+			return "", err
+		}
+	}
+
 	return advisory.ID, nil
 }
 
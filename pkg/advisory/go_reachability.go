@@ -0,0 +1,67 @@
+package advisory
+
+import (
+	"fmt"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/goreachability"
+)
+
+// TriageGoReachabilityOptions configures the TriageGoReachability operation.
+type TriageGoReachabilityOptions struct {
+	// Package is the name of the distro package the advisory is for.
+	Package string
+
+	// VulnerabilityID is the ID of the vulnerability to triage. It can be a Go
+	// vulnerability ID, or a CVE or GHSA ID known to the Go vulnerability
+	// database.
+	VulnerabilityID string
+
+	// Binaries are the Go binaries shipped by the package (including its
+	// subpackages).
+	Binaries []*goreachability.Binary
+
+	// VulnDB is the local snapshot of the Go vulnerability database.
+	VulnDB *goreachability.Snapshot
+
+	// CurrentTime is the time to use for the proposed event.
+	CurrentTime v2.Timestamp
+}
+
+// TriageGoReachability checks whether the code affected by the Go
+// vulnerability is present in the package's Go binaries. If none of the
+// vulnerable symbols are present in any binary, it returns a Request for a
+// false positive determination of type
+// FPTypeVulnerableCodeNotIncludedInPackage, with the analysis as evidence in
+// the note. Otherwise, the returned Request is nil. The analysis result is
+// always returned, so callers can explain the outcome.
+func TriageGoReachability(opts TriageGoReachabilityOptions) (*Request, *goreachability.Result, error) {
+	entry, err := opts.VulnDB.Get(opts.VulnerabilityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := goreachability.Analyze(entry, opts.Binaries)
+	if err != nil {
+		return nil, nil, fmt.Errorf("analyzing reachability of %s: %w", opts.VulnerabilityID, err)
+	}
+
+	if len(opts.Binaries) == 0 || result.Present() {
+		return nil, result, nil
+	}
+
+	req := &Request{
+		Package: opts.Package,
+		Aliases: []string{opts.VulnerabilityID},
+		Event: v2.Event{
+			Timestamp: opts.CurrentTime,
+			Type:      v2.EventTypeFalsePositiveDetermination,
+			Data: v2.FalsePositiveDetermination{
+				Type: v2.FPTypeVulnerableCodeNotIncludedInPackage,
+				Note: result.Summary(),
+			},
+		},
+	}
+
+	return req, result, nil
+}
@@ -0,0 +1,263 @@
+package advisory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+)
+
+// ErrMergeConflict is returned when advisory data from multiple repositories
+// can't be merged without discarding information, and strict merging was
+// requested.
+var ErrMergeConflict = errors.New("conflicting advisory data across repositories")
+
+// MergeConflict describes a pair of events, from different advisory
+// repositories, that disagree about an advisory.
+//
+// Either the events have the same timestamp, in which case only one of them can
+// be kept, or they are each repository's latest event and reach different
+// conclusions, in which case both are kept but the merged advisory only
+// reflects the later one.
+type MergeConflict struct {
+	Package    string
+	AdvisoryID string
+
+	// Kept is the event that determines the merged advisory's state, and
+	// Discarded is the event that was dropped or superseded.
+	Kept, Discarded v2.Event
+}
+
+func (c MergeConflict) String() string {
+	if !c.Kept.Timestamp.Equal(c.Discarded.Timestamp) {
+		return fmt.Sprintf(
+			"%s: advisory %s: latest events conflict: kept %q at %s, superseded %q at %s",
+			c.Package,
+			c.AdvisoryID,
+			c.Kept.Type,
+			c.Kept.Timestamp,
+			c.Discarded.Type,
+			c.Discarded.Timestamp,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s: advisory %s: events at %s conflict: kept %q, discarded %q",
+		c.Package,
+		c.AdvisoryID,
+		c.Kept.Timestamp,
+		c.Kept.Type,
+		c.Discarded.Type,
+	)
+}
+
+// MergedDocument is an advisory document produced by merging the documents for
+// the same package from one or more advisory repositories.
+type MergedDocument struct {
+	v2.Document
+
+	// Sources lists the positions, within the slice of indices given to
+	// MergeAdvisoryDocIndices, of the indices that contributed to this document.
+	Sources []int
+}
+
+// MergeAdvisoryDocIndices combines the advisory documents from the given
+// indices, such that there is exactly one document per package. Documents are
+// returned in the order in which their packages were first encountered.
+//
+// Documents for the same package are merged advisory by advisory. Two
+// advisories are considered the same if they share an advisory ID or any
+// vulnerability ID (i.e. their IDs or aliases overlap). Merged advisories
+// have the union of all aliases and the events of all merged advisories,
+// ordered by timestamp. Duplicate events are only included once.
+//
+// Indices are given in order of precedence, highest first. The precedence
+// applies in two cases: a merged advisory uses the ID from the
+// highest-precedence repository that has the advisory, and when two events
+// with the same timestamp differ in type or data, only the event from the
+// higher-precedence repository is kept. The latter case is a MergeConflict.
+//
+// It's also a MergeConflict when the repositories' latest events for an
+// advisory are both conclusions (such as "fixed" or a false positive
+// determination) but different ones. Both events are kept, and the later one
+// determines the merged advisory's state.
+//
+// If strict is true, any MergeConflict causes an error wrapping
+// ErrMergeConflict. Otherwise, conflicts are logged as warnings.
+func MergeAdvisoryDocIndices(ctx context.Context, indices []*configs.Index[v2.Document], strict bool) ([]MergedDocument, error) {
+	log := clog.FromContext(ctx)
+
+	var merged []MergedDocument
+	positionByPackage := make(map[string]int)
+	var conflicts []MergeConflict
+
+	for i, index := range indices {
+		for _, doc := range index.Select().Configurations() {
+			name := doc.Name()
+
+			pos, exists := positionByPackage[name]
+			if !exists {
+				positionByPackage[name] = len(merged)
+				merged = append(merged, MergedDocument{
+					Document: copyDocument(doc),
+					Sources:  []int{i},
+				})
+				continue
+			}
+
+			log.Debug("merging advisory data for package from multiple repositories", "package", name, "index", i)
+
+			m := &merged[pos]
+			var docConflicts []MergeConflict
+			m.Document, docConflicts = mergeDocuments(m.Document, doc)
+			m.Sources = append(m.Sources, i)
+			conflicts = append(conflicts, docConflicts...)
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return merged, nil
+	}
+
+	if strict {
+		msgs := make([]string, 0, len(conflicts))
+		for _, c := range conflicts {
+			msgs = append(msgs, c.String())
+		}
+
+		return nil, fmt.Errorf("%w:\n%s", ErrMergeConflict, strings.Join(msgs, "\n"))
+	}
+
+	for _, c := range conflicts {
+		log.Warn("conflicting advisory data across repositories", "conflict", c.String())
+	}
+
+	return merged, nil
+}
+
+// mergeDocuments merges the lower-precedence document "other" into "base".
+func mergeDocuments(base, other v2.Document) (v2.Document, []MergeConflict) {
+	var conflicts []MergeConflict
+
+	other = copyDocument(other)
+	for _, otherAdv := range other.Advisories {
+		baseAdv, ok := base.Advisories.Get(otherAdv.ID)
+		if !ok {
+			baseAdv, ok = base.Advisories.GetByAnyVulnerability(otherAdv.VulnerabilityIDs()...)
+		}
+
+		if !ok {
+			base.Advisories = append(base.Advisories, otherAdv)
+			continue
+		}
+
+		mergedAdv, advConflicts := mergeAdvisories(baseAdv, otherAdv)
+		for i := range advConflicts {
+			advConflicts[i].Package = base.Name()
+		}
+		conflicts = append(conflicts, advConflicts...)
+
+		base.Advisories = base.Advisories.Update(baseAdv.ID, mergedAdv)
+	}
+
+	sort.Sort(base.Advisories)
+
+	return base, conflicts
+}
+
+// mergeAdvisories merges the lower-precedence advisory "other" into "base".
+// Conflicts are returned without their Package field set.
+func mergeAdvisories(base, other v2.Advisory) (v2.Advisory, []MergeConflict) {
+	var conflicts []MergeConflict
+
+	// A CGA ID from another repository isn't a vulnerability ID, so it isn't
+	// carried over as an alias.
+	aliases := other.Aliases
+	if other.ID != base.ID && !cgaid.RegexCGA.MatchString(other.ID) {
+		aliases = append(aliases, other.ID)
+	}
+	base = base.MergeInAliases(aliases...)
+
+	events := base.Events
+	for _, otherEvent := range other.Events {
+		i := slices.IndexFunc(events, func(e v2.Event) bool {
+			return time.Time(e.Timestamp).Equal(time.Time(otherEvent.Timestamp))
+		})
+		if i == -1 {
+			events = append(events, otherEvent)
+			continue
+		}
+
+		// An event already exists at this time. If it's not identical, the existing
+		// event takes precedence.
+		if e := events[i]; !sameEventContent(e, otherEvent) {
+			conflicts = append(conflicts, MergeConflict{
+				AdvisoryID: base.ID,
+				Kept:       e,
+				Discarded:  otherEvent,
+			})
+		}
+	}
+
+	baseLatest, otherLatest := base.Latest(), other.Latest()
+
+	base.Events = events
+	base.Events = base.SortedEvents()
+
+	// Events at different times don't collide above, but each repository's latest
+	// event is its conclusion about the advisory, and those can still disagree.
+	if isConclusion(baseLatest) && isConclusion(otherLatest) && !baseLatest.Timestamp.Equal(otherLatest.Timestamp) && !sameEventContent(baseLatest, otherLatest) {
+		kept, superseded := baseLatest, otherLatest
+		if kept.Timestamp.Before(superseded.Timestamp) {
+			kept, superseded = superseded, kept
+		}
+
+		conflicts = append(conflicts, MergeConflict{
+			AdvisoryID: base.ID,
+			Kept:       kept,
+			Discarded:  superseded,
+		})
+	}
+
+	return base, conflicts
+}
+
+// conclusionEventTypes are the event types that conclude an investigation of
+// an advisory.
+var conclusionEventTypes = []string{
+	v2.EventTypeFixed,
+	v2.EventTypeFalsePositiveDetermination,
+	v2.EventTypeFixNotPlanned,
+	v2.EventTypeAnalysisNotPlanned,
+}
+
+func isConclusion(e v2.Event) bool {
+	return slices.Contains(conclusionEventTypes, e.Type)
+}
+
+func sameEventContent(a, b v2.Event) bool {
+	return a.Type == b.Type && reflect.DeepEqual(a.Data, b.Data)
+}
+
+// copyDocument returns a copy of the document whose advisories can be modified
+// without affecting the original.
+func copyDocument(doc v2.Document) v2.Document {
+	advs := make(v2.Advisories, 0, len(doc.Advisories))
+	for _, adv := range doc.Advisories {
+		adv.Aliases = append([]string(nil), adv.Aliases...)
+		adv.Events = append([]v2.Event(nil), adv.Events...)
+		advs = append(advs, adv)
+	}
+	doc.Advisories = advs
+
+	return doc
+}
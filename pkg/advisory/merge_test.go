@@ -0,0 +1,149 @@
+package advisory
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+)
+
+func TestMergeAdvisoryDocIndices(t *testing.T) {
+	ctx := context.Background()
+
+	var indices []*configs.Index[v2.Document]
+	for _, dir := range []string{"testdata/merge/repo-a", "testdata/merge/repo-b"} {
+		index, err := adv2.NewIndex(ctx, memfs.New(os.DirFS(dir)))
+		require.NoError(t, err)
+		indices = append(indices, index)
+	}
+
+	day := func(d int) v2.Timestamp {
+		return v2.Timestamp(time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC))
+	}
+
+	detection := v2.Event{
+		Timestamp: day(1),
+		Type:      v2.EventTypeDetection,
+		Data:      v2.Detection{Type: v2.DetectionTypeManual},
+	}
+
+	expected := []MergedDocument{
+		{
+			Document: v2.Document{
+				SchemaVersion: "2.0.2",
+				Package:       v2.Package{Name: "ko"},
+				Advisories: v2.Advisories{
+					{
+						ID:      "CGA-2222-2222-2222",
+						Aliases: []string{"CVE-2023-1111", "GHSA-2222-2222-2222"},
+						Events: []v2.Event{
+							detection,
+							{
+								Timestamp: day(2),
+								Type:      v2.EventTypeTruePositiveDetermination,
+								Data:      v2.TruePositiveDetermination{Note: "Confirmed upstream."},
+							},
+							{
+								Timestamp: day(3),
+								Type:      v2.EventTypeFixed,
+								Data:      v2.Fixed{FixedVersion: "0.15.0-r0"},
+							},
+						},
+					},
+					{
+						// The conflicting false positive determination from repo-b is discarded.
+						ID:      "CGA-3333-3333-3333",
+						Aliases: []string{"CVE-2023-2222"},
+						Events:  []v2.Event{detection},
+					},
+					{
+						ID:      "CGA-7777-7777-7777",
+						Aliases: []string{"CVE-2023-3333"},
+						Events: []v2.Event{
+							{
+								Timestamp: day(2),
+								Type:      v2.EventTypeFixed,
+								Data:      v2.Fixed{FixedVersion: "0.14.0-r0"},
+							},
+						},
+					},
+					{
+						// Both latest events are kept, but they conflict.
+						ID:      "CGA-9999-9999-9999",
+						Aliases: []string{"CVE-2023-5555"},
+						Events: []v2.Event{
+							{
+								Timestamp: day(2),
+								Type:      v2.EventTypeFixed,
+								Data:      v2.Fixed{FixedVersion: "0.14.0-r0"},
+							},
+							{
+								Timestamp: day(3),
+								Type:      v2.EventTypeFalsePositiveDetermination,
+								Data:      v2.FalsePositiveDetermination{Type: v2.FPTypeVulnerableCodeNotIncludedInPackage},
+							},
+						},
+					},
+				},
+			},
+			Sources: []int{0, 1},
+		},
+		{
+			Document: v2.Document{
+				SchemaVersion: "2.0.2",
+				Package:       v2.Package{Name: "crane"},
+				Advisories: v2.Advisories{
+					{
+						ID:      "CGA-8888-8888-8888",
+						Aliases: []string{"CVE-2023-4444"},
+						Events: []v2.Event{
+							{
+								Timestamp: day(1),
+								Type:      v2.EventTypeFixed,
+								Data:      v2.Fixed{FixedVersion: "0.19.0-r0"},
+							},
+						},
+					},
+				},
+			},
+			Sources: []int{1},
+		},
+	}
+
+	t.Run("non-strict", func(t *testing.T) {
+		merged, err := MergeAdvisoryDocIndices(ctx, indices, false)
+		require.NoError(t, err)
+
+		if diff := cmp.Diff(expected, merged); diff != "" {
+			t.Errorf("MergeAdvisoryDocIndices() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := MergeAdvisoryDocIndices(ctx, indices, true)
+		assert.ErrorIs(t, err, ErrMergeConflict)
+		assert.ErrorContains(t, err, "CGA-3333-3333-3333")
+		assert.ErrorContains(t, err, "CGA-9999-9999-9999: latest events conflict")
+	})
+
+	t.Run("source indices are unmodified", func(t *testing.T) {
+		_, err := MergeAdvisoryDocIndices(ctx, indices, false)
+		require.NoError(t, err)
+
+		doc, err := indices[0].Select().WhereName("ko").First()
+		require.NoError(t, err)
+
+		adv, ok := doc.Configuration().Advisories.Get("CGA-2222-2222-2222")
+		require.True(t, ok)
+		assert.Len(t, adv.Events, 2)
+		assert.Len(t, adv.Aliases, 1)
+	})
+}
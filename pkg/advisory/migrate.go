@@ -0,0 +1,296 @@
+package advisory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/hashicorp/go-version"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaMigration is a single step that upgrades an advisory document to a
+// newer schema version.
+type SchemaMigration struct {
+	// Version is the schema version of a document after this migration has been
+	// applied. The migration is applied to documents whose schema version is
+	// older than this version.
+	Version string
+
+	// Description briefly explains what the migration changes.
+	Description string
+
+	// Mutate transforms the document's YAML AST. Implementations must be
+	// idempotent, such that applying Mutate to an already-migrated document has
+	// no effect. Mutate may be nil if the only change needed is the update of the
+	// document's schema version.
+	//
+	// Mutate does not need to update the document's schema version, since this is
+	// done automatically after Mutate returns.
+	Mutate configs.YAMLASTMutater[v2.Document]
+}
+
+// SchemaMigrationRegistry is an ordered set of schema migrations.
+type SchemaMigrationRegistry struct {
+	migrations []SchemaMigration
+	versions   []*version.Version
+}
+
+// NewSchemaMigrationRegistry returns a new SchemaMigrationRegistry for the given
+// migrations. The migrations must be given in order of strictly increasing
+// schema version.
+func NewSchemaMigrationRegistry(migrations ...SchemaMigration) (*SchemaMigrationRegistry, error) {
+	r := &SchemaMigrationRegistry{}
+
+	for _, m := range migrations {
+		v, err := version.NewVersion(m.Version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version of migration %q: %w", m.Version, err)
+		}
+
+		if n := len(r.versions); n > 0 && !r.versions[n-1].LessThan(v) {
+			return nil, fmt.Errorf("migration %q must have a higher version than migration %q", m.Version, r.migrations[n-1].Version)
+		}
+
+		r.migrations = append(r.migrations, m)
+		r.versions = append(r.versions, v)
+	}
+
+	return r, nil
+}
+
+// DefaultSchemaMigrations is the registry of all schema migrations known to
+// this version of wolfictl. When the advisory schema changes, a new migration
+// should be added to the end of this list.
+var DefaultSchemaMigrations = mustNewSchemaMigrationRegistry(
+	SchemaMigration{
+		Version:     v2.SchemaVersion,
+		Description: "update the schema version to the latest version supported by wolfictl",
+	},
+)
+
+func mustNewSchemaMigrationRegistry(migrations ...SchemaMigration) *SchemaMigrationRegistry {
+	r, err := NewSchemaMigrationRegistry(migrations...)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+// Latest returns the schema version produced by the last migration in the
+// registry.
+func (r *SchemaMigrationRegistry) Latest() string {
+	if len(r.migrations) == 0 {
+		return ""
+	}
+
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+// ErrUnknownSchemaVersion is returned when a target schema version doesn't
+// correspond to any migration in the registry.
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// ErrInvalidSchemaVersion is returned when a document's schema version can't
+// be parsed.
+var ErrInvalidSchemaVersion = errors.New("invalid schema version")
+
+// Plan returns the migrations needed to bring a document from the "from"
+// schema version to the "to" schema version, in the order they should be
+// applied. An empty "from" means the document predates schema versioning, so
+// every migration up to "to" is needed. An empty "to" means the latest version
+// in the registry.
+func (r *SchemaMigrationRegistry) Plan(from, to string) ([]SchemaMigration, error) {
+	if to == "" {
+		to = r.Latest()
+	}
+
+	toVersion, err := version.NewVersion(to)
+	if err != nil {
+		return nil, fmt.Errorf("parsing target schema version %q: %w", to, err)
+	}
+
+	if !slices.ContainsFunc(r.versions, toVersion.Equal) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSchemaVersion, to)
+	}
+
+	var fromVersion *version.Version
+	if from != "" {
+		fromVersion, err = version.NewVersion(from)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidSchemaVersion, from, err)
+		}
+	}
+
+	var plan []SchemaMigration
+	for i, v := range r.versions {
+		if fromVersion != nil && v.LessThanOrEqual(fromVersion) {
+			continue
+		}
+
+		if v.GreaterThan(toVersion) {
+			break
+		}
+
+		plan = append(plan, r.migrations[i])
+	}
+
+	return plan, nil
+}
+
+// MigrateSchemaOptions configures the MigrateSchema operation.
+type MigrateSchemaOptions struct {
+	// AdvisoryDocs is the Index of advisory documents on which to operate.
+	AdvisoryDocs *configs.Index[v2.Document]
+
+	// Registry is the set of available migrations. If nil,
+	// DefaultSchemaMigrations is used.
+	Registry *SchemaMigrationRegistry
+
+	// To is the schema version to migrate documents to. If empty, documents are
+	// migrated to the latest version in the Registry.
+	To string
+
+	// SelectedPackages is the set of packages to operate on. If empty, all
+	// packages will be operated on.
+	SelectedPackages map[string]struct{}
+}
+
+// SchemaMigrationResult describes the migrations applied to a single document.
+type SchemaMigrationResult struct {
+	// Package is the name of the package the document describes.
+	Package string
+
+	// Path is the path to the document's file in the index's filesystem.
+	Path string
+
+	// From and To are the document's schema versions before and after migration.
+	From, To string
+
+	// Applied lists the migrations applied to the document, in order.
+	Applied []SchemaMigration
+
+	// Err is set if the document couldn't be migrated because its schema version
+	// is invalid. Such documents are left unchanged.
+	Err error
+}
+
+// MigrateSchema migrates the selected advisory documents to the target schema
+// version, applying each needed migration in order. Documents are updated via
+// their YAML ASTs, so formatting and comments are preserved. Documents already
+// at or beyond the target version are left unchanged. A result is returned for
+// each document that was migrated, and for each document whose schema version
+// is invalid, with its Err set. A document without a schema version is treated
+// as having the oldest schema.
+func MigrateSchema(ctx context.Context, opts MigrateSchemaOptions) ([]SchemaMigrationResult, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = DefaultSchemaMigrations
+	}
+
+	selection := opts.AdvisoryDocs.Select()
+	if len(opts.SelectedPackages) > 0 {
+		selection = selection.Where(func(e configs.Entry[v2.Document]) bool {
+			_, ok := opts.SelectedPackages[(*e.Configuration()).Name()]
+			return ok
+		})
+	}
+
+	var results []SchemaMigrationResult
+
+	for _, entry := range selection.Entries() {
+		doc := entry.Configuration()
+		log := clog.FromContext(ctx).With("package", doc.Name(), "schemaVersion", doc.SchemaVersion)
+
+		plan, err := registry.Plan(doc.SchemaVersion, opts.To)
+		if errors.Is(err, ErrInvalidSchemaVersion) {
+			log.Warn("skipping document with invalid schema version", "error", err)
+			results = append(results, SchemaMigrationResult{
+				Package: doc.Name(),
+				Path:    entry.Path(),
+				From:    doc.SchemaVersion,
+				Err:     err,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("planning migration for %q: %w", doc.Name(), err)
+		}
+
+		if len(plan) == 0 {
+			log.Debug("document needs no migration")
+			continue
+		}
+
+		result := SchemaMigrationResult{
+			Package: doc.Name(),
+			Path:    entry.Path(),
+			From:    doc.SchemaVersion,
+		}
+
+		for _, m := range plan {
+			log.Debug("applying schema migration", "version", m.Version)
+
+			err := opts.AdvisoryDocs.Select().WhereFilePath(entry.Path()).Update(ctx, newSchemaMigrationUpdater(m))
+			if err != nil {
+				return nil, fmt.Errorf("migrating %q to schema version %q: %w", doc.Name(), m.Version, err)
+			}
+
+			result.Applied = append(result.Applied, m)
+			result.To = m.Version
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func newSchemaMigrationUpdater(m SchemaMigration) configs.EntryUpdater[v2.Document] {
+	setSchemaVersion := configs.NewTargetedYAMLASTMutater(
+		"schema-version",
+		func(_ v2.Document) (string, error) {
+			return m.Version, nil
+		},
+		func(doc v2.Document, data string) v2.Document {
+			doc.SchemaVersion = data
+			return doc
+		},
+	)
+
+	return configs.NewYAMLUpdateFunc(func(doc v2.Document, node *yaml.Node) error {
+		if m.Mutate != nil {
+			if err := m.Mutate(doc, node); err != nil {
+				return err
+			}
+		}
+
+		ensureSchemaVersionKey(node)
+		return setSchemaVersion(doc, node)
+	})
+}
+
+// ensureSchemaVersionKey adds an empty "schema-version" key to the top of the
+// document if it doesn't have one, where the key is expected to be.
+func ensureSchemaVersionKey(root *yaml.Node) {
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+
+	rootMap := root.Content[0]
+	for i := 0; i+1 < len(rootMap.Content); i += 2 {
+		if rootMap.Content[i].Value == "schema-version" {
+			return
+		}
+	}
+
+	rootMap.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "schema-version"},
+		{Kind: yaml.ScalarNode, Tag: "!!str"},
+	}, rootMap.Content...)
+}
@@ -0,0 +1,204 @@
+package advisory
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/dprotaso/go-yit"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/testerfs"
+	"gopkg.in/yaml.v3"
+)
+
+// testSchemaMigrations is a registry whose middle migration sorts each
+// advisory's aliases, so that we can observe a migration's effect on the
+// document content.
+var testSchemaMigrations = mustNewSchemaMigrationRegistry(
+	SchemaMigration{Version: "2", Description: "initial version"},
+	SchemaMigration{Version: "2.0.1", Description: "sort aliases", Mutate: sortAliasesMutater},
+	SchemaMigration{Version: "2.0.2", Description: "no structural change"},
+)
+
+func sortAliasesMutater(_ v2.Document, root *yaml.Node) error {
+	iter := yit.FromNode(root.Content[0]).
+		ValuesForMap(yit.WithValue("advisories"), yit.All).
+		Values().
+		ValuesForMap(yit.WithValue("aliases"), yit.All)
+
+	for node, ok := iter(); ok; node, ok = iter() {
+		sort.SliceStable(node.Content, func(i, j int) bool {
+			return node.Content[i].Value < node.Content[j].Value
+		})
+	}
+
+	return nil
+}
+
+func TestNewSchemaMigrationRegistry(t *testing.T) {
+	cases := []struct {
+		name      string
+		versions  []string
+		assertErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "ascending",
+			versions:  []string{"2", "2.0.1", "2.1.0"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "out of order",
+			versions:  []string{"2.0.1", "2"},
+			assertErr: assert.Error,
+		},
+		{
+			name:      "duplicate",
+			versions:  []string{"2", "2.0.0"},
+			assertErr: assert.Error,
+		},
+		{
+			name:      "invalid version",
+			versions:  []string{"two"},
+			assertErr: assert.Error,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var migrations []SchemaMigration
+			for _, v := range tt.versions {
+				migrations = append(migrations, SchemaMigration{Version: v})
+			}
+
+			_, err := NewSchemaMigrationRegistry(migrations...)
+			tt.assertErr(t, err)
+		})
+	}
+}
+
+func TestSchemaMigrationRegistry_Plan(t *testing.T) {
+	cases := []struct {
+		name      string
+		from, to  string
+		expected  []string
+		assertErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "to latest",
+			from:      "2",
+			expected:  []string{"2.0.1", "2.0.2"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "to specific version",
+			from:      "2",
+			to:        "2.0.1",
+			expected:  []string{"2.0.1"},
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "already at target",
+			from:      "2.0.2",
+			expected:  nil,
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "beyond target",
+			from:      "2.0.2",
+			to:        "2.0.1",
+			expected:  nil,
+			assertErr: assert.NoError,
+		},
+		{
+			name:      "no schema version",
+			from:      "",
+			expected:  []string{"2", "2.0.1", "2.0.2"},
+			assertErr: assert.NoError,
+		},
+		{
+			name: "invalid schema version",
+			from: "two",
+			assertErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidSchemaVersion)
+			},
+		},
+		{
+			name:      "unknown target",
+			from:      "2",
+			to:        "3",
+			assertErr: assert.Error,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := testSchemaMigrations.Plan(tt.from, tt.to)
+			tt.assertErr(t, err)
+
+			var got []string
+			for _, m := range plan {
+				got = append(got, m.Version)
+			}
+
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("Plan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMigrateSchema(t *testing.T) {
+	ctx := context.Background()
+
+	fsys, err := testerfs.New(os.DirFS("testdata/migrate"))
+	require.NoError(t, err)
+
+	index, err := adv2.NewIndex(ctx, fsys)
+	require.NoError(t, err)
+
+	opts := MigrateSchemaOptions{
+		AdvisoryDocs: index,
+		Registry:     testSchemaMigrations,
+	}
+
+	results, err := MigrateSchema(ctx, opts)
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	sort.Slice(results, func(i, j int) bool { return results[i].Package < results[j].Package })
+
+	assert.Equal(t, "brotli", results[0].Package)
+	assert.Equal(t, "2", results[0].From)
+	assert.Equal(t, "2.0.2", results[0].To)
+	assert.Len(t, results[0].Applied, 2)
+	assert.NoError(t, results[0].Err)
+
+	// A document without a schema version gets every migration.
+	assert.Equal(t, "curl", results[1].Package)
+	assert.Equal(t, "", results[1].From)
+	assert.Equal(t, "2.0.2", results[1].To)
+	assert.Len(t, results[1].Applied, 3)
+	assert.NoError(t, results[1].Err)
+
+	// A document with an invalid schema version is reported and left alone,
+	// without stopping the others from being migrated.
+	assert.Equal(t, "zlib", results[2].Package)
+	assert.ErrorIs(t, results[2].Err, ErrInvalidSchemaVersion)
+	assert.Empty(t, results[2].Applied)
+
+	if diff := fsys.DiffAll(); diff != "" {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	// Migrating again should be a no-op, apart from reporting the invalid
+	// document again.
+	results, err = MigrateSchema(ctx, opts)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "zlib", results[0].Package)
+}
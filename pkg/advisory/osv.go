@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -26,6 +27,9 @@ type OSVOptions struct {
 	// TODO(luhring): We should move toward unifying all advisory repositories into
 	//  a single collection of all advisory documents. At that point, we won't need
 	//  to use multiple advisory indices here.
+	//
+	// Advisory data for the same package from multiple indices is merged (see
+	// MergeAdvisoryDocIndices), with indices listed earlier taking precedence.
 	AdvisoryDocIndices []*configs.Index[v2.Document]
 
 	// PackageConfigIndices is a list of indexes containing Chainguard package build
@@ -55,6 +59,19 @@ type OSVOptions struct {
 	// OutputDirectory is the path to a local directory in which the generated OSV
 	// dataset will be written.
 	OutputDirectory string
+
+	// Strict causes building the dataset to fail if advisory data from multiple
+	// indices conflicts, rather than resolving the conflict by precedence.
+	Strict bool
+
+	// Incremental causes only the entries that changed since the last incremental
+	// build to be rewritten, based on a manifest of previously emitted entries
+	// (see OSVManifest) stored in the output directory. Entries for advisories
+	// that no longer exist are deleted. Incremental builds also write an all.zip
+	// archive of all entries.
+	//
+	// The first incremental build in a given directory writes every entry.
+	Incremental bool
 }
 
 // OSVEcosystem is the name of the OSV ecosystem for Chainguard advisories.
@@ -95,94 +112,101 @@ func BuildOSVDataset(ctx context.Context, opts OSVOptions) error {
 		}
 	}
 
+	docs, err := MergeAdvisoryDocIndices(ctx, opts.AdvisoryDocIndices, opts.Strict)
+	if err != nil {
+		return err
+	}
+
 	advisoryIDsToModels := make(map[string]models.Vulnerability)
 
-	for i, index := range opts.AdvisoryDocIndices {
-		// See if we need to add additional ecosystems for this particular advisories
-		// index.
-		addedEcosystem := opts.AddedEcosystems[i]
+	for _, doc := range docs {
+		// See if we need to add additional ecosystems for any of the advisories
+		// indices this document's data came from.
 		ecosystems := []models.Ecosystem{OSVEcosystem}
-		if addedEcosystem != "" {
-			ecosystems = append(ecosystems, models.Ecosystem(addedEcosystem))
+		for _, i := range doc.Sources {
+			if addedEcosystem := opts.AddedEcosystems[i]; addedEcosystem != "" && !slices.Contains(ecosystems, models.Ecosystem(addedEcosystem)) {
+				ecosystems = append(ecosystems, models.Ecosystem(addedEcosystem))
+			}
 		}
 
-		documents := index.Select().Configurations()
-		for _, doc := range documents {
-			// We'll have one or more affected packages listed for each advisory. We'll
-			// always include the origin package in the Chainguard ecosystem as an 'affected
-			// package'. If there are subpackages, we'll add an 'affected package' for each
-			// of those. Finally, we'll add any specified additional ecosystems (e.g.
-			// "wolfi") to produce additional 'affected packages' for each of the
-			// origin+subpackages.
-			//
-			// The final count of 'affected packages' for each advisory should be:
-			//
-			//   (1 + number of subpackages) * (1 + number of additional ecosystems)
-
-			pkgName := doc.Package.Name
-			pkgs := append([]string{pkgName}, pkgNameToSubpackages[pkgName]...)
-
-			logger.Debug("processing advisories document", "name", pkgName, "packages", pkgs)
-
-			var affectedPackages []models.Package
-			for _, pkg := range pkgs {
-				for _, ecosystem := range ecosystems {
-					affectedPackages = append(affectedPackages, models.Package{
-						Name:      pkg,
-						Ecosystem: ecosystem,
-						Purl:      createPurl(pkg, ecosystem),
-					})
-				}
+		// We'll have one or more affected packages listed for each advisory. We'll
+		// always include the origin package in the Chainguard ecosystem as an 'affected
+		// package'. If there are subpackages, we'll add an 'affected package' for each
+		// of those. Finally, we'll add any specified additional ecosystems (e.g.
+		// "wolfi") to produce additional 'affected packages' for each of the
+		// origin+subpackages.
+		//
+		// The final count of 'affected packages' for each advisory should be:
+		//
+		//   (1 + number of subpackages) * (1 + number of additional ecosystems)
+
+		pkgName := doc.Package.Name
+		pkgs := append([]string{pkgName}, pkgNameToSubpackages[pkgName]...)
+
+		logger.Debug("processing advisories document", "name", pkgName, "packages", pkgs)
+
+		var affectedPackages []models.Package
+		for _, pkg := range pkgs {
+			for _, ecosystem := range ecosystems {
+				affectedPackages = append(affectedPackages, models.Package{
+					Name:      pkg,
+					Ecosystem: ecosystem,
+					Purl:      createPurl(pkg, ecosystem),
+				})
 			}
+		}
 
-			for _, adv := range doc.Advisories {
-				latestEvent := adv.Latest()
-				advisoryLastUpdated := time.Time(latestEvent.Timestamp)
-
-				var affectedRange models.Range
-
-				switch latestEvent.Type {
-				case v2.EventTypeFixed:
-					if d, ok := latestEvent.Data.(v2.Fixed); ok {
-						affectedRange = rangeForFixed(d.FixedVersion)
-					} else {
-						return fmt.Errorf("unexpected data type for fixed event: %T (advisory index %d, package %q, advisory ID %q)", latestEvent.Data, i, pkgName, adv.ID)
-					}
-				case v2.EventTypeFalsePositiveDetermination:
-					affectedRange = rangeForFalsePositive()
-				default:
-					// We don't yet produce OSV data for other event types.
-					logger.Debug("skipping advisory with unsupported event type", "advisoryID", adv.ID, "eventType", latestEvent.Type)
-					continue
-				}
+		for _, adv := range doc.Advisories {
+			latestEvent := adv.Latest()
+			advisoryLastUpdated := time.Time(latestEvent.Timestamp)
 
-				// Note: The OSV data should include our advisory ID itself among the listed
-				// related vulnerability IDs.
-				related := append([]string{adv.ID}, adv.Aliases...)
+			var affectedRange models.Range
 
-				affecteds := make([]models.Affected, 0, len(affectedPackages))
-				for _, pkg := range affectedPackages {
-					affecteds = append(affecteds, models.Affected{
-						Package: pkg,
-						Ranges:  []models.Range{affectedRange},
-					})
+			switch latestEvent.Type {
+			case v2.EventTypeFixed:
+				if d, ok := latestEvent.Data.(v2.Fixed); ok {
+					affectedRange = rangeForFixed(d.FixedVersion)
+				} else {
+					return fmt.Errorf("unexpected data type for fixed event: %T (package %q, advisory ID %q)", latestEvent.Data, pkgName, adv.ID)
 				}
+			case v2.EventTypeFalsePositiveDetermination:
+				affectedRange = rangeForFalsePositive()
+			default:
+				// We don't yet produce OSV data for other event types.
+				logger.Debug("skipping advisory with unsupported event type", "advisoryID", adv.ID, "eventType", latestEvent.Type)
+				continue
+			}
 
-				entry := models.Vulnerability{
-					ID:       adv.ID,
-					Related:  related,
-					Affected: affecteds,
-					Modified: advisoryLastUpdated,
-				}
+			// Note: The OSV data should include our advisory ID itself among the listed
+			// related vulnerability IDs.
+			related := append([]string{adv.ID}, adv.Aliases...)
 
-				advisoryIDsToModels[adv.ID] = entry
+			affecteds := make([]models.Affected, 0, len(affectedPackages))
+			for _, pkg := range affectedPackages {
+				affecteds = append(affecteds, models.Affected{
+					Package: pkg,
+					Ranges:  []models.Range{affectedRange},
+				})
 			}
+
+			entry := models.Vulnerability{
+				ID:       adv.ID,
+				Related:  related,
+				Affected: affecteds,
+				Modified: advisoryLastUpdated,
+			}
+
+			advisoryIDsToModels[adv.ID] = entry
 		}
 	}
 
 	ids := lo.Keys(advisoryIDsToModels)
 	sort.Strings(ids)
 
+	if opts.Incremental {
+		return writeOSVDatasetIncremental(ctx, opts.OutputDirectory, ids, advisoryIDsToModels)
+	}
+
 	// write the all.json ("the index") and individual advisory files
 	logger.Info("generating all.json index file", "outputDirectory", opts.OutputDirectory, "advisoryCount", len(ids))
 
@@ -221,8 +245,7 @@ func BuildOSVDataset(ctx context.Context, opts OSVOptions) error {
 		}
 	}
 
-	const indexFileName = "all.json"
-	indexFilepath := filepath.Join(opts.OutputDirectory, indexFileName)
+	indexFilepath := filepath.Join(opts.OutputDirectory, osvIndexFileName)
 	indexFile, err := os.Create(indexFilepath)
 	if err != nil {
 		return fmt.Errorf("creating file for OSV index: %w", err)
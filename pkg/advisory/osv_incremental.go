@@ -0,0 +1,223 @@
+package advisory
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/google/osv-scanner/pkg/models"
+)
+
+const (
+	// OSVManifestFileName is the name of the file in the OSV dataset's output
+	// directory that records the entries emitted by the last incremental build.
+	OSVManifestFileName = "manifest.json"
+
+	osvIndexFileName   = "all.json"
+	osvArchiveFileName = "all.zip"
+)
+
+// OSVManifest records the entries of an OSV dataset as of the last incremental
+// build, so that the next build can tell which entries have changed.
+type OSVManifest struct {
+	// Entries maps each OSV entry ID to information about its emitted content.
+	Entries map[string]OSVManifestEntry `json:"entries"`
+}
+
+// OSVManifestEntry describes a single emitted OSV entry.
+type OSVManifestEntry struct {
+	// SHA256 is the hex-encoded SHA-256 digest of the entry's JSON file.
+	SHA256 string `json:"sha256"`
+
+	// Modified is the entry's "modified" timestamp.
+	Modified time.Time `json:"modified"`
+}
+
+// readOSVManifest reads the manifest from the given directory. If no manifest
+// exists yet, an empty manifest is returned.
+func readOSVManifest(dir string) (*OSVManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, OSVManifestFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &OSVManifest{Entries: make(map[string]OSVManifestEntry)}, nil
+		}
+		return nil, fmt.Errorf("reading OSV manifest: %w", err)
+	}
+
+	m := new(OSVManifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("decoding OSV manifest: %w", err)
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]OSVManifestEntry)
+	}
+
+	return m, nil
+}
+
+// writeOSVDatasetIncremental writes the given OSV entries to the output
+// directory, rewriting only the entries whose content differs from what the
+// manifest says was emitted last time, and deleting the entries that no longer
+// exist. It then writes the index (all.json), an archive of all entries
+// (all.zip), and an updated manifest.
+func writeOSVDatasetIncremental(ctx context.Context, dir string, ids []string, entries map[string]models.Vulnerability) error {
+	logger := clog.FromContext(ctx)
+
+	previous, err := readOSVManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	current := &OSVManifest{Entries: make(map[string]OSVManifestEntry, len(ids))}
+
+	var (
+		indexEntries = make([]models.Vulnerability, 0, len(ids))
+		contents     = make(map[string][]byte, len(ids))
+		written      int
+	)
+
+	for _, id := range ids {
+		entry := entries[id]
+
+		b, err := encodeOSVJSON(entry)
+		if err != nil {
+			return fmt.Errorf("encoding OSV advisory %q to JSON: %w", id, err)
+		}
+		contents[id] = b
+
+		digest := sha256.Sum256(b)
+		manifestEntry := OSVManifestEntry{
+			SHA256:   hex.EncodeToString(digest[:]),
+			Modified: entry.Modified,
+		}
+		current.Entries[id] = manifestEntry
+
+		indexEntries = append(indexEntries, models.Vulnerability{
+			ID:       entry.ID,
+			Modified: entry.Modified,
+		})
+
+		path := filepath.Join(dir, osvEntryFileName(id))
+		if prev, ok := previous.Entries[id]; ok && prev == manifestEntry {
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+		}
+
+		if err := os.WriteFile(path, b, 0o644); err != nil { //nolint:gosec // The dataset is meant to be readable by all.
+			return fmt.Errorf("writing OSV advisory %q: %w", id, err)
+		}
+		written++
+	}
+
+	deleted := 0
+	for id := range previous.Entries {
+		if _, ok := current.Entries[id]; ok {
+			continue
+		}
+
+		err := os.Remove(filepath.Join(dir, osvEntryFileName(id)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("deleting removed OSV advisory %q: %w", id, err)
+		}
+		deleted++
+	}
+
+	logger.Info("wrote OSV entries incrementally", "written", written, "unchanged", len(ids)-written, "deleted", deleted)
+
+	index, err := encodeOSVJSON(indexEntries)
+	if err != nil {
+		return fmt.Errorf("encoding OSV index to JSON: %w", err)
+	}
+	if err := writeFileIfChanged(filepath.Join(dir, osvIndexFileName), index); err != nil {
+		return fmt.Errorf("writing OSV index: %w", err)
+	}
+
+	archive, err := buildOSVArchive(ids, contents, current)
+	if err != nil {
+		return fmt.Errorf("building OSV archive: %w", err)
+	}
+	if err := writeFileIfChanged(filepath.Join(dir, osvArchiveFileName), archive); err != nil {
+		return fmt.Errorf("writing OSV archive: %w", err)
+	}
+
+	manifest, err := encodeOSVJSON(current)
+	if err != nil {
+		return fmt.Errorf("encoding OSV manifest to JSON: %w", err)
+	}
+	if err := writeFileIfChanged(filepath.Join(dir, OSVManifestFileName), manifest); err != nil {
+		return fmt.Errorf("writing OSV manifest: %w", err)
+	}
+
+	return nil
+}
+
+// buildOSVArchive returns a zip archive containing every entry's JSON file.
+// The archive's content depends only on the entries, so that it's unchanged
+// when the entries are unchanged.
+func buildOSVArchive(ids []string, contents map[string][]byte, manifest *OSVManifest) ([]byte, error) {
+	sorted := make([]string, len(ids))
+	copy(sorted, ids)
+	sort.Strings(sorted)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	for _, id := range sorted {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     osvEntryFileName(id),
+			Method:   zip.Deflate,
+			Modified: manifest.Entries[id].Modified.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write(contents[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func osvEntryFileName(id string) string {
+	return fmt.Sprintf("%s.json", id)
+}
+
+func encodeOSVJSON(v any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFileIfChanged writes the data to the file at path, unless the file
+// already has exactly that content, in which case the file is left untouched.
+func writeFileIfChanged(path string, data []byte) error {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+
+	return os.WriteFile(path, data, 0o644) //nolint:gosec // The dataset is meant to be readable by all.
+}
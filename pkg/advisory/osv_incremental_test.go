@@ -0,0 +1,147 @@
+package advisory
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"chainguard.dev/melange/pkg/config"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/build"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+)
+
+func Test_BuildOSVDataset_Incremental(t *testing.T) {
+	const testdataOSVDir = "./testdata/osv"
+
+	indexRepos := func(t *testing.T, repos ...string) ([]*configs.Index[v2.Document], []*configs.Index[config.Configuration]) {
+		t.Helper()
+
+		var advisoryIndices []*configs.Index[v2.Document]
+		var packageIndices []*configs.Index[config.Configuration]
+		for _, r := range repos {
+			advIndex, err := adv2.NewIndex(context.Background(), rwos.DirFS(filepath.Join(testdataOSVDir, "advisories-repo-"+r)))
+			require.NoError(t, err)
+			advisoryIndices = append(advisoryIndices, advIndex)
+
+			pkgIndex, err := build.NewIndex(context.Background(), rwos.DirFS(filepath.Join(testdataOSVDir, "packages-repo-"+r)))
+			require.NoError(t, err)
+			packageIndices = append(packageIndices, pkgIndex)
+		}
+
+		return advisoryIndices, packageIndices
+	}
+
+	outputDir := t.TempDir()
+
+	buildDataset := func(t *testing.T, addedEcosystems []string, repos ...string) {
+		t.Helper()
+
+		advisoryIndices, packageIndices := indexRepos(t, repos...)
+		err := BuildOSVDataset(context.Background(), OSVOptions{
+			AdvisoryDocIndices:   advisoryIndices,
+			PackageConfigIndices: packageIndices,
+			AddedEcosystems:      addedEcosystems,
+			OutputDirectory:      outputDir,
+			Incremental:          true,
+		})
+		require.NoError(t, err)
+	}
+
+	// Backdate every file in the output directory, so that we can tell which files
+	// a subsequent build touches.
+	past := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	backdate := func(t *testing.T) {
+		t.Helper()
+
+		entries, err := os.ReadDir(outputDir)
+		require.NoError(t, err)
+		for _, e := range entries {
+			require.NoError(t, os.Chtimes(filepath.Join(outputDir, e.Name()), past, past))
+		}
+	}
+	touched := func(t *testing.T) []string {
+		t.Helper()
+
+		entries, err := os.ReadDir(outputDir)
+		require.NoError(t, err)
+
+		var names []string
+		for _, e := range entries {
+			info, err := e.Info()
+			require.NoError(t, err)
+			if !info.ModTime().Equal(past) {
+				names = append(names, e.Name())
+			}
+		}
+		return names
+	}
+	files := func(t *testing.T) []string {
+		t.Helper()
+
+		entries, err := os.ReadDir(outputDir)
+		require.NoError(t, err)
+
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	// The first build writes everything.
+	buildDataset(t, []string{"Wolfi", "Extra"}, "a", "b")
+
+	allEntries := []string{
+		"CGA-37qj-pjrf-fmrw.json",
+		"CGA-5f5c-53mg-6p2v.json",
+		"CGA-6mjr-v678-c6gm.json",
+		"CGA-gg4h-ppqq-vf35.json",
+		"CGA-mm7m-x6cw-5fg4.json",
+		"CGA-vj68-6p3f-8xmr.json",
+	}
+	require.Equal(t, append(append([]string{}, allEntries...), "all.json", "all.zip", "manifest.json"), files(t))
+
+	zr, err := zip.OpenReader(filepath.Join(outputDir, "all.zip"))
+	require.NoError(t, err)
+	var zipped []string
+	for _, f := range zr.File {
+		zipped = append(zipped, f.Name)
+	}
+	require.NoError(t, zr.Close())
+	sort.Strings(zipped)
+	require.Equal(t, allEntries, zipped)
+
+	manifest, err := readOSVManifest(outputDir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, len(allEntries))
+
+	// Rebuilding from the same data touches nothing.
+	backdate(t)
+	buildDataset(t, []string{"Wolfi", "Extra"}, "a", "b")
+	require.Empty(t, touched(t))
+
+	// Dropping the added ecosystem changes only the entries from the second
+	// repository.
+	backdate(t)
+	buildDataset(t, []string{"Wolfi", ""}, "a", "b")
+	require.Equal(t, []string{"CGA-37qj-pjrf-fmrw.json", "all.zip", "manifest.json"}, touched(t))
+
+	// Removing the second repository deletes its entries.
+	backdate(t)
+	buildDataset(t, []string{"Wolfi"}, "a")
+	require.Equal(t, []string{"all.json", "all.zip", "manifest.json"}, touched(t))
+	require.NotContains(t, files(t), "CGA-37qj-pjrf-fmrw.json")
+
+	manifest, err = readOSVManifest(outputDir)
+	require.NoError(t, err)
+	require.NotContains(t, manifest.Entries, "CGA-37qj-pjrf-fmrw")
+	require.Len(t, manifest.Entries, len(allEntries)-1)
+}
@@ -0,0 +1,233 @@
+package advisory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs"
+	wgit "github.com/wolfi-dev/wolfictl/pkg/git"
+	"gopkg.in/yaml.v3"
+)
+
+// ProvenanceDir is the directory in an advisories repository that holds the
+// provenance sidecar files, one per package. Provenance is kept out of the
+// advisory documents themselves, since the advisory schema has no place for
+// it, and the advisory document index ignores subdirectories.
+const ProvenanceDir = "provenance"
+
+// Provenance describes who or what produced an advisory event, and on what
+// basis.
+type Provenance struct {
+	// Actor is the person who asserted the event, if known.
+	Actor *Actor `yaml:"actor,omitempty"`
+
+	// Tool is the program (and subcommand) that produced the event, e.g.
+	// "wolfictl advisory discover".
+	Tool string `yaml:"tool,omitempty"`
+
+	// Evidence is the input that the event was based on, such as the checksum of
+	// the vulnerability database used by a scanner.
+	Evidence []Evidence `yaml:"evidence,omitempty"`
+}
+
+// Actor identifies a person.
+type Actor struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+}
+
+// Evidence is a single piece of input that an advisory event was based on.
+type Evidence struct {
+	// Type describes what kind of evidence this is, e.g. "grype-db-checksum".
+	Type string `yaml:"type"`
+
+	// Value is the evidence itself, e.g. a checksum or a URL.
+	Value string `yaml:"value"`
+}
+
+// NewProvenance returns a Provenance for the given tool and evidence. The actor
+// is the git author configured via the GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL
+// environment variables, if set.
+func NewProvenance(tool string, evidence ...Evidence) *Provenance {
+	p := &Provenance{
+		Tool:     tool,
+		Evidence: evidence,
+	}
+
+	if sig := wgit.GetGitAuthorSignature(); sig != nil {
+		p.Actor = &Actor{
+			Name:  sig.Name,
+			Email: sig.Email,
+		}
+	}
+
+	return p
+}
+
+// ProvenanceDocument is the provenance sidecar for a package's advisory
+// document.
+type ProvenanceDocument struct {
+	Package string             `yaml:"package"`
+	Records []ProvenanceRecord `yaml:"records"`
+}
+
+// ProvenanceRecord is the provenance of a single advisory event. The event is
+// identified by its advisory ID, timestamp, and type.
+type ProvenanceRecord struct {
+	AdvisoryID string       `yaml:"advisory"`
+	Timestamp  v2.Timestamp `yaml:"timestamp"`
+	EventType  string       `yaml:"type"`
+
+	Provenance `yaml:",inline"`
+}
+
+// ProvenancePath returns the path of the provenance sidecar for the given
+// package, relative to the root of the advisories repository.
+func ProvenancePath(packageName string) string {
+	return path.Join(ProvenanceDir, fmt.Sprintf("%s.provenance.yaml", packageName))
+}
+
+// ReadProvenance reads the provenance sidecar for the given package from the
+// advisories repository. If there's no sidecar, an empty document is returned.
+func ReadProvenance(fsys fs.FS, packageName string) (*ProvenanceDocument, error) {
+	f, err := fsys.Open(ProvenancePath(packageName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &ProvenanceDocument{Package: packageName}, nil
+		}
+		return nil, fmt.Errorf("opening provenance for %q: %w", packageName, err)
+	}
+	defer f.Close()
+
+	doc := new(ProvenanceDocument)
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding provenance for %q: %w", packageName, err)
+	}
+	if doc.Package == "" {
+		doc.Package = packageName
+	}
+
+	return doc, nil
+}
+
+// Get returns the provenance of the given event of the given advisory, if
+// recorded.
+func (doc ProvenanceDocument) Get(advisoryID string, event v2.Event) (Provenance, bool) {
+	for _, r := range doc.Records {
+		if r.matches(advisoryID, event) {
+			return r.Provenance, true
+		}
+	}
+
+	return Provenance{}, false
+}
+
+func (r ProvenanceRecord) matches(advisoryID string, event v2.Event) bool {
+	return r.AdvisoryID == advisoryID && r.EventType == event.Type && time.Time(r.Timestamp).Equal(time.Time(event.Timestamp))
+}
+
+// RecordProvenance adds the provenance of the given event of the given advisory
+// to the package's provenance sidecar in the advisories repository, replacing
+// any provenance previously recorded for the same event.
+func RecordProvenance(fsys rwfs.FS, packageName, advisoryID string, event v2.Event, p Provenance) error {
+	doc, err := ReadProvenance(fsys, packageName)
+	if err != nil {
+		return err
+	}
+
+	record := ProvenanceRecord{
+		AdvisoryID: advisoryID,
+		Timestamp:  event.Timestamp,
+		EventType:  event.Type,
+		Provenance: p,
+	}
+
+	replaced := false
+	for i, r := range doc.Records {
+		if r.matches(advisoryID, event) {
+			doc.Records[i] = record
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		doc.Records = append(doc.Records, record)
+	}
+
+	sort.SliceStable(doc.Records, func(i, j int) bool {
+		if doc.Records[i].AdvisoryID != doc.Records[j].AdvisoryID {
+			return doc.Records[i].AdvisoryID < doc.Records[j].AdvisoryID
+		}
+		return time.Time(doc.Records[i].Timestamp).Before(time.Time(doc.Records[j].Timestamp))
+	})
+
+	// The provenance directory doesn't exist until the first record is written.
+	if d, ok := fsys.(dirCreator); ok {
+		if err := d.MkdirAll(ProvenanceDir); err != nil {
+			return fmt.Errorf("creating provenance directory: %w", err)
+		}
+	}
+
+	w, err := fsys.Create(ProvenancePath(packageName))
+	if err != nil {
+		return fmt.Errorf("creating provenance for %q: %w", packageName, err)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		w.Close()
+		return fmt.Errorf("encoding provenance for %q: %w", packageName, err)
+	}
+	if err := enc.Close(); err != nil {
+		w.Close()
+		return fmt.Errorf("encoding provenance for %q: %w", packageName, err)
+	}
+
+	return w.Close()
+}
+
+// dirCreator is implemented by filesystems that need directories to be created
+// before files can be created in them.
+type dirCreator interface {
+	MkdirAll(name string) error
+}
+
+// recordRequestProvenance records the provenance of the request's event, if
+// the request has any, in the provenance sidecar next to the given index's
+// advisory documents. The request is expected to have been applied to the index
+// already.
+func recordRequestProvenance(index *configs.Index[v2.Document], req Request) error {
+	if req.Provenance == nil || req.Event.IsZero() {
+		return nil
+	}
+
+	entry, err := index.Select().WhereName(req.Package).First()
+	if err != nil {
+		return fmt.Errorf("finding advisory document for %q to record provenance: %w", req.Package, err)
+	}
+	doc := entry.Configuration()
+
+	var adv v2.Advisory
+	var ok bool
+	if req.AdvisoryID != "" {
+		adv, ok = doc.Advisories.Get(req.AdvisoryID)
+	} else {
+		adv, ok = doc.Advisories.GetByAnyVulnerability(req.Aliases...)
+	}
+	if !ok {
+		return fmt.Errorf("finding advisory for %q in %q to record provenance", strings.Join(req.VulnerabilityIDs(), ", "), req.Package)
+	}
+
+	return RecordProvenance(index.FS(), req.Package, adv.ID, req.Event, *req.Provenance)
+}
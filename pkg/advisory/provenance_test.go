@@ -0,0 +1,106 @@
+package advisory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/yam/pkg/yam/formatted"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+)
+
+func TestRecordProvenance(t *testing.T) {
+	fsys := rwos.DirFS(t.TempDir())
+
+	detection := v2.Event{
+		Timestamp: v2.Timestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Type:      v2.EventTypeDetection,
+	}
+	fixed := v2.Event{
+		Timestamp: v2.Timestamp(time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)),
+		Type:      v2.EventTypeFixed,
+	}
+
+	// With no sidecar yet, there's no provenance.
+	doc, err := ReadProvenance(fsys, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", doc.Package)
+	_, ok := doc.Get("CGA-2222-2222-2222", detection)
+	assert.False(t, ok)
+
+	discovered := Provenance{
+		Tool:     "wolfictl advisory discover",
+		Evidence: []Evidence{{Type: "nvd-api-host", Value: "services.nvd.nist.gov"}},
+	}
+	require.NoError(t, RecordProvenance(fsys, "foo", "CGA-2222-2222-2222", fixed, discovered))
+	require.NoError(t, RecordProvenance(fsys, "foo", "CGA-2222-2222-2222", detection, discovered))
+
+	// Recording the same event again replaces its provenance.
+	created := Provenance{
+		Actor: &Actor{Name: "Jane Doe", Email: "jane@example.com"},
+		Tool:  "wolfictl advisory create",
+	}
+	require.NoError(t, RecordProvenance(fsys, "foo", "CGA-2222-2222-2222", detection, created))
+
+	doc, err = ReadProvenance(fsys, "foo")
+	require.NoError(t, err)
+	require.Len(t, doc.Records, 2)
+
+	// Records are sorted by timestamp within an advisory.
+	assert.Equal(t, v2.EventTypeDetection, doc.Records[0].EventType)
+	assert.Equal(t, v2.EventTypeFixed, doc.Records[1].EventType)
+
+	got, ok := doc.Get("CGA-2222-2222-2222", detection)
+	require.True(t, ok)
+	assert.Equal(t, created, got)
+
+	got, ok = doc.Get("CGA-2222-2222-2222", fixed)
+	require.True(t, ok)
+	assert.Equal(t, discovered, got)
+}
+
+func TestFSPutter_Upsert_RecordsProvenance(t *testing.T) {
+	dir := t.TempDir()
+	fsys := rwos.DirFS(dir)
+
+	p := &FSPutter{
+		fsys:        fsys,
+		enc:         NewYamDocumentEncoder(formatted.EncodeOptions{Indent: 2}),
+		idGenerator: cgaid.StaticIDGenerator{ID: "CGA-2222-2222-2222"},
+	}
+
+	event := v2.Event{
+		Timestamp: v2.Timestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Type:      v2.EventTypeFixed,
+		Data: v2.Fixed{
+			FixedVersion: "1.0.9-r0",
+		},
+	}
+	provenance := &Provenance{
+		Tool:     "wolfictl advisory guide",
+		Evidence: []Evidence{{Type: "grype-db-checksum", Value: "sha256:abc123"}},
+	}
+
+	id, err := p.Upsert(t.Context(), Request{
+		Package:    "foo",
+		Aliases:    []string{"CVE-2020-8927"},
+		Event:      event,
+		Provenance: provenance,
+	})
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, ProvenancePath("foo")))
+	require.NoError(t, err)
+
+	doc, err := ReadProvenance(fsys, "foo")
+	require.NoError(t, err)
+
+	got, ok := doc.Get(id, event)
+	require.True(t, ok)
+	assert.Equal(t, *provenance, got)
+}
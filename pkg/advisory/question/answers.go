@@ -0,0 +1,96 @@
+package question
+
+import (
+	"fmt"
+	"io"
+
+	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+	"gopkg.in/yaml.v3"
+)
+
+// AnswerFile records the answers given in an advisory guide interview about a
+// single vulnerability in a single package. It can be replayed to reach the
+// same advisory request without user interaction, and serves as a record of how
+// the triage decision was reached.
+type AnswerFile struct {
+	// Package is the name of the package the interview is about.
+	Package string `yaml:"package"`
+
+	// Vulnerability is the ID of the vulnerability the interview is about, as
+	// reported by the scanner (e.g. a CVE, GHSA, or CGA ID).
+	Vulnerability string `yaml:"vulnerability"`
+
+	// Answers are the answers to the interview's questions, in order.
+	Answers []question.Answer `yaml:"answers"`
+}
+
+// ReadAnswerFile decodes an AnswerFile from YAML. Unknown fields are rejected.
+func ReadAnswerFile(r io.Reader) (*AnswerFile, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	f := new(AnswerFile)
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("decoding answer file: %w", err)
+	}
+
+	if f.Package == "" {
+		return nil, fmt.Errorf("answer file has no package")
+	}
+	if f.Vulnerability == "" {
+		return nil, fmt.Errorf("answer file has no vulnerability")
+	}
+
+	return f, nil
+}
+
+// FileName returns the conventional name of the answer file, like
+// "ko-CVE-2023-45288.answers.yaml".
+func (f AnswerFile) FileName() string {
+	return fmt.Sprintf("%s-%s.answers.yaml", f.Package, f.Vulnerability)
+}
+
+// Write encodes the AnswerFile as YAML to the given writer.
+func (f AnswerFile) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("encoding answer file: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// Replay conducts the interview starting at the given root question using the
+// recorded answers, and returns the resulting advisory request. The initial
+// request can have additional information (e.g. resolved aliases) beyond what
+// InitialRequest provides.
+//
+// If the interview terminates without a result, the returned error wraps
+// question.ErrTerminate.
+func (f AnswerFile) Replay(root question.Question[advisory.Request], initial advisory.Request) (advisory.Request, error) {
+	req, err := question.Replay(root, initial, f.Answers)
+	if err != nil {
+		return advisory.Request{}, fmt.Errorf("replaying answers for %s in %s: %w", f.Vulnerability, f.Package, err)
+	}
+
+	return req, nil
+}
+
+// InitialRequest returns the advisory request that an interview about the given
+// vulnerability in the given package starts with.
+func InitialRequest(packageName, vulnerabilityID string) advisory.Request {
+	req := advisory.Request{
+		Package: packageName,
+	}
+	if cgaid.RegexCGA.MatchString(vulnerabilityID) {
+		req.AdvisoryID = vulnerabilityID
+	} else {
+		req.Aliases = []string{vulnerabilityID}
+	}
+
+	return req
+}
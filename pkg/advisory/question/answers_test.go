@@ -0,0 +1,100 @@
+package question
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+)
+
+func TestAnswerFile_Replay(t *testing.T) {
+	root, err := Default(context.Background())
+	if err != nil {
+		t.Fatalf("Default() error = %v", err)
+	}
+
+	cases := []struct {
+		file        string
+		expected    advisory.Request
+		expectedErr error
+	}{
+		{
+			file: "ko-CVE-2023-45288.answers.yaml",
+			expected: advisory.Request{
+				Package: "ko",
+				Aliases: []string{"CVE-2023-45288"},
+				Event: v2.Event{
+					Type: v2.EventTypeFalsePositiveDetermination,
+					Data: v2.FalsePositiveDetermination{
+						Type: v2.FPTypeComponentVulnerabilityMismatch,
+						Note: "This vulnerability is specific to Debian.",
+					},
+				},
+			},
+		},
+		{
+			file: "ko-CGA-2222-3333-4444.answers.yaml",
+			expected: advisory.Request{
+				Package:    "ko",
+				AdvisoryID: "CGA-2222-3333-4444",
+				Event: v2.Event{
+					Type: v2.EventTypeFixNotPlanned,
+					Data: v2.FixNotPlanned{
+						Note: "Package is no longer supported upstream. Source: https://github.com/ko-build/ko/issues/1",
+					},
+				},
+			},
+		},
+		{
+			file:        "ko-CVE-2024-0001.answers.yaml",
+			expectedErr: question.ErrTerminate,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.file, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", "answers", tt.file))
+			if err != nil {
+				t.Fatalf("reading answer file: %v", err)
+			}
+
+			f, err := ReadAnswerFile(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("ReadAnswerFile() error = %v", err)
+			}
+
+			req, err := f.Replay(root, InitialRequest(f.Package, f.Vulnerability))
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Replay() error = %v, want %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			if f.FileName() != tt.file {
+				t.Errorf("FileName() = %q, want %q", f.FileName(), tt.file)
+			}
+
+			if diff := cmp.Diff(tt.expected, req, cmpopts.IgnoreFields(v2.Event{}, "Timestamp")); diff != "" {
+				t.Errorf("unexpected request (-want +got):\n%s", diff)
+			}
+
+			// Writing the answer file back out should reproduce it exactly.
+			buf := new(bytes.Buffer)
+			if err := f.Write(buf); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if diff := cmp.Diff(string(b), buf.String()); diff != "" {
+				t.Errorf("unexpected written answer file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
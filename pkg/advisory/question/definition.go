@@ -0,0 +1,422 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+	"github.com/wolfi-dev/wolfictl/pkg/question/graph"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidDefinition is returned when a question tree definition is
+// malformed.
+var ErrInvalidDefinition = errors.New("invalid question definition")
+
+// Definition is a declarative definition of the advisory guide's question tree.
+type Definition struct {
+	// Root is the ID of the first question to ask.
+	Root string `yaml:"root"`
+
+	// Questions are all of the questions in the tree.
+	Questions []QuestionDefinition `yaml:"questions"`
+}
+
+// QuestionDefinition defines a single question. A question is answered by
+// picking one of its Choices, or by entering text if it has an Input. If it has
+// neither, the question is just a message for the user to acknowledge.
+type QuestionDefinition struct {
+	// ID uniquely identifies the question within the tree.
+	ID string `yaml:"id"`
+
+	// Text is shown to the user.
+	Text string `yaml:"text"`
+
+	// Choices are the options the user can pick from.
+	Choices []ChoiceDefinition `yaml:"choices,omitempty"`
+
+	// Input allows the user to answer with freeform text.
+	Input *InputDefinition `yaml:"input,omitempty"`
+
+	// Next is the ID of the question to ask after a message is acknowledged.
+	// Only applicable to messages.
+	Next string `yaml:"next,omitempty"`
+
+	// Terminate indicates that the interview ends without a result after a
+	// message is acknowledged. Only applicable to messages.
+	Terminate bool `yaml:"terminate,omitempty"`
+}
+
+// ChoiceDefinition defines one of a question's choices.
+type ChoiceDefinition struct {
+	// Text is shown to the user.
+	Text string `yaml:"text"`
+
+	// Set describes how picking this choice updates the advisory request.
+	Set RequestUpdate `yaml:"set,omitempty"`
+
+	// Next is the ID of the question to ask after this choice is picked. If
+	// empty, the interview concludes.
+	Next string `yaml:"next,omitempty"`
+}
+
+// InputDefinition defines how a question is answered with freeform text.
+type InputDefinition struct {
+	// Required indicates that the user must enter non-empty text. If they don't,
+	// the question is asked again. By default, any text is accepted, including
+	// none.
+	Required bool `yaml:"required,omitempty"`
+
+	// Set describes how the entered text updates the advisory request.
+	Set RequestUpdate `yaml:"set,omitempty"`
+
+	// Next is the ID of the question to ask after text is entered. If empty, the
+	// interview concludes.
+	Next string `yaml:"next,omitempty"`
+}
+
+// RequestUpdate describes how an answer updates the advisory request being
+// built by the interview.
+type RequestUpdate struct {
+	// EventType sets the type of the request's event, and sets its timestamp to
+	// the current time.
+	EventType string `yaml:"event-type,omitempty"`
+
+	// FalsePositiveType sets the type of a false positive determination event.
+	FalsePositiveType string `yaml:"false-positive-type,omitempty"`
+
+	// Note sets the event's note. It's a Go template, where {{ .Input }} is the
+	// text entered by the user.
+	Note string `yaml:"note,omitempty"`
+}
+
+// ParseDefinition decodes a question tree definition from YAML. Unknown fields
+// are rejected.
+func ParseDefinition(r io.Reader) (*Definition, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	def := new(Definition)
+	if err := dec.Decode(def); err != nil {
+		return nil, fmt.Errorf("decoding question definition: %w", err)
+	}
+
+	return def, nil
+}
+
+// Build validates the definition and constructs the question tree, returning
+// its root question. Beyond checking the definition itself, Build traverses
+// every path through the tree to make sure that each one either terminates or
+// produces a valid advisory event.
+func (d Definition) Build(ctx context.Context) (question.Question[advisory.Request], error) {
+	if err := d.validate(); err != nil {
+		return question.Question[advisory.Request]{}, err
+	}
+
+	// Allocate all questions up front, so that answers can refer to any question,
+	// regardless of the order of definition.
+	questions := make(map[string]*question.Question[advisory.Request], len(d.Questions))
+	for _, qd := range d.Questions {
+		questions[qd.ID] = &question.Question[advisory.Request]{ID: qd.ID, Text: qd.Text}
+	}
+
+	for _, qd := range d.Questions {
+		q := questions[qd.ID]
+
+		switch {
+		case len(qd.Choices) > 0:
+			choices := make(question.MultipleChoice[advisory.Request], 0, len(qd.Choices))
+			for _, cd := range qd.Choices {
+				u, err := cd.Set.compile()
+				if err != nil {
+					return question.Question[advisory.Request]{}, fmt.Errorf("question %q: choice %q: %w", qd.ID, cd.Text, err)
+				}
+
+				next := questions[cd.Next]
+				choices = append(choices, question.Choice[advisory.Request]{
+					Text: cd.Text,
+					Choose: func(req advisory.Request) (advisory.Request, *question.Question[advisory.Request], error) {
+						req, err := u.apply(req, "")
+						return req, next, err
+					},
+				})
+			}
+			q.Answer = choices
+
+		case qd.Input != nil:
+			u, err := qd.Input.Set.compile()
+			if err != nil {
+				return question.Question[advisory.Request]{}, fmt.Errorf("question %q: input: %w", qd.ID, err)
+			}
+
+			next := questions[qd.Input.Next]
+			required := qd.Input.Required
+			q.Answer = question.AcceptText[advisory.Request](func(req advisory.Request, text string) (advisory.Request, *question.Question[advisory.Request], error) {
+				if required && strings.TrimSpace(text) == "" {
+					// Ask again.
+					return req, q, nil
+				}
+
+				req, err := u.apply(req, text)
+				return req, next, err
+			})
+
+		case qd.Terminate:
+			*q = question.NewTerminatingMessage[advisory.Request](qd.Text)
+			q.ID = qd.ID
+
+		default:
+			*q = question.NewMessage(qd.Text, questions[qd.Next])
+			q.ID = qd.ID
+		}
+	}
+
+	root := *questions[d.Root]
+
+	sampleReq := advisory.Request{
+		Package:    "foo",
+		AdvisoryID: "CGA-xxxx-xxxx-xxxx",
+	}
+	if err := graph.Validate(ctx, root, sampleReq, validateResult); err != nil {
+		return question.Question[advisory.Request]{}, fmt.Errorf("%w: %w", ErrInvalidDefinition, err)
+	}
+
+	return root, nil
+}
+
+// validateResult checks the advisory request produced by a concluded line of
+// questioning.
+func validateResult(req advisory.Request) error {
+	if req.Event.Type == "" {
+		return errors.New("no event type was set")
+	}
+
+	return req.Event.Validate()
+}
+
+// validate checks the definition's structure and references, without building
+// the tree.
+func (d Definition) validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidDefinition}, args...)...))
+	}
+
+	ids := make(map[string]QuestionDefinition, len(d.Questions))
+	for i, qd := range d.Questions {
+		if qd.ID == "" {
+			invalid("question at index %d has no id", i)
+			continue
+		}
+		if _, ok := ids[qd.ID]; ok {
+			invalid("duplicate question id %q", qd.ID)
+			continue
+		}
+		ids[qd.ID] = qd
+	}
+
+	checkNext := func(where, next string) {
+		if next == "" {
+			return
+		}
+		if _, ok := ids[next]; !ok {
+			invalid("%s: next question %q is not defined", where, next)
+		}
+	}
+
+	if d.Root == "" {
+		invalid("no root question specified")
+	} else if _, ok := ids[d.Root]; !ok {
+		invalid("root question %q is not defined", d.Root)
+	}
+
+	for _, qd := range d.Questions {
+		where := fmt.Sprintf("question %q", qd.ID)
+
+		if strings.TrimSpace(qd.Text) == "" {
+			invalid("%s has no text", where)
+		}
+
+		if len(qd.Choices) > 0 && qd.Input != nil {
+			invalid("%s can't have both choices and an input", where)
+		}
+
+		isMessage := len(qd.Choices) == 0 && qd.Input == nil
+		if !isMessage && (qd.Next != "" || qd.Terminate) {
+			invalid("%s: next and terminate only apply to messages; set next on its choices or input instead", where)
+		}
+		if qd.Next != "" && qd.Terminate {
+			invalid("%s can't have both next and terminate", where)
+		}
+		checkNext(where, qd.Next)
+
+		for _, cd := range qd.Choices {
+			if strings.TrimSpace(cd.Text) == "" {
+				invalid("%s has a choice with no text", where)
+			}
+			if err := cd.Set.validate(); err != nil {
+				invalid("%s: choice %q: %v", where, cd.Text, err)
+			}
+			checkNext(fmt.Sprintf("%s: choice %q", where, cd.Text), cd.Next)
+		}
+
+		if qd.Input != nil {
+			if err := qd.Input.Set.validate(); err != nil {
+				invalid("%s: input: %v", where, err)
+			}
+			checkNext(where+": input", qd.Input.Next)
+		}
+	}
+
+	if len(errs) == 0 {
+		for _, id := range d.unreachable() {
+			invalid("question %q is not reachable from the root question", id)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// unreachable returns the IDs of questions that can't be reached from the root
+// question. It assumes that all references are valid.
+func (d Definition) unreachable() []string {
+	byID := make(map[string]QuestionDefinition, len(d.Questions))
+	for _, qd := range d.Questions {
+		byID[qd.ID] = qd
+	}
+
+	reached := make(map[string]bool)
+	queue := []string{d.Root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id == "" || reached[id] {
+			continue
+		}
+		reached[id] = true
+
+		qd := byID[id]
+		queue = append(queue, qd.Next)
+		for _, cd := range qd.Choices {
+			queue = append(queue, cd.Next)
+		}
+		if qd.Input != nil {
+			queue = append(queue, qd.Input.Next)
+		}
+	}
+
+	var ids []string
+	for _, qd := range d.Questions {
+		if !reached[qd.ID] {
+			ids = append(ids, qd.ID)
+		}
+	}
+
+	return ids
+}
+
+func (u RequestUpdate) validate() error {
+	if u.EventType != "" && !slices.Contains(v2.EventTypes, u.EventType) {
+		return fmt.Errorf("unknown event type %q", u.EventType)
+	}
+
+	if u.FalsePositiveType != "" {
+		if !slices.Contains(v2.FPTypes, u.FalsePositiveType) {
+			return fmt.Errorf("unknown false positive type %q", u.FalsePositiveType)
+		}
+		if u.EventType != "" && u.EventType != v2.EventTypeFalsePositiveDetermination {
+			return fmt.Errorf("false positive type can't be set for event type %q", u.EventType)
+		}
+	}
+
+	_, err := u.compile()
+	return err
+}
+
+// compiledRequestUpdate is a RequestUpdate with its note template parsed.
+type compiledRequestUpdate struct {
+	RequestUpdate
+	note *template.Template
+}
+
+func (u RequestUpdate) compile() (*compiledRequestUpdate, error) {
+	c := &compiledRequestUpdate{RequestUpdate: u}
+
+	if u.Note != "" {
+		tmpl, err := template.New("note").Option("missingkey=error").Parse(u.Note)
+		if err != nil {
+			return nil, fmt.Errorf("parsing note template: %w", err)
+		}
+		c.note = tmpl
+	}
+
+	return c, nil
+}
+
+// apply returns the request as updated by this answer, given the text entered
+// by the user (if any).
+func (u compiledRequestUpdate) apply(req advisory.Request, input string) (advisory.Request, error) {
+	if u.EventType != "" {
+		req.Event.Timestamp = v2.Now()
+		if req.Event.Type != u.EventType {
+			req.Event.Type = u.EventType
+			req.Event.Data = nil
+		}
+	}
+
+	if u.FalsePositiveType == "" && u.note == nil {
+		return req, nil
+	}
+
+	note := ""
+	if u.note != nil {
+		sb := new(strings.Builder)
+		if err := u.note.Execute(sb, struct{ Input string }{Input: input}); err != nil {
+			return req, fmt.Errorf("rendering note: %w", err)
+		}
+		note = sb.String()
+	}
+
+	switch req.Event.Type {
+	case v2.EventTypeFalsePositiveDetermination:
+		data, _ := req.Event.Data.(v2.FalsePositiveDetermination)
+		if u.FalsePositiveType != "" {
+			data.Type = u.FalsePositiveType
+		}
+		if u.note != nil {
+			data.Note = note
+		}
+		req.Event.Data = data
+		return req, nil
+
+	case "":
+		return req, errors.New("no event type has been set yet")
+	}
+
+	if u.FalsePositiveType != "" {
+		return req, fmt.Errorf("false positive type can't be set for event type %q", req.Event.Type)
+	}
+
+	switch req.Event.Type {
+	case v2.EventTypeTruePositiveDetermination:
+		req.Event.Data = v2.TruePositiveDetermination{Note: note}
+	case v2.EventTypeAnalysisNotPlanned:
+		req.Event.Data = v2.AnalysisNotPlanned{Note: note}
+	case v2.EventTypeFixNotPlanned:
+		req.Event.Data = v2.FixNotPlanned{Note: note}
+	case v2.EventTypePendingUpstreamFix:
+		req.Event.Data = v2.PendingUpstreamFix{Note: note}
+	default:
+		return req, fmt.Errorf("a note can't be set for event type %q", req.Event.Type)
+	}
+
+	return req, nil
+}
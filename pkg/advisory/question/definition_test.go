@@ -0,0 +1,296 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+	"github.com/wolfi-dev/wolfictl/pkg/question/graph"
+)
+
+func TestDefault(t *testing.T) {
+	ctx := context.Background()
+
+	root, err := Default(ctx)
+	if err != nil {
+		t.Fatalf("Default() error = %v", err)
+	}
+
+	expected, err := os.ReadFile("testdata/default.dot")
+	if err != nil {
+		t.Fatalf("reading expected DOT: %v", err)
+	}
+
+	dot, err := graph.Dot(ctx, root, advisory.Request{Package: "foo", AdvisoryID: "CGA-xxxx-xxxx-xxxx"})
+	if err != nil {
+		t.Fatalf("Dot() error = %v", err)
+	}
+
+	if diff := cmp.Diff(string(expected), dot); diff != "" {
+		t.Errorf("unexpected question graph (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefinition_Build(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("answers update the request", func(t *testing.T) {
+		const def = `
+root: kind
+questions:
+  - id: kind
+    text: What kind?
+    choices:
+      - text: False positive
+        set:
+          event-type: false-positive-determination
+          false-positive-type: vulnerable-code-not-in-execution-path
+        next: why
+      - text: Won't fix
+        set:
+          event-type: fix-not-planned
+          note: Not worth it.
+  - id: why
+    text: Why?
+    input:
+      required: true
+      set:
+        note: "Because {{ .Input }}."
+`
+		root, err := Load(ctx, strings.NewReader(def))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		choices := root.Answer.(question.MultipleChoice[advisory.Request])
+
+		req, next, err := choices[1].Choose(advisory.Request{Package: "foo"})
+		if err != nil {
+			t.Fatalf("Choose() error = %v", err)
+		}
+		if next != nil {
+			t.Errorf("expected the interview to conclude, got next question %q", next.Text)
+		}
+		if diff := cmp.Diff(v2.FixNotPlanned{Note: "Not worth it."}, req.Event.Data); diff != "" {
+			t.Errorf("unexpected event data (-want +got):\n%s", diff)
+		}
+
+		req, next, err = choices[0].Choose(advisory.Request{Package: "foo"})
+		if err != nil {
+			t.Fatalf("Choose() error = %v", err)
+		}
+		if next == nil || next.Text != "Why?" {
+			t.Fatalf("expected the next question to be %q, got %v", "Why?", next)
+		}
+
+		input := next.Answer.(question.AcceptText[advisory.Request])
+
+		_, again, err := input(req, "  ")
+		if err != nil {
+			t.Fatalf("AcceptText() error = %v", err)
+		}
+		if again != next {
+			t.Errorf("expected an empty required input to ask the question again")
+		}
+
+		req, next, err = input(req, "it's unreachable")
+		if err != nil {
+			t.Fatalf("AcceptText() error = %v", err)
+		}
+		if next != nil {
+			t.Errorf("expected the interview to conclude, got next question %q", next.Text)
+		}
+
+		if req.Event.Type != v2.EventTypeFalsePositiveDetermination {
+			t.Errorf("expected event type %q, got %q", v2.EventTypeFalsePositiveDetermination, req.Event.Type)
+		}
+		expectedData := v2.FalsePositiveDetermination{
+			Type: v2.FPTypeVulnerableCodeNotInExecutionPath,
+			Note: "Because it's unreachable.",
+		}
+		if diff := cmp.Diff(expectedData, req.Event.Data); diff != "" {
+			t.Errorf("unexpected event data (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("inputs accept empty text unless required", func(t *testing.T) {
+		const def = `
+root: why
+questions:
+  - id: why
+    text: Why?
+    input:
+      set:
+        event-type: fix-not-planned
+        note: "Because {{ .Input }}."
+`
+		root, err := Load(ctx, strings.NewReader(def))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		input := root.Answer.(question.AcceptText[advisory.Request])
+
+		_, next, err := input(advisory.Request{Package: "foo"}, "")
+		if err != nil {
+			t.Fatalf("AcceptText() error = %v", err)
+		}
+		if next != nil {
+			t.Errorf("expected the interview to conclude, got next question %q", next.Text)
+		}
+	})
+
+	cases := []struct {
+		name        string
+		def         string
+		expectedErr string
+	}{
+		{
+			name: "unknown field",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    terminate: true
+    colour: blue
+`,
+			expectedErr: "field colour not found",
+		},
+		{
+			name: "undefined root",
+			def: `
+root: b
+questions:
+  - id: a
+    text: A
+    terminate: true
+`,
+			expectedErr: `root question "b" is not defined`,
+		},
+		{
+			name: "undefined next question",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    choices:
+      - text: "Yes"
+        next: b
+`,
+			expectedErr: `next question "b" is not defined`,
+		},
+		{
+			name: "duplicate id",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    terminate: true
+  - id: a
+    text: Also A
+    terminate: true
+`,
+			expectedErr: `duplicate question id "a"`,
+		},
+		{
+			name: "unknown false positive type",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    choices:
+      - text: "Yes"
+        set:
+          event-type: false-positive-determination
+          false-positive-type: it-is-fine
+`,
+			expectedErr: `unknown false positive type "it-is-fine"`,
+		},
+		{
+			name: "unreachable question",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    terminate: true
+  - id: b
+    text: B
+    terminate: true
+`,
+			expectedErr: `question "b" is not reachable`,
+		},
+		{
+			name: "path without event type",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    choices:
+      - text: "Yes"
+`,
+			expectedErr: "no event type was set",
+		},
+		{
+			name: "note before event type",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    choices:
+      - text: "Yes"
+        set:
+          note: Hello
+`,
+			expectedErr: "no event type has been set yet",
+		},
+		{
+			name: "cycle",
+			def: `
+root: a
+questions:
+  - id: a
+    text: A
+    choices:
+      - text: Again
+        next: b
+  - id: b
+    text: B
+    next: a
+`,
+			expectedErr: graph.ErrCycle.Error(),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(ctx, strings.NewReader(tt.def))
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error to contain %q, got %q", tt.expectedErr, err.Error())
+			}
+
+			if strings.HasPrefix(tt.name, "unknown field") {
+				return
+			}
+			if !errors.Is(err, ErrInvalidDefinition) {
+				t.Errorf("expected error to wrap ErrInvalidDefinition, got %v", err)
+			}
+		})
+	}
+}
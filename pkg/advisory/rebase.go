@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"slices"
 
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/melange/pkg/config"
 	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/chainguard-dev/clog"
@@ -31,6 +33,21 @@ type RebaseOptions struct {
 
 	// Used for any new events added to the destination.
 	CurrentTime v2.Timestamp
+
+	// TranslateFixedVersions enables rebasing advisories whose latest event is
+	// "fixed". The source's fixed version is translated to the earliest
+	// destination version that contains the same upstream fix, as determined from
+	// DestinationAPKIndexes and DestinationBuildCfgs. Advisories whose fixed
+	// version can't be translated are skipped.
+	TranslateFixedVersions bool
+
+	// DestinationAPKIndexes are the APKINDEXes of the destination distro's package
+	// repository, used to find the destination's published package versions.
+	DestinationAPKIndexes []*apk.APKIndex
+
+	// DestinationBuildCfgs is the index of the destination distro's build
+	// configurations, used to find the package's currently defined version.
+	DestinationBuildCfgs *configs.Index[config.Configuration]
 }
 
 // Rebase updates the destination package's advisories (or a specific advisory)
@@ -88,7 +105,30 @@ func Rebase(ctx context.Context, opts RebaseOptions) error {
 		// — "fixed" (values for "fixed version" would be incorrect since they describe
 		// a separate APK repository; additionally, we don't consider a package's first
 		// version to "fix" any vulnerabilities, since those vulnerabilities were never
-		// present in the current package's lineage).
+		// present in the current package's lineage), unless we've been asked to
+		// translate fixed versions to the destination.
+		if srcLatestEvent.Type == v2.EventTypeFixed && opts.TranslateFixedVersions {
+			dstEvent, err := opts.translateFixedEvent(ctx, srcLatestEvent)
+			if err != nil {
+				if errors.Is(err, ErrFixedVersionNotTranslatable) {
+					log.Warnf("skipping fixed advisory: %v", err)
+					continue
+				}
+				return fmt.Errorf("translating fixed version for %q: %w", srcAdv.ID, err)
+			}
+
+			dstFixed, ok := dstEvent.Data.(v2.Fixed)
+			if !ok {
+				return fmt.Errorf("translating fixed version for %q: unexpected event data of type %T", srcAdv.ID, dstEvent.Data)
+			}
+			log.Info("translated fixed version for destination", "dstFixedVersion", dstFixed.FixedVersion)
+
+			if err := opts.updateDestinationIndexWithNewAdvisoryData(clog.WithLogger(ctx, log), srcAdv.Aliases, dstEvent); err != nil {
+				return fmt.Errorf("updating destination with new advisory data for %q: %w", srcAdv.ID, err)
+			}
+			continue
+		}
+
 		if slices.Contains(
 			[]string{v2.EventTypeDetection, v2.EventTypeFixed},
 			srcLatestEvent.Type,
@@ -0,0 +1,125 @@
+package advisory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/samber/lo"
+	"github.com/wolfi-dev/wolfictl/pkg/versions"
+)
+
+// ErrFixedVersionNotTranslatable is returned when a source advisory's fixed
+// version can't be translated into a fixed version for the destination.
+var ErrFixedVersionNotTranslatable = errors.New("fixed version can't be translated to destination")
+
+// translateFixedEvent returns a "fixed" event for the destination that
+// corresponds to the given "fixed" event from the source.
+func (opts RebaseOptions) translateFixedEvent(ctx context.Context, srcEvent v2.Event) (v2.Event, error) {
+	srcFixed, ok := srcEvent.Data.(v2.Fixed)
+	if !ok {
+		return v2.Event{}, fmt.Errorf("unexpected data type for fixed event: %T", srcEvent.Data)
+	}
+
+	dstVersions := opts.destinationVersions()
+	clog.FromContext(ctx).Debug("translating fixed version", "srcFixedVersion", srcFixed.FixedVersion, "dstVersions", dstVersions)
+
+	dstFixedVersion, err := translateFixedVersion(srcFixed.FixedVersion, dstVersions)
+	if err != nil {
+		return v2.Event{}, err
+	}
+
+	return v2.Event{
+		Timestamp: opts.CurrentTime,
+		Type:      v2.EventTypeFixed,
+		Data: v2.Fixed{
+			FixedVersion: dstFixedVersion,
+		},
+	}, nil
+}
+
+// destinationVersions returns the versions of the package published in the
+// destination's APKINDEXes, plus the version currently defined in the
+// destination's build configuration, sorted from oldest to newest.
+func (opts RebaseOptions) destinationVersions() []string {
+	versionSet := make(map[string]struct{})
+
+	for _, apkindex := range opts.DestinationAPKIndexes {
+		if apkindex == nil {
+			continue
+		}
+
+		for _, pkg := range apkindex.Packages {
+			if pkg.Name == opts.PackageName {
+				versionSet[pkg.Version] = struct{}{}
+			}
+		}
+	}
+
+	if opts.DestinationBuildCfgs != nil {
+		entry, err := opts.DestinationBuildCfgs.Select().WhereName(opts.PackageName).First()
+		if err == nil {
+			pkg := entry.Configuration().Package
+			versionSet[fmt.Sprintf("%s-r%d", pkg.Version, pkg.Epoch)] = struct{}{}
+		}
+	}
+
+	vs := lo.Keys(versionSet)
+	sort.Sort(sort.Reverse(versions.ByLatestStrings(vs)))
+
+	return vs
+}
+
+// translateFixedVersion returns the earliest of the destination's versions
+// (sorted from oldest to newest) that contains the upstream fix described by
+// the source's fixed version.
+//
+// A source fixed version with an epoch of 0 means the fix came with that
+// upstream release, so the fix is in the first destination version with the
+// same upstream version, or a later one. A source fixed version with a nonzero
+// epoch means the fix was a patch applied by the source distro, which tells us
+// nothing about the destination's builds, so it can't be translated.
+//
+// If the destination's earliest version already contains the fix, the
+// vulnerability was never present in the destination package's lineage, and
+// that version isn't considered a fix.
+func translateFixedVersion(srcFixedVersion string, dstVersions []string) (string, error) {
+	i := strings.LastIndex(srcFixedVersion, "-r")
+	if i == -1 {
+		return "", fmt.Errorf("%w: source fixed version %q has no epoch", ErrFixedVersionNotTranslatable, srcFixedVersion)
+	}
+	srcUpstream := srcFixedVersion[:i]
+	if epoch, err := strconv.Atoi(srcFixedVersion[i+2:]); err != nil || epoch != 0 {
+		return "", fmt.Errorf("%w: source fixed version %q came from a distro patch, not an upstream release", ErrFixedVersionNotTranslatable, srcFixedVersion)
+	}
+
+	for i, v := range dstVersions {
+		if !containsUpstreamVersion(upstreamVersion(v), srcUpstream) {
+			continue
+		}
+
+		if i == 0 {
+			return "", fmt.Errorf("%w: the earliest destination version %q already contains upstream version %q", ErrFixedVersionNotTranslatable, v, srcUpstream)
+		}
+
+		return v, nil
+	}
+
+	return "", fmt.Errorf("%w: no destination version contains upstream version %q", ErrFixedVersionNotTranslatable, srcUpstream)
+}
+
+// containsUpstreamVersion reports whether the upstream version is equal to or
+// later than the fixed upstream version.
+func containsUpstreamVersion(upstream, fixedUpstream string) bool {
+	if upstream == fixedUpstream {
+		return true
+	}
+
+	// ByLatestStrings sorts later versions first.
+	return versions.ByLatestStrings{upstream, fixedUpstream}.Less(0, 1)
+}
@@ -9,10 +9,12 @@ import (
 	"testing"
 	"time"
 
+	"chainguard.dev/apko/pkg/apk/apk"
 	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/stretchr/testify/assert"
 	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	buildconfigs "github.com/wolfi-dev/wolfictl/pkg/configs/build"
 	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
 	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/testerfs"
 )
@@ -179,3 +181,116 @@ func TestRebase(t *testing.T) {
 		}
 	})
 }
+
+func TestRebase_TranslateFixedVersions(t *testing.T) {
+	ctx := context.Background()
+	testCaseDir := filepath.Join("testdata", "rebase", "translate-fixed-versions")
+
+	srcIndex, err := adv2.NewIndex(ctx, memfs.New(os.DirFS(filepath.Join(testCaseDir, "src"))))
+	if err != nil {
+		t.Fatalf("creating advisory index for source directory: %v", err)
+	}
+
+	dstIndex, err := adv2.NewIndex(ctx, memfs.New(os.DirFS(filepath.Join(testCaseDir, "dst"))))
+	if err != nil {
+		t.Fatalf("creating advisory index for destination directory: %v", err)
+	}
+
+	dstBuildCfgs, err := buildconfigs.NewIndex(ctx, memfs.New(os.DirFS(filepath.Join(testCaseDir, "dst-build"))))
+	if err != nil {
+		t.Fatalf("creating build configs index for destination: %v", err)
+	}
+
+	expectedNewAdvID := "CGA-zzzz-zzzz-zzzz"
+	cgaid.DefaultIDGenerator = cgaid.StaticIDGenerator{ID: expectedNewAdvID}
+	defer func() { cgaid.DefaultIDGenerator = &cgaid.RandomIDGenerator{} }()
+
+	currentTime := v2.Timestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	err = Rebase(ctx, RebaseOptions{
+		SourceIndex:            srcIndex,
+		DestinationIndex:       dstIndex,
+		PackageName:            "brotli",
+		CurrentTime:            currentTime,
+		TranslateFixedVersions: true,
+		DestinationAPKIndexes: []*apk.APKIndex{
+			{
+				Packages: []*apk.Package{
+					{Name: "brotli", Version: "1.0.8-r0"},
+					{Name: "brotli", Version: "1.0.8-r1"},
+					{Name: "brotli", Version: "1.0.9-r2"},
+					{Name: "brotli", Version: "1.0.10-r0"},
+					{Name: "brotli-dev", Version: "1.0.9-r0"},
+				},
+			},
+		},
+		DestinationBuildCfgs: dstBuildCfgs,
+	})
+	if err != nil {
+		t.Fatalf("Rebase() error = %v", err)
+	}
+
+	entry, err := dstIndex.Select().WhereName("brotli").First()
+	if err != nil {
+		t.Fatalf("finding destination document: %v", err)
+	}
+	doc := entry.Configuration()
+
+	fixedVersions := make(map[string]string)
+	for _, adv := range doc.Advisories {
+		latest := adv.Latest()
+		if latest.Type != v2.EventTypeFixed {
+			continue
+		}
+		if latest.Timestamp != currentTime {
+			t.Errorf("advisory %s: fixed event timestamp = %v, want %v", adv.ID, latest.Timestamp, currentTime)
+		}
+		fixedVersions[strings.Join(adv.Aliases, ",")] = latest.Data.(v2.Fixed).FixedVersion
+	}
+
+	expected := map[string]string{
+		// Fixed in the first destination version with the same upstream version.
+		"CVE-2023-1111": "1.0.9-r2",
+		// A new destination advisory was created.
+		"CVE-2023-5555": "1.0.10-r0",
+		// Not present: CVE-2023-2222 was fixed by a distro patch, CVE-2023-3333 isn't
+		// fixed in any destination version, and CVE-2023-4444 was fixed before the
+		// earliest destination version.
+	}
+	assert.Equal(t, expected, fixedVersions)
+
+	if len(doc.Advisories) != 2 {
+		t.Errorf("destination has %d advisories, want 2", len(doc.Advisories))
+	}
+}
+
+func TestTranslateFixedVersion(t *testing.T) {
+	dstVersions := []string{"1.0.8-r0", "1.0.8-r1", "1.0.9-r2", "1.0.10-r0", "1.0.10-r1"}
+
+	cases := []struct {
+		srcFixedVersion string
+		expected        string
+		wantErr         bool
+	}{
+		{srcFixedVersion: "1.0.9-r0", expected: "1.0.9-r2"},
+		{srcFixedVersion: "1.0.8-r0", wantErr: true},
+		{srcFixedVersion: "1.0.10-r0", expected: "1.0.10-r0"},
+		{srcFixedVersion: "1.0.9.1-r0", expected: "1.0.10-r0"},
+		{srcFixedVersion: "1.0.9-r1", wantErr: true},
+		{srcFixedVersion: "1.0.11-r0", wantErr: true},
+		{srcFixedVersion: "1.0.9", wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.srcFixedVersion, func(t *testing.T) {
+			got, err := translateFixedVersion(tt.srcFixedVersion, dstVersions)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrFixedVersionNotTranslatable)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -32,6 +32,11 @@ type Request struct {
 
 	// Event is the event to add to the advisory.
 	Event v2.Event
+
+	// Provenance optionally describes who or what produced the event. When set,
+	// it's recorded in the package's provenance sidecar (see ProvenancePath)
+	// alongside the event.
+	Provenance *Provenance
 }
 
 // VulnerabilityIDs returns the list of vulnerability IDs for the Request. This
@@ -1,116 +1,299 @@
 package advisory
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
+	"path"
 	"sort"
+	"strings"
 
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
-	"github.com/chainguard-dev/clog"
 	"github.com/wolfi-dev/wolfictl/pkg/advisory/secdb"
 	"github.com/wolfi-dev/wolfictl/pkg/configs"
 )
 
-const apkURL = "{{urlprefix}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk"
+const (
+	apkURL                  = "{{urlprefix}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk"
+	apkURLWithDistroVersion = "{{urlprefix}}/{{distroversion}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk"
+)
 
 // BuildSecurityDatabaseOptions contains the options for building a database.
 type BuildSecurityDatabaseOptions struct {
+	// AdvisoryDocIndices are the advisory repositories from which to build the
+	// database, in order of precedence. Advisory data for the same package from
+	// multiple repositories is merged (see MergeAdvisoryDocIndices).
 	AdvisoryDocIndices []*configs.Index[v2.Document]
 
 	URLPrefix string
 	Archs     []string
 	Repo      string
+
+	// Strict causes building the database to fail if advisory data from multiple
+	// repositories conflicts, rather than resolving the conflict by precedence.
+	Strict bool
 }
 
-var (
-	ErrNoPackageSecurityData = errors.New("no package security data found")
-	ErrorPackageCollision    = errors.New("found multiple advisory documents for the same package")
-)
+var ErrNoPackageSecurityData = errors.New("no package security data found")
 
 // BuildSecurityDatabase builds an Alpine-style security database from the given options.
 func BuildSecurityDatabase(ctx context.Context, opts BuildSecurityDatabaseOptions) ([]byte, error) {
-	log := clog.FromContext(ctx)
-	var packageEntries []secdb.PackageEntry
+	packageEntries, err := securityDatabasePackageEntries(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	seenPackages := make(map[string]struct{})
+	db := secdb.Database{
+		APKURL:    apkURL,
+		Archs:     opts.Archs,
+		Repo:      opts.Repo,
+		URLPrefix: opts.URLPrefix,
+		Packages:  packageEntries,
+	}
 
-	for _, index := range opts.AdvisoryDocIndices {
-		var indexPackageEntries []secdb.PackageEntry
+	return marshalSecurityDatabase(db)
+}
 
-		for _, doc := range index.Select().Configurations() {
-			if _, exists := seenPackages[doc.Package.Name]; exists {
-				// TODO Merge the events between multiple advisories
-				log.InfoContextf(ctx, "cannot process additional advisory data for package %q: %v", doc.Package.Name, ErrorPackageCollision)
-			}
-			seenPackages[doc.Package.Name] = struct{}{}
+// SecurityDatabaseFile is a single file of a security database that's split
+// across multiple files.
+type SecurityDatabaseFile struct {
+	// Path is the file's path, relative to the root of the security database's
+	// output directory, e.g. "v3.19/x86_64/os.json".
+	Path string
 
-			if len(doc.Advisories) == 0 {
-				continue
-			}
+	// Data is the file's JSON-encoded security database.
+	Data []byte
+}
 
-			secfixes := make(secdb.Secfixes)
+// BuildSecurityDatabaseFilesOptions contains the options for building a
+// security database split across multiple files.
+type BuildSecurityDatabaseFilesOptions struct {
+	BuildSecurityDatabaseOptions
 
-			sort.Slice(doc.Advisories, func(i, j int) bool {
-				return doc.Advisories[i].ID < doc.Advisories[j].ID
-			})
+	// SplitByArch causes a separate file to be emitted for each of the
+	// architectures in Archs, rather than one file listing all of them.
+	SplitByArch bool
+
+	// Branches are the branches of the package repository (e.g. "v3.19") for which
+	// to emit files. Each branch gets its own directory, and the branch is recorded
+	// in each file as the "distroversion", as in Alpine's security databases. When
+	// empty, files are emitted at the root of the output directory.
+	Branches []string
+}
 
-			for _, advisory := range doc.Advisories {
-				if len(advisory.Events) == 0 {
-					continue
-				}
-
-				sortedEvents := advisory.SortedEvents()
-				latest := sortedEvents[len(advisory.Events)-1]
-
-				addVulnToPkgVersion := func(vulnID string) {
-					switch latest.Type {
-					case v2.EventTypeFixed:
-						version := latest.Data.(v2.Fixed).FixedVersion //nolint:errcheck // We're confident in this type assertion
-						secfixes[version] = append(secfixes[version], vulnID)
-						sort.Strings(secfixes[version])
-					case v2.EventTypeFalsePositiveDetermination:
-						secfixes[secdb.NAK] = append(secfixes[secdb.NAK], vulnID)
-						sort.Strings(secfixes[secdb.NAK])
-					}
-				}
-
-				// Get vulnerabilities from advisory aliases
-				for _, alias := range advisory.Aliases {
-					vulnID := alias
-					addVulnToPkgVersion(vulnID)
-				}
+// BuildSecurityDatabaseFiles builds an Alpine-style security database from the
+// given options, split into one file per branch and (optionally) per
+// architecture. Files are laid out as "[<branch>/][<arch>/]<repo>.json". Each
+// file is validated against the secdb JSON schema.
+func BuildSecurityDatabaseFiles(ctx context.Context, opts BuildSecurityDatabaseFilesOptions) ([]SecurityDatabaseFile, error) {
+	if opts.Repo == "" {
+		return nil, errors.New("a repository name is required")
+	}
+	if len(opts.Archs) == 0 {
+		return nil, errors.New("at least one architecture is required")
+	}
+
+	packageEntries, err := securityDatabasePackageEntries(ctx, opts.BuildSecurityDatabaseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := opts.Branches
+	if len(branches) == 0 {
+		branches = []string{""}
+	}
+
+	archGroups := [][]string{opts.Archs}
+	if opts.SplitByArch {
+		archGroups = nil
+		for _, arch := range opts.Archs {
+			archGroups = append(archGroups, []string{arch})
+		}
+	}
+
+	var files []SecurityDatabaseFile
+	for _, branch := range branches {
+		for _, archs := range archGroups {
+			db := secdb.Database{
+				APKURL:        apkURL,
+				Archs:         archs,
+				Repo:          opts.Repo,
+				URLPrefix:     opts.URLPrefix,
+				DistroVersion: branch,
+				Packages:      packageEntries,
 			}
 
-			if len(secfixes) == 0 {
-				continue
+			var elems []string
+			if branch != "" {
+				db.APKURL = apkURLWithDistroVersion
+				elems = append(elems, branch)
+			}
+			if opts.SplitByArch {
+				elems = append(elems, archs[0])
 			}
+			elems = append(elems, opts.Repo+".json")
 
-			pe := secdb.PackageEntry{
-				Pkg: secdb.Package{
-					Name:     doc.Package.Name,
-					Secfixes: secfixes,
-				},
+			data, err := marshalSecurityDatabase(db)
+			if err != nil {
+				return nil, err
 			}
 
-			indexPackageEntries = append(indexPackageEntries, pe)
+			files = append(files, SecurityDatabaseFile{
+				Path: path.Join(elems...),
+				Data: data,
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// securityDatabasePackageEntries returns the security database's package
+// entries for the advisory data.
+func securityDatabasePackageEntries(ctx context.Context, opts BuildSecurityDatabaseOptions) ([]secdb.PackageEntry, error) {
+	for _, index := range opts.AdvisoryDocIndices {
+		hasSecurityData := false
+		for _, doc := range index.Select().Configurations() {
+			if len(secfixesForDocument(doc)) > 0 {
+				hasSecurityData = true
+				break
+			}
 		}
 
-		if len(indexPackageEntries) == 0 {
+		if !hasSecurityData {
 			// Catch the unexpected case where an advisories directory contains no security data.
 			return nil, ErrNoPackageSecurityData
 		}
+	}
 
-		packageEntries = append(packageEntries, indexPackageEntries...)
+	docs, err := MergeAdvisoryDocIndices(ctx, opts.AdvisoryDocIndices, opts.Strict)
+	if err != nil {
+		return nil, err
 	}
 
-	db := secdb.Database{
-		APKURL:    apkURL,
-		Archs:     opts.Archs,
-		Repo:      opts.Repo,
-		URLPrefix: opts.URLPrefix,
-		Packages:  packageEntries,
+	var packageEntries []secdb.PackageEntry
+
+	for _, doc := range docs {
+		secfixes := secfixesForDocument(doc.Document)
+		if len(secfixes) == 0 {
+			continue
+		}
+
+		pe := secdb.PackageEntry{
+			Pkg: secdb.Package{
+				Name:     doc.Package.Name,
+				Secfixes: secfixes,
+			},
+		}
+
+		packageEntries = append(packageEntries, pe)
+	}
+
+	return packageEntries, nil
+}
+
+// marshalSecurityDatabase encodes the database as JSON and validates the result
+// against the secdb JSON schema.
+func marshalSecurityDatabase(db secdb.Database) ([]byte, error) {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := secdb.Validate(data); err != nil {
+		return nil, fmt.Errorf("validating security database for %s: %w", path.Join(db.DistroVersion, db.Repo), err)
+	}
+
+	return data, nil
+}
+
+// secfixesForDocument returns the secfixes for the document's resolved
+// advisories.
+func secfixesForDocument(doc v2.Document) secdb.Secfixes {
+	secfixes := make(secdb.Secfixes)
+
+	for _, advisory := range doc.Advisories {
+		if len(advisory.Events) == 0 {
+			continue
+		}
+
+		latest := advisory.Latest()
+
+		addVulnToPkgVersion := func(vulnID string) {
+			switch latest.Type {
+			case v2.EventTypeFixed:
+				version := latest.Data.(v2.Fixed).FixedVersion //nolint:errcheck // We're confident in this type assertion
+				secfixes[version] = append(secfixes[version], vulnID)
+				sort.Strings(secfixes[version])
+			case v2.EventTypeFalsePositiveDetermination:
+				secfixes[secdb.NAK] = append(secfixes[secdb.NAK], vulnID)
+				sort.Strings(secfixes[secdb.NAK])
+			}
+		}
+
+		// Get vulnerabilities from advisory aliases
+		for _, alias := range advisory.Aliases {
+			vulnID := alias
+			addVulnToPkgVersion(vulnID)
+		}
+	}
+
+	return secfixes
+}
+
+// ErrSecurityDatabaseMismatch is returned when a published security database
+// differs from the one built from the current advisory data.
+var ErrSecurityDatabaseMismatch = errors.New("published security database differs from advisory data")
+
+// CheckSecurityDatabaseFiles verifies that each of the given files matches,
+// byte for byte, the published file at the same path in the given filesystem.
+// Published files that look like security database files (with the same name,
+// at the same depth) but that aren't among the given files are reported as
+// stale. All problems are reported together.
+func CheckSecurityDatabaseFiles(files []SecurityDatabaseFile, published fs.FS) error {
+	var errs []error
+
+	expected := make(map[string]bool, len(files))
+	names := make(map[string]bool)
+	depth := 0
+	for _, f := range files {
+		expected[f.Path] = true
+		names[path.Base(f.Path)] = true
+		depth = max(depth, strings.Count(f.Path, "/"))
+
+		data, err := fs.ReadFile(published, f.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: reading published file: %w", f.Path, err))
+			continue
+		}
+
+		if !bytes.Equal(data, f.Data) {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Path, ErrSecurityDatabaseMismatch))
+		}
+	}
+
+	err := fs.WalkDir(published, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && strings.Count(p, "/") >= depth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if names[d.Name()] && !expected[p] {
+			errs = append(errs, fmt.Errorf("%s: %w: the advisory data no longer produces this file", p, ErrSecurityDatabaseMismatch))
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing published files: %w", err))
 	}
 
-	return json.MarshalIndent(db, "", "  ")
+	return errors.Join(errs...)
 }
@@ -1,11 +1,12 @@
 package secdb
 
 type Database struct {
-	APKURL    string         `json:"apkurl"`
-	Archs     []string       `json:"archs"`
-	Repo      string         `json:"reponame"`
-	URLPrefix string         `json:"urlprefix"`
-	Packages  []PackageEntry `json:"packages"`
+	APKURL        string         `json:"apkurl"`
+	Archs         []string       `json:"archs"`
+	Repo          string         `json:"reponame"`
+	URLPrefix     string         `json:"urlprefix"`
+	DistroVersion string         `json:"distroversion,omitempty"`
+	Packages      []PackageEntry `json:"packages"`
 }
 
 type PackageEntry struct {
@@ -0,0 +1,35 @@
+package secdb
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schema.json
+var schemaJSON string
+
+const schemaURL = "schema.json"
+
+var schema = jsonschema.MustCompileString(schemaURL, schemaJSON)
+
+// Validate checks that the given JSON-encoded security database conforms to the
+// secdb JSON schema.
+func Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("decoding security database: %w", err)
+	}
+
+	if err := schema.Validate(v); err != nil {
+		return fmt.Errorf("security database doesn't conform to schema: %w", err)
+	}
+
+	return nil
+}
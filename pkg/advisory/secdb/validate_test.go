@@ -0,0 +1,55 @@
+package secdb
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: `{"apkurl": "{{urlprefix}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk", "archs": ["x86_64"], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": [{"pkg": {"name": "ko", "secfixes": {"0": ["CVE-2023-1234"], "1.2.3-r1": ["CVE-2023-5678"]}}}]}`,
+		},
+		{
+			name: "no packages",
+			data: `{"apkurl": "x", "archs": ["x86_64"], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": []}`,
+		},
+		{
+			name: "null packages",
+			data: `{"apkurl": "x", "archs": ["x86_64"], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": null}`,
+		},
+		{
+			name:    "no archs",
+			data:    `{"apkurl": "x", "archs": [], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty secfixes version",
+			data:    `{"apkurl": "x", "archs": ["x86_64"], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": [{"pkg": {"name": "ko", "secfixes": {"": ["CVE-2023-1234"]}}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			data:    `{"apkurl": "x", "archs": ["x86_64"], "reponame": "os", "urlprefix": "https://packages.wolfi.dev", "packages": [], "extra": 1}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			data:    `apkurl: x`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
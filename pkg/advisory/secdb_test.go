@@ -2,13 +2,17 @@ package advisory
 
 import (
 	"context"
+	"encoding/json"
+	"io/fs"
 	"os"
 	"testing"
+	"testing/fstest"
 
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory/secdb"
 	"github.com/wolfi-dev/wolfictl/pkg/configs"
 	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
 	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
@@ -91,3 +95,119 @@ func TestBuildSecurityDatabase(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildSecurityDatabase_Empty(t *testing.T) {
+	database, err := BuildSecurityDatabase(context.Background(), BuildSecurityDatabaseOptions{
+		URLPrefix: "https://packages.wolfi.dev",
+		Archs:     []string{"x86_64"},
+		Repo:      "os",
+	})
+	require.NoError(t, err)
+
+	// An empty database keeps its historical "null" list of packages.
+	assert.Contains(t, string(database), `"packages": null`)
+}
+
+func TestBuildSecurityDatabaseFiles(t *testing.T) {
+	index, err := adv2.NewIndex(context.Background(), rwos.DirFS("./testdata/secdb/advisories"))
+	require.NoError(t, err)
+
+	opts := BuildSecurityDatabaseFilesOptions{
+		BuildSecurityDatabaseOptions: BuildSecurityDatabaseOptions{
+			AdvisoryDocIndices: []*configs.Index[v2.Document]{index},
+			URLPrefix:          "https://packages.wolfi.dev",
+			Archs:              []string{"x86_64", "aarch64"},
+			Repo:               "os",
+		},
+		SplitByArch: true,
+	}
+
+	files, err := BuildSecurityDatabaseFiles(context.Background(), opts)
+	require.NoError(t, err)
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"x86_64/os.json", "aarch64/os.json"}, paths)
+
+	var db secdb.Database
+	require.NoError(t, json.Unmarshal(files[1].Data, &db))
+	assert.Equal(t, []string{"aarch64"}, db.Archs)
+	assert.Empty(t, db.DistroVersion)
+	assert.Equal(t, apkURL, db.APKURL)
+
+	// Each branch gets its own directory, and records the branch as the
+	// distroversion.
+	opts.Branches = []string{"v1", "v2"}
+	files, err = BuildSecurityDatabaseFiles(context.Background(), opts)
+	require.NoError(t, err)
+
+	paths = paths[:0]
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"v1/x86_64/os.json", "v1/aarch64/os.json", "v2/x86_64/os.json", "v2/aarch64/os.json"}, paths)
+
+	db = secdb.Database{}
+	require.NoError(t, json.Unmarshal(files[3].Data, &db))
+	assert.Equal(t, []string{"aarch64"}, db.Archs)
+	assert.Equal(t, "v2", db.DistroVersion)
+	assert.Equal(t, apkURLWithDistroVersion, db.APKURL)
+
+	// Branches don't require splitting by architecture.
+	opts.SplitByArch = false
+	files, err = BuildSecurityDatabaseFiles(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "v1/os.json", files[0].Path)
+	assert.Equal(t, "v2/os.json", files[1].Path)
+	assert.Contains(t, string(files[1].Data), `"distroversion": "v2"`)
+
+	// Without splitting, the single file matches what BuildSecurityDatabase
+	// produces.
+	opts.Branches = nil
+	opts.Archs = []string{"x86_64"}
+	files, err = BuildSecurityDatabaseFiles(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "os.json", files[0].Path)
+
+	expected, err := os.ReadFile("./testdata/secdb/security.json")
+	require.NoError(t, err)
+	if diff := cmp.Diff(expected, files[0].Data); diff != "" {
+		t.Errorf("BuildSecurityDatabaseFiles() produced an unexpected database (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckSecurityDatabaseFiles(t *testing.T) {
+	published := fstest.MapFS{
+		"x86_64/os.json":    {Data: []byte(`{"same": true}`)},
+		"aarch64/os.json":   {Data: []byte(`{"changed": false}`)},
+		"armv7/os.json":     {Data: []byte(`{"stale": true}`)},
+		"x86_64/other.json": {Data: []byte(`{}`)},
+	}
+
+	err := CheckSecurityDatabaseFiles([]SecurityDatabaseFile{
+		{Path: "x86_64/os.json", Data: []byte(`{"same": true}`)},
+		{Path: "aarch64/os.json", Data: []byte(`{"changed": false}`)},
+		{Path: "armv7/os.json", Data: []byte(`{"stale": true}`)},
+	}, published)
+	assert.NoError(t, err)
+
+	err = CheckSecurityDatabaseFiles([]SecurityDatabaseFile{
+		{Path: "x86_64/os.json", Data: []byte(`{"same": true}`)},
+		{Path: "aarch64/os.json", Data: []byte(`{"changed": true}`)},
+		{Path: "riscv64/os.json", Data: []byte(`{}`)},
+	}, published)
+	assert.ErrorIs(t, err, ErrSecurityDatabaseMismatch)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorContains(t, err, "aarch64/os.json")
+	assert.ErrorContains(t, err, "riscv64/os.json")
+
+	// Published files that are no longer produced are stale, but unrelated files
+	// are left alone.
+	assert.ErrorContains(t, err, "armv7/os.json: "+ErrSecurityDatabaseMismatch.Error())
+	assert.NotContains(t, err.Error(), "x86_64/os.json")
+	assert.NotContains(t, err.Error(), "other.json")
+}
@@ -0,0 +1,433 @@
+package advisory
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/vuln"
+)
+
+//go:embed site/*.tmpl site/style.css
+var siteFS embed.FS
+
+var siteTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"packagePage":       sitePackagePagePath,
+	"vulnerabilityPage": siteVulnerabilityPagePath,
+	"advisoryView": func(root string, adv siteAdvisory) siteAdvisoryView {
+		return siteAdvisoryView{Root: root, Advisory: adv}
+	},
+}).ParseFS(siteFS, "site/*.tmpl"))
+
+// SiteOptions contains the options for building a static website from advisory
+// data.
+type SiteOptions struct {
+	// AdvisoryDocs is the index of advisory documents to render.
+	AdvisoryDocs *configs.Index[v2.Document]
+
+	// OutputDirectory is the path to a local directory in which the website will
+	// be written. It's created if it doesn't exist.
+	OutputDirectory string
+
+	// Title is shown at the top of every page. If empty, a generic title is used.
+	Title string
+}
+
+// SiteSearchEntry describes a single advisory in the website's search index
+// (search.json), which lets clients search advisories without parsing the
+// HTML pages.
+type SiteSearchEntry struct {
+	Package       string   `json:"package"`
+	ID            string   `json:"id"`
+	Aliases       []string `json:"aliases,omitempty"`
+	Status        string   `json:"status"`
+	FixedVersions []string `json:"fixed_versions,omitempty"`
+	Updated       string   `json:"updated"`
+	URL           string   `json:"url"`
+}
+
+const (
+	sitePackagesDir        = "packages"
+	siteVulnerabilitiesDir = "vulnerabilities"
+	siteSearchIndexFile    = "search.json"
+	siteStylesheetFile     = "style.css"
+	defaultSiteTitle       = "Security Advisories"
+)
+
+// BuildSite renders the advisory data as a static website, with an index page,
+// one page per package, and one page per vulnerability ID (including aliases).
+// Each page shows the event timeline, aliases, and fixed versions of the
+// relevant advisories, with links to external vulnerability databases where
+// available.
+func BuildSite(ctx context.Context, opts SiteOptions) error {
+	logger := clog.FromContext(ctx)
+
+	title := opts.Title
+	if title == "" {
+		title = defaultSiteTitle
+	}
+
+	documents := opts.AdvisoryDocs.Select().Configurations()
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].Name() < documents[j].Name()
+	})
+
+	logger.Info("building advisory site", "documents", len(documents), "outputDirectory", opts.OutputDirectory)
+
+	for _, dir := range []string{sitePackagesDir, siteVulnerabilitiesDir} {
+		if err := os.MkdirAll(filepath.Join(opts.OutputDirectory, dir), 0o755); err != nil {
+			return fmt.Errorf("creating site directory: %w", err)
+		}
+	}
+
+	var (
+		packages        []sitePackageSummary
+		searchEntries   []SiteSearchEntry
+		byVulnerability = make(map[string][]siteAdvisory)
+	)
+
+	for _, doc := range documents {
+		advs := make([]siteAdvisory, 0, len(doc.Advisories))
+		open := 0
+		for _, adv := range doc.Advisories {
+			sa := newSiteAdvisory(doc.Name(), adv)
+			advs = append(advs, sa)
+
+			if !sa.Resolved {
+				open++
+			}
+
+			for _, id := range adv.VulnerabilityIDs() {
+				byVulnerability[id] = append(byVulnerability[id], sa)
+			}
+		}
+
+		sort.Slice(advs, func(i, j int) bool { return advs[i].ID < advs[j].ID })
+
+		for _, sa := range advs {
+			searchEntries = append(searchEntries, SiteSearchEntry{
+				Package:       sa.Package,
+				ID:            sa.ID,
+				Aliases:       sa.aliasIDs(),
+				Status:        sa.Status,
+				FixedVersions: sa.FixedVersions,
+				Updated:       sa.Updated,
+				URL:           sitePackagePagePath(sa.Package) + "#" + sa.ID,
+			})
+		}
+
+		page := sitePackagePage{
+			sitePage:   sitePage{SiteTitle: title, Title: doc.Name(), Root: "../"},
+			Package:    doc.Name(),
+			Advisories: advs,
+		}
+		if err := renderSitePage(opts.OutputDirectory, sitePackagePagePath(doc.Name()), "package.html.tmpl", page); err != nil {
+			return err
+		}
+
+		packages = append(packages, sitePackageSummary{
+			Name:       doc.Name(),
+			Advisories: len(advs),
+			Open:       open,
+		})
+	}
+
+	vulnIDs := make([]string, 0, len(byVulnerability))
+	for id := range byVulnerability {
+		vulnIDs = append(vulnIDs, id)
+	}
+	sort.Strings(vulnIDs)
+
+	vulnerabilities := make([]siteVulnerabilitySummary, 0, len(vulnIDs))
+	for _, id := range vulnIDs {
+		advs := byVulnerability[id]
+		sort.Slice(advs, func(i, j int) bool {
+			if advs[i].Package != advs[j].Package {
+				return advs[i].Package < advs[j].Package
+			}
+			return advs[i].ID < advs[j].ID
+		})
+
+		var aliases []string
+		for _, sa := range advs {
+			for _, other := range append([]string{sa.ID}, sa.aliasIDs()...) {
+				if other != id && !slices.Contains(aliases, other) {
+					aliases = append(aliases, other)
+				}
+			}
+		}
+		sort.Strings(aliases)
+
+		page := siteVulnerabilityPage{
+			sitePage:   sitePage{SiteTitle: title, Title: id, Root: "../"},
+			ID:         id,
+			URL:        vuln.URL(id),
+			Aliases:    newSiteLinks(aliases),
+			Advisories: advs,
+		}
+		if err := renderSitePage(opts.OutputDirectory, siteVulnerabilityPagePath(id), "vulnerability.html.tmpl", page); err != nil {
+			return err
+		}
+
+		packageNames := make([]string, 0, len(advs))
+		for _, sa := range advs {
+			if !slices.Contains(packageNames, sa.Package) {
+				packageNames = append(packageNames, sa.Package)
+			}
+		}
+
+		vulnerabilities = append(vulnerabilities, siteVulnerabilitySummary{
+			ID:       id,
+			Packages: packageNames,
+		})
+	}
+
+	index := siteIndexPage{
+		sitePage:        sitePage{SiteTitle: title, Title: title},
+		Packages:        packages,
+		Vulnerabilities: vulnerabilities,
+	}
+	if err := renderSitePage(opts.OutputDirectory, "index.html", "index.html.tmpl", index); err != nil {
+		return err
+	}
+
+	if err := writeSiteSearchIndex(opts.OutputDirectory, searchEntries); err != nil {
+		return err
+	}
+
+	css, err := siteFS.ReadFile("site/" + siteStylesheetFile)
+	if err != nil {
+		return fmt.Errorf("reading site stylesheet: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(opts.OutputDirectory, siteStylesheetFile), css, 0o644); err != nil { //nolint:gosec // The site is meant to be readable by all.
+		return fmt.Errorf("writing site stylesheet: %w", err)
+	}
+
+	return nil
+}
+
+type sitePage struct {
+	// SiteTitle is the title of the whole site.
+	SiteTitle string
+
+	// Title is the title of the page.
+	Title string
+
+	// Root is the relative path from the page to the root of the site.
+	Root string
+}
+
+type siteIndexPage struct {
+	sitePage
+	Packages        []sitePackageSummary
+	Vulnerabilities []siteVulnerabilitySummary
+}
+
+type sitePackageSummary struct {
+	Name       string
+	Advisories int
+	Open       int
+}
+
+type siteVulnerabilitySummary struct {
+	ID       string
+	Packages []string
+}
+
+type sitePackagePage struct {
+	sitePage
+	Package    string
+	Advisories []siteAdvisory
+}
+
+type siteVulnerabilityPage struct {
+	sitePage
+	ID         string
+	URL        string
+	Aliases    []siteLink
+	Advisories []siteAdvisory
+}
+
+type siteAdvisory struct {
+	Package       string
+	ID            string
+	Aliases       []siteLink
+	Status        string
+	Resolved      bool
+	FixedVersions []string
+	Updated       string
+	Events        []siteEvent
+}
+
+// siteAdvisoryView is the data for rendering an advisory within a page.
+type siteAdvisoryView struct {
+	Root     string
+	Advisory siteAdvisory
+}
+
+func (sa siteAdvisory) aliasIDs() []string {
+	ids := make([]string, 0, len(sa.Aliases))
+	for _, l := range sa.Aliases {
+		ids = append(ids, l.ID)
+	}
+	return ids
+}
+
+// siteLink is a vulnerability ID, along with the URL of the vulnerability in an
+// external database, if known.
+type siteLink struct {
+	ID  string
+	URL string
+}
+
+type siteEvent struct {
+	Timestamp string
+	Type      string
+	Label     string
+	Detail    string
+	Note      string
+}
+
+func newSiteLinks(ids []string) []siteLink {
+	links := make([]siteLink, 0, len(ids))
+	for _, id := range ids {
+		links = append(links, siteLink{ID: id, URL: vuln.URL(id)})
+	}
+	return links
+}
+
+func newSiteAdvisory(packageName string, adv v2.Advisory) siteAdvisory {
+	sa := siteAdvisory{
+		Package:  packageName,
+		ID:       adv.ID,
+		Aliases:  newSiteLinks(adv.Aliases),
+		Resolved: adv.Resolved(),
+	}
+
+	sortedEvents := adv.SortedEvents()
+	for _, e := range sortedEvents {
+		se := siteEvent{
+			Timestamp: time.Time(e.Timestamp).UTC().Format(time.RFC3339),
+			Type:      e.Type,
+			Label:     siteEventLabel(e.Type),
+		}
+
+		switch data := e.Data.(type) {
+		case v2.Detection:
+			se.Detail = siteDetectionDetail(data)
+		case v2.Fixed:
+			se.Detail = "Fixed in " + data.FixedVersion
+			if !slices.Contains(sa.FixedVersions, data.FixedVersion) {
+				sa.FixedVersions = append(sa.FixedVersions, data.FixedVersion)
+			}
+		case v2.FalsePositiveDetermination:
+			se.Detail = data.Type
+			se.Note = data.Note
+		case v2.TruePositiveDetermination:
+			se.Note = data.Note
+		case v2.AnalysisNotPlanned:
+			se.Note = data.Note
+		case v2.FixNotPlanned:
+			se.Note = data.Note
+		case v2.PendingUpstreamFix:
+			se.Note = data.Note
+		}
+
+		sa.Events = append(sa.Events, se)
+	}
+
+	if len(sortedEvents) > 0 {
+		latest := sortedEvents[len(sortedEvents)-1]
+		sa.Status = siteEventLabel(latest.Type)
+		sa.Updated = time.Time(latest.Timestamp).UTC().Format(time.RFC3339)
+	}
+
+	return sa
+}
+
+func siteDetectionDetail(d v2.Detection) string {
+	switch data := d.Data.(type) {
+	case v2.DetectionScanV1:
+		return fmt.Sprintf("%s found %s %s", data.Scanner, data.ComponentName, data.ComponentVersion)
+	case v2.DetectionNVDAPI:
+		return fmt.Sprintf("NVD API match for %s", data.CPEFound)
+	}
+
+	return d.Type
+}
+
+func siteEventLabel(eventType string) string {
+	switch eventType {
+	case v2.EventTypeDetection:
+		return "Detected"
+	case v2.EventTypeTruePositiveDetermination:
+		return "Affected"
+	case v2.EventTypeFixed:
+		return "Fixed"
+	case v2.EventTypeFalsePositiveDetermination:
+		return "Not affected"
+	case v2.EventTypeAnalysisNotPlanned:
+		return "Analysis not planned"
+	case v2.EventTypeFixNotPlanned:
+		return "Fix not planned"
+	case v2.EventTypePendingUpstreamFix:
+		return "Pending upstream fix"
+	}
+
+	return eventType
+}
+
+var siteUnsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+
+func siteFileName(name string) string {
+	return siteUnsafeFileNameChars.ReplaceAllString(name, "_") + ".html"
+}
+
+func sitePackagePagePath(packageName string) string {
+	return sitePackagesDir + "/" + siteFileName(packageName)
+}
+
+func siteVulnerabilityPagePath(id string) string {
+	return siteVulnerabilitiesDir + "/" + siteFileName(id)
+}
+
+func renderSitePage(outputDir, pagePath, templateName string, data any) error {
+	f, err := os.Create(filepath.Join(outputDir, filepath.FromSlash(pagePath)))
+	if err != nil {
+		return fmt.Errorf("creating site page %q: %w", pagePath, err)
+	}
+	defer f.Close()
+
+	if err := siteTemplates.ExecuteTemplate(f, templateName, data); err != nil {
+		return fmt.Errorf("rendering site page %q: %w", pagePath, err)
+	}
+
+	return nil
+}
+
+func writeSiteSearchIndex(outputDir string, entries []SiteSearchEntry) error {
+	f, err := os.Create(filepath.Join(outputDir, siteSearchIndexFile))
+	if err != nil {
+		return fmt.Errorf("creating site search index: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		return fmt.Errorf("encoding site search index to JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package advisory
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+)
+
+func TestBuildSite(t *testing.T) {
+	const expectedDir = "testdata/site/expected"
+
+	advisoryDocs, err := adv2.NewIndex(context.Background(), rwos.DirFS("testdata/site/advisories"))
+	if err != nil {
+		t.Fatalf("unable to create advisory docs index: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if *update {
+		outputDir = expectedDir
+	}
+
+	err = BuildSite(context.Background(), SiteOptions{
+		AdvisoryDocs:    advisoryDocs,
+		OutputDirectory: outputDir,
+		Title:           "Test Advisories",
+	})
+	if err != nil {
+		t.Fatalf("BuildSite() error = %v", err)
+	}
+
+	expectedFiles := 0
+	err = filepath.WalkDir(expectedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		expectedFiles++
+
+		rel, err := filepath.Rel(expectedDir, path)
+		if err != nil {
+			return err
+		}
+
+		expected, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		actual, err := os.ReadFile(filepath.Join(outputDir, rel))
+		if err != nil {
+			t.Errorf("expected file %s was not written: %v", rel, err)
+			return nil
+		}
+
+		if diff := cmp.Diff(string(expected), string(actual)); diff != "" {
+			t.Errorf("unexpected content for %s (-want +got):\n%s", rel, diff)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking expected site: %v", err)
+	}
+
+	actualFiles := 0
+	err = filepath.WalkDir(outputDir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			actualFiles++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walking generated site: %v", err)
+	}
+
+	if actualFiles != expectedFiles {
+		t.Errorf("generated %d files, want %d", actualFiles, expectedFiles)
+	}
+}
@@ -77,5 +77,5 @@ func Update(ctx context.Context, req Request, opts UpdateOptions) error {
 		return fmt.Errorf("unable to update schema version for %q: %w", req.Package, err)
 	}
 
-	return nil
+	return recordRequestProvenance(opts.AdvisoryDocs, req)
 }
@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/go-git/go-git/v5"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	wgit "github.com/wolfi-dev/wolfictl/pkg/git"
+)
+
+// Sink is where the watcher reads existing advisory data from, and where it
+// writes the advisory requests produced by each cycle.
+type Sink interface {
+	// AdvisoryDocs returns the current advisory documents.
+	AdvisoryDocs(ctx context.Context) (*configs.Index[v2.Document], error)
+
+	// Apply applies all of the given requests, recording them as a single change
+	// with the given message.
+	Apply(ctx context.Context, reqs []advisory.Request, message string) error
+}
+
+// FSSink is a Sink for an advisories repository in a local directory. Requests
+// are written using an advisory.FSPutter. If the directory is a git
+// repository, each cycle's changes are committed.
+type FSSink struct {
+	dir    string
+	putter *advisory.FSPutter
+}
+
+// NewFSSink returns a new FSSink for the advisories repository in the given
+// directory.
+func NewFSSink(dir string) *FSSink {
+	return &FSSink{
+		dir:    dir,
+		putter: advisory.NewFSPutterWithAutomaticEncoder(rwos.DirFS(dir)),
+	}
+}
+
+func (s *FSSink) AdvisoryDocs(ctx context.Context) (*configs.Index[v2.Document], error) {
+	return adv2.NewIndex(ctx, rwos.DirFS(s.dir))
+}
+
+func (s *FSSink) Apply(ctx context.Context, reqs []advisory.Request, message string) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	for _, req := range reqs {
+		if _, err := s.putter.Upsert(ctx, req); err != nil {
+			return fmt.Errorf("writing advisory data for %q: %w", req.Package, err)
+		}
+	}
+
+	repo, err := git.PlainOpen(s.dir)
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return nil
+		}
+		return fmt.Errorf("opening advisories repository: %w", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("getting worktree: %w", err)
+	}
+
+	for _, req := range reqs {
+		paths := []string{fmt.Sprintf("%s.advisories.yaml", req.Package)}
+		if req.Provenance != nil {
+			paths = append(paths, advisory.ProvenancePath(req.Package))
+		}
+
+		for _, p := range paths {
+			if _, err := os.Stat(filepath.Join(s.dir, p)); err != nil {
+				continue
+			}
+			if _, err := wt.Add(p); err != nil {
+				return fmt.Errorf("staging %q: %w", p, err)
+			}
+		}
+	}
+
+	_, err = wt.Commit(message, &git.CommitOptions{
+		Author: wgit.GetGitAuthorSignature(),
+	})
+	if err != nil {
+		return fmt.Errorf("creating commit: %w", err)
+	}
+
+	return nil
+}
+
+// SessionSink is a Sink for an advisory.DataSession. Each cycle's changes are
+// committed to the session's working branch and pushed to the session's
+// remote.
+type SessionSink struct {
+	Session *advisory.DataSession
+}
+
+func (s SessionSink) AdvisoryDocs(_ context.Context) (*configs.Index[v2.Document], error) {
+	return s.Session.Index(), nil
+}
+
+func (s SessionSink) Apply(ctx context.Context, reqs []advisory.Request, message string) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	if err := s.Session.AppendAll(ctx, reqs, message); err != nil {
+		return err
+	}
+
+	if err := s.Session.Push(ctx); err != nil {
+		return fmt.Errorf("pushing advisory data session: %w", err)
+	}
+
+	return nil
+}
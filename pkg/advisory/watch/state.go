@@ -0,0 +1,79 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// State is what the watcher remembers between cycles (and between runs): the
+// version of each package that was last scanned, and what the scan found.
+type State struct {
+	// Packages maps each origin package name to its last scan.
+	Packages map[string]PackageState `json:"packages"`
+}
+
+// PackageState describes the last scan of a package.
+type PackageState struct {
+	// Version is the full version (including epoch) of the package that was
+	// scanned.
+	Version string `json:"version"`
+
+	// Findings are the IDs of the vulnerabilities found by the scan, sorted.
+	Findings []string `json:"findings,omitempty"`
+}
+
+// ReadState reads the watcher state from the file at the given path. If the
+// file doesn't exist yet, an empty state is returned.
+func ReadState(path string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &State{Packages: make(map[string]PackageState)}, nil
+		}
+		return nil, fmt.Errorf("reading watch state: %w", err)
+	}
+
+	s := new(State)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("decoding watch state from %q: %w", path, err)
+	}
+	if s.Packages == nil {
+		s.Packages = make(map[string]PackageState)
+	}
+
+	return s, nil
+}
+
+// WriteState writes the watcher state to the file at the given path. The file
+// is replaced atomically, so that an interrupted write never leaves a partial
+// state behind.
+func WriteState(path string, s *State) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding watch state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".watch-state-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary watch state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing watch state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing watch state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing watch state: %w", err)
+	}
+
+	return nil
+}
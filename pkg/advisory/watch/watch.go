@@ -0,0 +1,436 @@
+// Package watch continuously reconciles vulnerability scans of a distro's
+// published packages with the distro's advisory data.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/chainguard-dev/clog"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/scan"
+	"github.com/wolfi-dev/wolfictl/pkg/versions"
+)
+
+// Scanner scans an APK file for vulnerabilities. It's implemented by
+// *scan.Scanner.
+type Scanner interface {
+	ScanAPK(ctx context.Context, apk fs.File, distroID string) (*scan.Result, error)
+}
+
+// Options configure a Watcher.
+type Options struct {
+	// MirrorDir is the root of a local mirror of the distro's package
+	// repository, laid out as "<arch>/APKINDEX.tar.gz" and "<arch>/<name>-<version>.apk".
+	MirrorDir string
+
+	// Arches are the architectures in the mirror to watch.
+	Arches []string
+
+	// DistroID is the distro ID passed to the Scanner (e.g. "wolfi").
+	DistroID string
+
+	// Scanner scans the APKs of new package versions.
+	Scanner Scanner
+
+	// Sink is where advisory data is read from and written to.
+	Sink Sink
+
+	// StatePath is the path of the file where the watcher keeps track of what it
+	// has already scanned.
+	StatePath string
+
+	// Interval is how long the watcher waits between cycles.
+	Interval time.Duration
+
+	// Provenance, if set, is recorded for each advisory event the watcher
+	// produces, with the scanner's data source added as evidence.
+	Provenance *advisory.Provenance
+
+	// Now returns the current time, used to timestamp advisory events. If nil,
+	// v2.Now is used.
+	Now func() v2.Timestamp
+}
+
+// Watcher periodically scans new package versions found in a local APKINDEX
+// mirror. It creates "detection" events for new findings and proposes "fixed"
+// events for findings that disappear in a newer version.
+type Watcher struct {
+	opts Options
+}
+
+// New returns a new Watcher.
+func New(opts Options) (*Watcher, error) {
+	if opts.MirrorDir == "" {
+		return nil, fmt.Errorf("mirror directory must be specified")
+	}
+	if len(opts.Arches) == 0 {
+		return nil, fmt.Errorf("at least one architecture must be specified")
+	}
+	if opts.Scanner == nil {
+		return nil, fmt.Errorf("scanner must be specified")
+	}
+	if opts.Sink == nil {
+		return nil, fmt.Errorf("sink must be specified")
+	}
+	if opts.StatePath == "" {
+		return nil, fmt.Errorf("state path must be specified")
+	}
+	if opts.Now == nil {
+		opts.Now = v2.Now
+	}
+
+	return &Watcher{opts: opts}, nil
+}
+
+// Run runs cycles until the context is canceled, waiting for the configured
+// interval between cycles. An error in a single cycle is logged and doesn't
+// stop the watcher.
+func (w *Watcher) Run(ctx context.Context) error {
+	logger := clog.FromContext(ctx)
+
+	for {
+		result, err := w.RunCycle(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error("watch cycle failed", "error", err)
+		} else {
+			logger.Info("watch cycle complete", "scanned", len(result.Scanned), "requests", len(result.Requests))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.opts.Interval):
+		}
+	}
+}
+
+// CycleResult describes what happened during a single cycle.
+type CycleResult struct {
+	// Scanned are the packages scanned during the cycle, as "<name>-<version>".
+	Scanned []string
+
+	// Requests are the advisory requests applied during the cycle.
+	Requests []advisory.Request
+}
+
+// RunCycle runs a single cycle: it reads the mirror's APKINDEXes, scans each
+// package whose latest version hasn't been scanned yet, applies the resulting
+// advisory requests to the sink as a single change, and then records the new
+// state.
+func (w *Watcher) RunCycle(ctx context.Context) (*CycleResult, error) {
+	logger := clog.FromContext(ctx)
+
+	state, err := ReadState(w.opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := w.latestPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := w.opts.Sink.AdvisoryDocs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading advisory data: %w", err)
+	}
+
+	result := &CycleResult{}
+	newState := make(map[string]PackageState)
+
+	for _, origin := range sortedKeys(latest) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pkg := latest[origin]
+		previous, seen := state.Packages[origin]
+		if seen && previous.Version == pkg.version {
+			continue
+		}
+
+		findings, dataSource, err := w.scanPackage(ctx, pkg)
+		if err != nil {
+			// Leave the package's state alone, so that it's retried next cycle.
+			logger.Warn("unable to scan package, skipping", "package", origin, "version", pkg.version, "error", err)
+			continue
+		}
+		result.Scanned = append(result.Scanned, fmt.Sprintf("%s-%s", origin, pkg.version))
+
+		reqs := w.requestsForPackage(docs, origin, pkg.version, findings, previous, seen)
+		for i := range reqs {
+			reqs[i].Provenance = w.provenance(dataSource)
+		}
+		result.Requests = append(result.Requests, reqs...)
+
+		newState[origin] = PackageState{
+			Version:  pkg.version,
+			Findings: sortedKeys(findings),
+		}
+	}
+
+	if len(result.Requests) > 0 {
+		err := w.opts.Sink.Apply(ctx, result.Requests, cycleCommitMessage(result.Requests))
+		if err != nil {
+			return nil, fmt.Errorf("applying advisory requests: %w", err)
+		}
+	}
+
+	for origin, s := range newState {
+		state.Packages[origin] = s
+	}
+	if err := WriteState(w.opts.StatePath, state); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mirrorPackage is the latest version of an origin package in the mirror, and
+// the APKs built for that version.
+type mirrorPackage struct {
+	version string
+	apks    []mirrorAPK
+}
+
+// mirrorAPK is a single APK in the mirror.
+type mirrorAPK struct {
+	// path is the APK's location on disk.
+	path string
+
+	// name is the name of the (sub)package the APK provides.
+	name string
+}
+
+// apkFinding is a scan finding along with the name of the APK it was found in.
+type apkFinding struct {
+	scan.Finding
+	subpackage string
+}
+
+// latestPackages returns the latest version of each origin package found in
+// the mirror's APKINDEXes.
+func (w *Watcher) latestPackages() (map[string]*mirrorPackage, error) {
+	latest := make(map[string]*mirrorPackage)
+
+	for _, arch := range w.opts.Arches {
+		f, err := os.Open(filepath.Join(w.opts.MirrorDir, arch, "APKINDEX.tar.gz"))
+		if err != nil {
+			return nil, fmt.Errorf("opening APKINDEX for %s: %w", arch, err)
+		}
+
+		index, err := apk.IndexFromArchive(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing APKINDEX for %s: %w", arch, err)
+		}
+
+		for _, p := range index.Packages {
+			if p.Origin == "" {
+				continue
+			}
+
+			a := mirrorAPK{path: filepath.Join(w.opts.MirrorDir, arch, p.Filename()), name: p.Name}
+
+			mp, ok := latest[p.Origin]
+			switch {
+			case !ok:
+				latest[p.Origin] = &mirrorPackage{version: p.Version, apks: []mirrorAPK{a}}
+
+			case mp.version == p.Version:
+				mp.apks = append(mp.apks, a)
+
+			default:
+				vs := []string{mp.version, p.Version}
+				sort.Sort(versions.ByLatestStrings(vs))
+				if vs[0] == p.Version {
+					latest[p.Origin] = &mirrorPackage{version: p.Version, apks: []mirrorAPK{a}}
+				}
+			}
+		}
+	}
+
+	for _, mp := range latest {
+		sort.Slice(mp.apks, func(i, j int) bool { return mp.apks[i].path < mp.apks[j].path })
+	}
+
+	return latest, nil
+}
+
+// scanPackage scans every APK of the package and returns the findings, keyed
+// by vulnerability ID, along with the scanner's data source.
+func (w *Watcher) scanPackage(ctx context.Context, pkg *mirrorPackage) (map[string]apkFinding, scan.DataSource, error) {
+	findings := make(map[string]apkFinding)
+	var dataSource scan.DataSource
+
+	for _, a := range pkg.apks {
+		f, err := os.Open(a.path)
+		if err != nil {
+			return nil, scan.DataSource{}, fmt.Errorf("opening APK: %w", err)
+		}
+
+		result, err := w.opts.Scanner.ScanAPK(ctx, f, w.opts.DistroID)
+		f.Close()
+		if err != nil {
+			return nil, scan.DataSource{}, fmt.Errorf("scanning %q: %w", filepath.Base(a.path), err)
+		}
+		dataSource = result.DataSource
+
+		for _, finding := range result.Findings {
+			if _, ok := findings[finding.Vulnerability.ID]; !ok {
+				findings[finding.Vulnerability.ID] = apkFinding{Finding: finding, subpackage: a.name}
+			}
+		}
+	}
+
+	return findings, dataSource, nil
+}
+
+// provenance returns the provenance for requests based on a scan that used the
+// given data source.
+func (w *Watcher) provenance(dataSource scan.DataSource) *advisory.Provenance {
+	if w.opts.Provenance == nil {
+		return nil
+	}
+
+	p := *w.opts.Provenance
+	if dataSource.Integrity != "" {
+		p.Evidence = append(slices.Clone(p.Evidence), advisory.Evidence{
+			Type:  fmt.Sprintf("%s-checksum", dataSource.Kind),
+			Value: dataSource.Integrity,
+		})
+	}
+
+	return &p
+}
+
+// requestsForPackage returns the advisory requests for a newly scanned package
+// version: a "detection" event for each finding that has no advisory yet, and
+// a "fixed" event for each finding from the previous scan that's gone now and
+// whose advisory is still open.
+func (w *Watcher) requestsForPackage(
+	docs *configs.Index[v2.Document],
+	origin, version string,
+	findings map[string]apkFinding,
+	previous PackageState,
+	seen bool,
+) []advisory.Request {
+	var advs v2.Advisories
+	if doc, err := docs.Select().WhereName(origin).First(); err == nil {
+		advs = doc.Configuration().Advisories
+	}
+
+	var reqs []advisory.Request
+
+	for _, id := range sortedKeys(findings) {
+		finding := findings[id]
+		vulnIDs := append([]string{id}, finding.Vulnerability.Aliases...)
+		if _, ok := advs.GetByAnyVulnerability(vulnIDs...); ok {
+			continue
+		}
+
+		reqs = append(reqs, advisory.Request{
+			Package: origin,
+			Aliases: vulnIDs,
+			Event: v2.Event{
+				Timestamp: w.opts.Now(),
+				Type:      v2.EventTypeDetection,
+				Data: v2.Detection{
+					Type: v2.DetectionTypeScanV1,
+					Data: v2.DetectionScanV1{
+						SubpackageName:    finding.subpackage,
+						ComponentID:       finding.Package.ID,
+						ComponentName:     finding.Package.Name,
+						ComponentVersion:  finding.Package.Version,
+						ComponentType:     finding.Package.Type,
+						ComponentLocation: finding.Package.Location,
+						Scanner:           "grype",
+					},
+				},
+			},
+		})
+	}
+
+	if !seen {
+		return reqs
+	}
+
+	for _, id := range previous.Findings {
+		if _, ok := findings[id]; ok {
+			continue
+		}
+
+		adv, ok := advs.GetByAnyVulnerability(id)
+		if !ok || !slices.Contains(openEventTypes, adv.Latest().Type) {
+			continue
+		}
+
+		reqs = append(reqs, advisory.Request{
+			Package:    origin,
+			AdvisoryID: adv.ID,
+			Aliases:    adv.Aliases,
+			Event: v2.Event{
+				Timestamp: w.opts.Now(),
+				Type:      v2.EventTypeFixed,
+				Data: v2.Fixed{
+					FixedVersion: version,
+				},
+			},
+		})
+	}
+
+	return reqs
+}
+
+// openEventTypes are the latest event types of an advisory for which the
+// disappearance of the finding is taken to mean the vulnerability was fixed.
+var openEventTypes = []string{
+	v2.EventTypeDetection,
+	v2.EventTypeTruePositiveDetermination,
+	v2.EventTypePendingUpstreamFix,
+}
+
+// cycleCommitMessage describes all of a cycle's requests in a single commit
+// message.
+func cycleCommitMessage(reqs []advisory.Request) string {
+	var detections, fixes int
+	for _, req := range reqs {
+		switch req.Event.Type {
+		case v2.EventTypeDetection:
+			detections++
+		case v2.EventTypeFixed:
+			fixes++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "watch: %d detection(s), %d fix(es)\n\n", detections, fixes)
+	for _, req := range reqs {
+		fmt.Fprintf(&b, "- %s: %s %s\n", req.Package, req.Event.Type, strings.Join(req.VulnerabilityIDs(), ", "))
+	}
+
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,185 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/apk/apk"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/scan"
+)
+
+// fakeScanner returns canned findings for each APK, keyed by file name.
+type fakeScanner map[string][]scan.Finding
+
+func (s fakeScanner) ScanAPK(_ context.Context, f fs.File, _ string) (*scan.Result, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &scan.Result{Findings: s[stat.Name()]}, nil
+}
+
+func writeMirror(t *testing.T, dir string, packages ...*apk.Package) {
+	t.Helper()
+
+	archDir := filepath.Join(dir, "x86_64")
+	require.NoError(t, os.MkdirAll(archDir, 0o755))
+
+	archive, err := apk.ArchiveFromIndex(&apk.APKIndex{Packages: packages})
+	require.NoError(t, err)
+	b, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(archDir, "APKINDEX.tar.gz"), b, 0o644))
+
+	for _, p := range packages {
+		require.NoError(t, os.WriteFile(filepath.Join(archDir, p.Filename()), nil, 0o644))
+	}
+}
+
+func finding(vulnID string) scan.Finding {
+	return scan.Finding{
+		Package: scan.Package{
+			ID:       "abc123",
+			Name:     "stdlib",
+			Version:  "go1.22.0",
+			Type:     "go-module",
+			Location: "/usr/bin/foo",
+		},
+		Vulnerability: scan.Vulnerability{
+			ID: vulnID,
+		},
+	}
+}
+
+func TestWatcher_RunCycle(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("GIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jane@doe.org")
+
+	mirrorDir := t.TempDir()
+	advisoriesDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	repo, err := git.PlainInit(advisoriesDir, false)
+	require.NoError(t, err)
+
+	scanner := fakeScanner{
+		"foo-1.0.0-r0.apk": {finding("CVE-2024-0001"), finding("CVE-2024-0002")},
+		"foo-1.1.0-r0.apk": {finding("CVE-2024-0001")},
+	}
+
+	now := v2.Timestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	w, err := New(Options{
+		MirrorDir: mirrorDir,
+		Arches:    []string{"x86_64"},
+		DistroID:  "wolfi",
+		Scanner:   scanner,
+		Sink:      NewFSSink(advisoriesDir),
+		StatePath: statePath,
+		Now:       func() v2.Timestamp { return now },
+	})
+	require.NoError(t, err)
+
+	commitCount := func(t *testing.T) int {
+		t.Helper()
+
+		iter, err := repo.Log(&git.LogOptions{})
+		require.NoError(t, err)
+		n := 0
+		require.NoError(t, iter.ForEach(func(*object.Commit) error {
+			n++
+			return nil
+		}))
+		return n
+	}
+
+	// The first scan of a package creates detections for all of its findings, in
+	// a single commit.
+	writeMirror(t, mirrorDir, &apk.Package{Name: "foo", Origin: "foo", Version: "1.0.0-r0", Arch: "x86_64"})
+
+	result, err := w.RunCycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo-1.0.0-r0"}, result.Scanned)
+	require.Len(t, result.Requests, 2)
+	for _, req := range result.Requests {
+		assert.Equal(t, v2.EventTypeDetection, req.Event.Type)
+
+		detection, ok := req.Event.Data.(v2.Detection)
+		require.True(t, ok)
+		scanData, ok := detection.Data.(v2.DetectionScanV1)
+		require.True(t, ok)
+		assert.Equal(t, "foo", scanData.SubpackageName)
+		assert.Equal(t, "stdlib", scanData.ComponentName)
+	}
+	assert.Equal(t, 1, commitCount(t))
+
+	state, err := ReadState(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, PackageState{Version: "1.0.0-r0", Findings: []string{"CVE-2024-0001", "CVE-2024-0002"}}, state.Packages["foo"])
+
+	// Nothing new in the mirror means nothing to scan.
+	result, err = w.RunCycle(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Scanned)
+	assert.Empty(t, result.Requests)
+	assert.Equal(t, 1, commitCount(t))
+
+	// A finding that disappears in a new version gets a proposed fix.
+	writeMirror(t, mirrorDir, &apk.Package{Name: "foo", Origin: "foo", Version: "1.1.0-r0", Arch: "x86_64"})
+
+	result, err = w.RunCycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo-1.1.0-r0"}, result.Scanned)
+	require.Len(t, result.Requests, 1)
+	assert.Equal(t, v2.EventTypeFixed, result.Requests[0].Event.Type)
+	assert.Equal(t, v2.Fixed{FixedVersion: "1.1.0-r0"}, result.Requests[0].Event.Data)
+	assert.Contains(t, result.Requests[0].Aliases, "CVE-2024-0002")
+	assert.Equal(t, 2, commitCount(t))
+
+	docs, err := NewFSSink(advisoriesDir).AdvisoryDocs(ctx)
+	require.NoError(t, err)
+	entry, err := docs.Select().WhereName("foo").First()
+	require.NoError(t, err)
+	adv, ok := entry.Configuration().Advisories.GetByAnyVulnerability("CVE-2024-0002")
+	require.True(t, ok)
+	assert.Equal(t, v2.EventTypeFixed, adv.Latest().Type)
+}
+
+func TestWatcher_RunCycle_ScanFailureIsRetried(t *testing.T) {
+	ctx := context.Background()
+
+	mirrorDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	writeMirror(t, mirrorDir, &apk.Package{Name: "foo", Origin: "foo", Version: "1.0.0-r0", Arch: "x86_64"})
+	require.NoError(t, os.Remove(filepath.Join(mirrorDir, "x86_64", "foo-1.0.0-r0.apk")))
+
+	w, err := New(Options{
+		MirrorDir: mirrorDir,
+		Arches:    []string{"x86_64"},
+		Scanner:   fakeScanner{},
+		Sink:      NewFSSink(t.TempDir()),
+		StatePath: statePath,
+	})
+	require.NoError(t, err)
+
+	result, err := w.RunCycle(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Scanned)
+
+	state, err := ReadState(statePath)
+	require.NoError(t, err)
+	assert.NotContains(t, state.Packages, "foo")
+}
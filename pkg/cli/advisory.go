@@ -42,15 +42,19 @@ func cmdAdvisory() *cobra.Command {
 		cmdAdvisoryDiff(),
 		cmdAdvisoryDiscover(),
 		cmdAdvisoryExport(),
+		cmdAdvisoryGoReachability(),
 		cmdAdvisoryGuide(),
 		cmdAdvisoryID(),
 		cmdAdvisoryList(),
+		cmdAdvisoryMigrate(),
 		cmdAdvisoryMigrateIDs(),
 		cmdAdvisoryOSV(),
 		cmdAdvisoryRebase(),
 		cmdAdvisorySecDB(),
+		cmdAdvisorySite(),
 		cmdAdvisoryUpdate(),
 		cmdAdvisoryValidate(),
+		cmdAdvisoryWatch(),
 	)
 
 	return cmd
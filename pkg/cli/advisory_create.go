@@ -242,6 +242,7 @@ newly created advisory and any other advisories for the same package.`,
 					return fmt.Errorf("completing alias set for advisory request (package %q): %w", r.Package, err)
 				}
 				r.Aliases = aliases
+				r.Provenance = advisory.NewProvenance(cmd.CommandPath())
 
 				_, err = advPutter.Upsert(ctx, r)
 				if err != nil {
@@ -1,13 +1,19 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
+	"chainguard.dev/apko/pkg/apk/apk"
+	"chainguard.dev/apko/pkg/apk/client"
 	"chainguard.dev/melange/pkg/config"
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 	"github.com/wolfi-dev/wolfictl/pkg/advisory"
@@ -18,6 +24,7 @@ import (
 	rwfsOS "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
 	"github.com/wolfi-dev/wolfictl/pkg/distro"
 	"github.com/wolfi-dev/wolfictl/pkg/vuln/nvdapi"
+	"github.com/wolfi-dev/wolfictl/pkg/yam"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -27,9 +34,19 @@ const envVarNameForNVDAPIKey = "WOLFICTL_NVD_API_KEY"
 func cmdAdvisoryDiscover() *cobra.Command {
 	p := &discoverParams{}
 	cmd := &cobra.Command{
-		Use:           "discover",
-		Short:         "Automatically create advisories by matching distro packages to vulnerabilities in NVD",
-		Deprecated:    advisoryDeprecationMessage,
+		Use:        "discover",
+		Short:      "Automatically create advisories by matching distro packages to vulnerabilities in NVD",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Automatically create advisories by matching distro packages to vulnerabilities in NVD.
+
+When --fixes is specified, instead of searching for new vulnerabilities, this
+command looks at advisories whose latest event is "detection" or
+"pending-upstream-fix". For each of these, it compares the package's current
+version (from the distro's build configuration) and the package's published
+versions (from the APKINDEX) against the vulnerable version range reported by
+NVD. If the package is no longer vulnerable, a "fixed" event is added to the
+advisory, using the earliest published version that is no longer vulnerable.
+`,
 		SilenceErrors: true,
 		Args:          cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -69,9 +86,14 @@ func cmdAdvisoryDiscover() *cobra.Command {
 				return fmt.Errorf("unable to select packages: %w", err)
 			}
 
-			selectedPackages := getSelectedOrDistroPackages(p.packageName, buildCfgs)
 			apiKey := p.resolveNVDAPIKey()
 
+			if p.fixes {
+				return p.discoverFixes(cmd.Context(), cmd.OutOrStdout(), cmd.CommandPath(), advisoriesRepoDir, advisoryCfgs, buildCfgs, packageRepositoryURL, apiKey)
+			}
+
+			selectedPackages := getSelectedOrDistroPackages(p.packageName, buildCfgs)
+
 			ctx := cmd.Context()
 			g, ctx := errgroup.WithContext(ctx)
 			events := make(chan interface{})
@@ -102,6 +124,10 @@ func cmdAdvisoryDiscover() *cobra.Command {
 					Arches:                []string{"x86_64", "aarch64"},
 					VulnerabilityDetector: nvdapi.NewDetector(http.DefaultClient, nvdapi.DefaultHost, apiKey),
 					VulnEvents:            events,
+					Provenance: advisory.NewProvenance(
+						cmd.CommandPath(),
+						advisory.Evidence{Type: "nvd-api-host", Value: nvdapi.DefaultHost},
+					),
 				})
 				return err
 			})
@@ -134,6 +160,8 @@ type discoverParams struct {
 	packageRepositoryURL string
 
 	nvdAPIKey string
+
+	fixes bool
 }
 
 func (p *discoverParams) addFlagsTo(cmd *cobra.Command) {
@@ -146,6 +174,8 @@ func (p *discoverParams) addFlagsTo(cmd *cobra.Command) {
 
 	cmd.Flags().StringVarP(&p.packageRepositoryURL, "package-repo-url", "r", "", "URL of the APK package repository")
 
+	cmd.Flags().BoolVar(&p.fixes, "fixes", false, "add fixed events to open advisories whose vulnerabilities are fixed in the current package version")
+
 	cmd.Flags().StringVar(&p.nvdAPIKey, "nvd-api-key", "", fmt.Sprintf("NVD API key (Can also be set via the environment variable '%s'. Using an API key significantly increases the rate limit for API requests. If you need an NVD API key, go to https://nvd.nist.gov/developers/request-an-api-key.)", envVarNameForNVDAPIKey))
 }
 
@@ -166,6 +196,72 @@ func (p *discoverParams) resolveNVDAPIKey() string {
 	return ""
 }
 
+func (p *discoverParams) discoverFixes(
+	ctx context.Context,
+	w io.Writer,
+	tool string,
+	advisoriesRepoDir string,
+	advisoryCfgs *configs.Index[v2.Document],
+	buildCfgs *configs.Index[config.Configuration],
+	packageRepositoryURL, apiKey string,
+) error {
+	if packageRepositoryURL == "" {
+		return fmt.Errorf("package repository URL must be specified")
+	}
+
+	c := client.New(http.DefaultClient)
+	var apkindexes []*apk.APKIndex
+	for _, arch := range []string{"x86_64", "aarch64"} {
+		idx, err := c.GetRemoteIndex(ctx, packageRepositoryURL, arch)
+		if err != nil {
+			return fmt.Errorf("getting APKINDEX for %s: %w", arch, err)
+		}
+		apkindexes = append(apkindexes, idx)
+	}
+
+	var selectedPackages []string
+	if p.packageName != "" {
+		selectedPackages = []string{p.packageName}
+	}
+
+	reqs, err := advisory.DiscoverFixes(ctx, advisory.DiscoverFixesOptions{
+		SelectedPackages:      selectedPackages,
+		BuildCfgs:             buildCfgs,
+		AdvisoryDocs:          advisoryCfgs,
+		APKIndexes:            apkindexes,
+		VulnerabilityDetector: nvdapi.NewDetector(http.DefaultClient, nvdapi.DefaultHost, apiKey),
+		CurrentTime:           v2.Now(),
+		Provenance: advisory.NewProvenance(
+			tool,
+			advisory.Evidence{Type: "nvd-api-host", Value: nvdapi.DefaultHost},
+			advisory.Evidence{Type: "package-repository-url", Value: packageRepositoryURL},
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	encodeOpts, err := yam.TryReadingEncodeOptions(advisoriesRepoDir)
+	if err != nil {
+		return fmt.Errorf("getting yam encode options: %w", err)
+	}
+	putter := advisory.NewFSPutter(rwfsOS.DirFS(advisoriesRepoDir), advisory.NewYamDocumentEncoder(encodeOpts))
+
+	for _, req := range reqs {
+		if _, err := putter.Upsert(ctx, req); err != nil {
+			return fmt.Errorf("adding fixed event to advisory %q for %q: %w", req.AdvisoryID, req.Package, err)
+		}
+
+		fixed, ok := req.Event.Data.(v2.Fixed)
+		if !ok {
+			return fmt.Errorf("fixed event for advisory %q for %q has unexpected data of type %T", req.AdvisoryID, req.Package, req.Event.Data)
+		}
+		_, _ = fmt.Fprintf(w, "%s: %s (%s) fixed in %s\n", req.Package, req.AdvisoryID, strings.Join(req.Aliases, ", "), fixed.FixedVersion)
+	}
+
+	return nil
+}
+
 func getSelectedOrDistroPackages(packageName string, buildCfgs *configs.Index[config.Configuration]) []string {
 	if packageName != "" {
 		return []string{packageName}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"strings"
 
@@ -26,6 +27,7 @@ func cmdAdvisoryExport() *cobra.Command {
 		Args:          cobra.NoArgs,
 		Hidden:        true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			var detected *distro.Distro
 			if len(p.advisoriesRepoDirs) == 0 {
 				if p.doNotDetectDistro {
 					return fmt.Errorf("no advisories repo dir specified")
@@ -38,6 +40,7 @@ func cmdAdvisoryExport() *cobra.Command {
 
 				p.advisoriesRepoDirs = append(p.advisoriesRepoDirs, d.Local.AdvisoriesRepo.Dir)
 				_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+				detected = &d
 			}
 
 			indices := make([]*configs.Index[v2.Document], 0, len(p.advisoriesRepoDirs))
@@ -51,6 +54,10 @@ func cmdAdvisoryExport() *cobra.Command {
 				indices = append(indices, index)
 			}
 
+			if p.format == OutputCSAF {
+				return p.exportCSAF(cmd, indices, detected)
+			}
+
 			opts := advisory.ExportOptions{
 				AdvisoryDocIndices: indices,
 			}
@@ -63,7 +70,7 @@ func cmdAdvisoryExport() *cobra.Command {
 			case OutputCSV:
 				export, err = advisory.ExportCSV(opts)
 			default:
-				return fmt.Errorf("unrecognized format: %q. Valid formats are: [%s]", p.format, strings.Join([]string{OutputYAML, OutputCSV}, ", "))
+				return fmt.Errorf("unrecognized format: %q. Valid formats are: [%s]", p.format, strings.Join(exportFormats, ", "))
 			}
 			if err != nil {
 				return fmt.Errorf("unable to export advisory data: %w", err)
@@ -99,6 +106,10 @@ type exportParams struct {
 	outputLocation     string
 	// format controls how commands will produce their output.
 	format string
+
+	publisherName      string
+	publisherNamespace string
+	purlNamespace      string
 }
 
 const (
@@ -106,12 +117,60 @@ const (
 	OutputYAML = "yaml"
 	// OutputCSV CSV output.
 	OutputCSV = "csv"
+	// OutputCSAF CSAF VEX output, one document per vulnerability.
+	OutputCSAF = "csaf"
 )
 
+var exportFormats = []string{OutputYAML, OutputCSV, OutputCSAF}
+
 func (p *exportParams) addFlagsTo(cmd *cobra.Command) {
 	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
 
 	cmd.Flags().StringSliceVarP(&p.advisoriesRepoDirs, "advisories-repo-dir", "a", nil, "directory containing an advisories repository")
-	cmd.Flags().StringVarP(&p.outputLocation, "output", "o", "", "output location (default: stdout). In case using OSV format this will be the output directory.")
-	cmd.Flags().StringVarP(&p.format, "format", "f", OutputCSV, fmt.Sprintf("Output format. One of: [%s]", strings.Join([]string{OutputYAML, OutputCSV}, ", ")))
+	cmd.Flags().StringVarP(&p.outputLocation, "output", "o", "", "output location (default: stdout). In case using OSV or CSAF format this will be the output directory.")
+	cmd.Flags().StringVarP(&p.format, "format", "f", OutputCSV, fmt.Sprintf("Output format. One of: [%s]", strings.Join(exportFormats, ", ")))
+	cmd.Flags().StringVar(&p.publisherName, "csaf-publisher-name", "", "name of the publisher of CSAF documents (default: the detected distro's name)")
+	cmd.Flags().StringVar(&p.publisherNamespace, "csaf-publisher-namespace", "", "URL identifying the publisher of CSAF documents (default: derived from the detected distro's APK repository)")
+	cmd.Flags().StringVar(&p.purlNamespace, "csaf-purl-namespace", "", "namespace of the package URLs identifying products in CSAF documents (default: the lowercased publisher name)")
+}
+
+func (p *exportParams) exportCSAF(cmd *cobra.Command, indices []*configs.Index[v2.Document], detected *distro.Distro) error {
+	if p.outputLocation == "" {
+		return fmt.Errorf("an output directory must be specified with --output for the %s format", OutputCSAF)
+	}
+
+	opts := advisory.CSAFOptions{
+		AdvisoryDocIndices: indices,
+		PublisherName:      p.publisherName,
+		PublisherNamespace: p.publisherNamespace,
+		PURLNamespace:      p.purlNamespace,
+	}
+
+	if detected != nil {
+		if opts.PublisherName == "" {
+			opts.PublisherName = detected.Absolute.Name
+		}
+		if opts.PublisherNamespace == "" {
+			if u, err := url.Parse(detected.Absolute.APKRepositoryURL); err == nil {
+				opts.PublisherNamespace = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+			}
+		}
+	}
+	if opts.PublisherName == "" || opts.PublisherNamespace == "" {
+		return fmt.Errorf("the CSAF publisher's name and namespace must be specified when the distro isn't detected")
+	}
+	if opts.PURLNamespace == "" {
+		opts.PURLNamespace = strings.ToLower(strings.ReplaceAll(opts.PublisherName, " ", "-"))
+	}
+
+	docs, err := advisory.ExportCSAF(cmd.Context(), opts)
+	if err != nil {
+		return fmt.Errorf("unable to export advisory data: %w", err)
+	}
+
+	if err := advisory.WriteCSAFDocuments(p.outputLocation, docs); err != nil {
+		return fmt.Errorf("unable to export data to specified location: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+	"github.com/wolfi-dev/wolfictl/pkg/goreachability"
+	"github.com/wolfi-dev/wolfictl/pkg/yam"
+)
+
+func cmdAdvisoryGoReachability() *cobra.Command {
+	p := &goReachabilityParams{}
+	cmd := &cobra.Command{
+		Use:        "go-reachability <apk-file>...",
+		Short:      "Check whether code affected by a Go vulnerability is present in a package's binaries",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Check whether code affected by a Go vulnerability is present in a package's binaries.
+
+This command reads the Go binaries from the given APK files (typically the
+origin package and its subpackages), and looks up the vulnerability's affected
+packages and symbols in a local snapshot of the Go vulnerability database
+(https://vuln.go.dev). It then checks each binary's pclntab and symbol table for
+the vulnerable symbols, similar to govulncheck's binary mode.
+
+If none of the vulnerable symbols are present in any binary, the command
+proposes a false positive determination of type
+"vulnerable-code-not-included-in-package", with the analysis as evidence in the
+event's note. Use --apply to add the event to the package's advisory data.
+
+A vulndb snapshot can be obtained by downloading and unzipping
+https://vuln.go.dev/vulndb.zip.
+`,
+		Example: `
+wolfictl adv go-reachability ./ko-0.15.2-r0.apk --vulndb ./vulndb -p ko -V CVE-2023-45288
+
+wolfictl adv go-reachability ./ko-0.15.2-r0.apk --vulndb ./vulndb -p ko -V GO-2024-2687 --apply`,
+		SilenceErrors: true,
+		Args:          cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if p.vulnDBDir == "" {
+				return fmt.Errorf("need --vulndb")
+			}
+			if p.packageName == "" {
+				return fmt.Errorf("need --%s", flagNamePackage)
+			}
+			if p.vuln == "" {
+				return fmt.Errorf("need --%s", flagNameVuln)
+			}
+
+			snapshot, err := goreachability.NewSnapshot(os.DirFS(p.vulnDBDir))
+			if err != nil {
+				return fmt.Errorf("loading vulndb snapshot from %q: %w", p.vulnDBDir, err)
+			}
+
+			var binaries []*goreachability.Binary
+			for _, apkPath := range args {
+				f, err := os.Open(apkPath)
+				if err != nil {
+					return fmt.Errorf("opening APK: %w", err)
+				}
+
+				bs, err := goreachability.BinariesFromAPK(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("reading Go binaries from %q: %w", apkPath, err)
+				}
+
+				binaries = append(binaries, bs...)
+			}
+
+			req, result, err := advisory.TriageGoReachability(advisory.TriageGoReachabilityOptions{
+				Package:         p.packageName,
+				VulnerabilityID: p.vuln,
+				Binaries:        binaries,
+				VulnDB:          snapshot,
+				CurrentTime:     v2.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintln(w, result.Summary())
+
+			if req == nil {
+				fmt.Fprintln(w, "\nNo false positive determination proposed.")
+				return nil
+			}
+
+			fmt.Fprintf(w, "\nProposed: %s (%s) for %s\n", v2.EventTypeFalsePositiveDetermination, v2.FPTypeVulnerableCodeNotIncludedInPackage, p.vuln)
+
+			if !p.apply {
+				return nil
+			}
+
+			advisoriesRepoDir := resolveAdvisoriesDirInput(p.advisoriesRepoDir)
+			if advisoriesRepoDir == "" {
+				if p.doNotDetectDistro {
+					return fmt.Errorf("no advisories repo dir specified")
+				}
+
+				d, err := distro.Detect()
+				if err != nil {
+					return fmt.Errorf("no advisories repo dir specified, and distro auto-detection failed: %w", err)
+				}
+
+				advisoriesRepoDir = d.Local.AdvisoriesRepo.Dir
+				_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+			}
+
+			encodeOpts, err := yam.TryReadingEncodeOptions(advisoriesRepoDir)
+			if err != nil {
+				return fmt.Errorf("reading yam encode options: %w", err)
+			}
+
+			vulnDBIndex, err := os.ReadFile(filepath.Join(p.vulnDBDir, "index", "vulns.json"))
+			if err != nil {
+				return fmt.Errorf("reading vulndb index for provenance: %w", err)
+			}
+			req.Provenance = advisory.NewProvenance(
+				cmd.CommandPath(),
+				advisory.Evidence{Type: "go-vulndb-index-checksum", Value: fmt.Sprintf("sha256:%x", sha256.Sum256(vulnDBIndex))},
+			)
+
+			putter := advisory.NewFSPutter(rwos.DirFS(advisoriesRepoDir), advisory.NewYamDocumentEncoder(encodeOpts))
+			advID, err := putter.Upsert(cmd.Context(), *req)
+			if err != nil {
+				return fmt.Errorf("adding advisory event: %w", err)
+			}
+
+			fmt.Fprintf(w, "Added event to advisory %s\n", advID)
+
+			return nil
+		},
+	}
+
+	p.addFlagsTo(cmd)
+	return cmd
+}
+
+type goReachabilityParams struct {
+	doNotDetectDistro bool
+	advisoriesRepoDir string
+
+	vulnDBDir   string
+	packageName string
+	vuln        string
+	apply       bool
+}
+
+func (p *goReachabilityParams) addFlagsTo(cmd *cobra.Command) {
+	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
+	addAdvisoriesDirFlag(&p.advisoriesRepoDir, cmd)
+	addPackageFlag(&p.packageName, cmd)
+	addVulnFlag(&p.vuln, cmd)
+
+	cmd.Flags().StringVar(&p.vulnDBDir, "vulndb", "", "directory containing a local snapshot of the Go vulnerability database")
+	cmd.Flags().BoolVar(&p.apply, "apply", false, "add the proposed event to the package's advisory data")
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	cgaid "github.com/chainguard-dev/advisory-schema/pkg/advisory"
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/chainguard-dev/clog"
 	tea "github.com/charmbracelet/bubbletea"
@@ -51,6 +50,11 @@ func cmdAdvisoryGuide() *cobra.Command {
 
 			// Construct some things we'll need later.
 
+			rootQuestion, err := loadGuideQuestions(ctx, opts.questionsFile)
+			if err != nil {
+				return err
+			}
+
 			githubClient := github.NewClient(nil).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
 			af := advisory.NewHTTPAliasFinder(http.DefaultClient)
 
@@ -194,16 +198,22 @@ func cmdAdvisoryGuide() *cobra.Command {
 				return fmt.Errorf("failed to scan build group: %w", err)
 			}
 			collated := collateVulnerabilities(results)
+			evidence := scanEvidence(results)
 
 			// Grab the latest advisory data in a new session.
 
-			sess, err := advisory.NewDataSession(
-				ctx,
-				advisory.DataSessionOptions{
-					Distro:       detected,
-					GitHubClient: githubClient,
-				},
-			)
+			sessOpts := advisory.DataSessionOptions{
+				Distro:       detected,
+				GitHubClient: githubClient,
+			}
+			if opts.advisoriesRemote != "" {
+				sessOpts.Remote = advisory.LocalRemote{Path: opts.advisoriesRemote}
+			}
+			if opts.patchSeriesDir != "" {
+				sessOpts.ReviewRequester = advisory.PatchSeriesReviewRequester{Dir: opts.patchSeriesDir}
+			}
+
+			sess, err := advisory.NewDataSession(ctx, sessOpts)
 			if err != nil {
 				return fmt.Errorf("initializing advisory data session: %w", err)
 			}
@@ -275,14 +285,7 @@ func cmdAdvisoryGuide() *cobra.Command {
 				// advisory request.
 
 				findingVulnID := vaPicked.Result.Findings[0].Vulnerability.ID
-				req := advisory.Request{
-					Package: vaPicked.APKs[0],
-				}
-				if cgaid.RegexCGA.MatchString(findingVulnID) {
-					req.AdvisoryID = findingVulnID
-				} else {
-					req.Aliases = []string{findingVulnID}
-				}
+				req := question.InitialRequest(vaPicked.APKs[0], findingVulnID)
 
 				resolvedReq, err := req.ResolveAliases(ctx, af)
 				if err != nil {
@@ -290,7 +293,9 @@ func cmdAdvisoryGuide() *cobra.Command {
 				}
 				req = *resolvedReq
 
-				iv, err := interview.New(question.IsFalsePositive, req)
+				interviewRoot, transcript := question2.Record(rootQuestion)
+
+				iv, err := interview.New(interviewRoot, req)
 				if err != nil {
 					return fmt.Errorf("creating interview for advisory request: %w", err)
 				}
@@ -320,11 +325,24 @@ func cmdAdvisoryGuide() *cobra.Command {
 					return fmt.Errorf("no aliases found for advisory request, please report this")
 				}
 
+				req.Provenance = advisory.NewProvenance(cmd.CommandPath(), evidence...)
+
 				err = sess.Append(ctx, req)
 				if err != nil {
 					return fmt.Errorf("adding advisory data: %w", err)
 				}
 
+				if opts.recordAnswersDir != "" {
+					answers := question.AnswerFile{
+						Package:       req.Package,
+						Vulnerability: findingVulnID,
+						Answers:       transcript.Answers,
+					}
+					if err := writeAnswerFile(opts.recordAnswersDir, answers); err != nil {
+						return err
+					}
+				}
+
 				vuln := req.Aliases[0]
 				if len(req.Aliases) > 1 {
 					vuln += fmt.Sprintf(" (%s)", strings.Join(req.Aliases[1:], ", "))
@@ -342,6 +360,7 @@ func cmdAdvisoryGuide() *cobra.Command {
 
 	cmd.AddCommand(
 		cmdAdvisoryGuideGraph(),
+		cmdAdvisoryGuideReplay(),
 	)
 
 	opts.addToCmd(cmd)
@@ -386,11 +405,6 @@ var (
 			Key:         "p",
 			Description: "to open a PR with your updates",
 			Do: func(_ resultWithAPKs) tea.Cmd {
-				err := sess.Push(ctx)
-				if err != nil {
-					return picker.ErrCmd(fmt.Errorf("data session push: %w", err))
-				}
-
 				pr, err := sess.OpenPullRequest(ctx)
 				if err != nil {
 					return picker.ErrCmd(fmt.Errorf("data session pull request: %w", err))
@@ -404,11 +418,38 @@ var (
 )
 
 type advisoryGuideParams struct {
-	speedy bool
+	speedy           bool
+	questionsFile    string
+	recordAnswersDir string
+
+	advisoriesRemote string
+	patchSeriesDir   string
 }
 
 func (p *advisoryGuideParams) addToCmd(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&p.speedy, "speedy", "s", false, "Skip explanations and unnecessary time delays")
+	cmd.Flags().StringVar(&p.advisoriesRemote, "advisories-remote", "", "path to a local (e.g. bare) git repository to use instead of the distro's advisories repository on GitHub")
+	cmd.Flags().StringVar(&p.patchSeriesDir, "patch-series-dir", "", "write a patch series to this directory instead of opening a pull request on GitHub")
+	cmd.Flags().StringVar(&p.recordAnswersDir, "record-answers", "", "record the answers given for each vulnerability to an answer file in this directory, which can be replayed with 'guide replay'")
+	addGuideQuestionsFlag(&p.questionsFile, cmd)
+}
+
+func addGuideQuestionsFlag(val *string, cmd *cobra.Command) {
+	cmd.Flags().StringVar(val, "questions", "", "path to a YAML file defining the guide's questions (defaults to the built-in questions)")
+}
+
+// loadGuideQuestions returns the root question of the guide's question tree,
+// loaded from the given file, or the built-in tree if the path is empty.
+func loadGuideQuestions(ctx context.Context, path string) (question2.Question[advisory.Request], error) {
+	if path == "" {
+		root, err := question.Default(ctx)
+		if err != nil {
+			return question2.Question[advisory.Request]{}, fmt.Errorf("loading built-in guide questions: %w", err)
+		}
+		return root, nil
+	}
+
+	return question.LoadFile(ctx, path)
 }
 
 func (p advisoryGuideParams) pause() {
@@ -436,6 +477,33 @@ func renderResultWithAPKs(r resultWithAPKs) string {
 	)
 }
 
+// scanEvidence returns the provenance evidence for advisory data entered based
+// on the given scan results, i.e. the vulnerability data sources used by the
+// scanner.
+func scanEvidence(results []scan.Result) []advisory.Evidence {
+	var evidence []advisory.Evidence
+	seen := make(map[advisory.Evidence]struct{})
+
+	for i := range results {
+		ds := results[i].DataSource
+		if ds.Integrity == "" {
+			continue
+		}
+
+		e := advisory.Evidence{
+			Type:  fmt.Sprintf("%s-checksum", ds.Kind),
+			Value: ds.Integrity,
+		}
+		if _, ok := seen[e]; ok {
+			continue
+		}
+		seen[e] = struct{}{}
+		evidence = append(evidence, e)
+	}
+
+	return evidence
+}
+
 // collateVulnerabilities takes a slice of scan.Result and returns a slice of
 // resultWithAPKs.
 func collateVulnerabilities(results []scan.Result) []resultWithAPKs {
@@ -5,12 +5,13 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wolfi-dev/wolfictl/pkg/advisory"
-	"github.com/wolfi-dev/wolfictl/pkg/advisory/question"
 	"github.com/wolfi-dev/wolfictl/pkg/question/graph"
 )
 
 func cmdAdvisoryGuideGraph() *cobra.Command {
-	return &cobra.Command{
+	var questionsFile string
+
+	cmd := &cobra.Command{
 		Use:           "graph",
 		Short:         "Generate a DOT graph of the advisory guide interview questions",
 		Deprecated:    advisoryDeprecationMessage,
@@ -22,7 +23,12 @@ func cmdAdvisoryGuideGraph() *cobra.Command {
 				AdvisoryID: "CGA-xxxx-xxxx-xxxx",
 			}
 
-			dot, err := graph.Dot(cmd.Context(), question.IsFalsePositive, sampleReq)
+			rootQuestion, err := loadGuideQuestions(cmd.Context(), questionsFile)
+			if err != nil {
+				return err
+			}
+
+			dot, err := graph.Dot(cmd.Context(), rootQuestion, sampleReq)
 			if err != nil {
 				return fmt.Errorf("generating DOT: %w", err)
 			}
@@ -32,4 +38,7 @@ func cmdAdvisoryGuideGraph() *cobra.Command {
 			return nil
 		},
 	}
+
+	addGuideQuestionsFlag(&questionsFile, cmd)
+	return cmd
 }
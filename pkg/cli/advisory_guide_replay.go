@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory/question"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+	question2 "github.com/wolfi-dev/wolfictl/pkg/question"
+	"github.com/wolfi-dev/wolfictl/pkg/yam"
+)
+
+func cmdAdvisoryGuideReplay() *cobra.Command {
+	p := &guideReplayParams{}
+	cmd := &cobra.Command{
+		Use:        "replay <answer-file>...",
+		Short:      "Replay recorded guide answers to enter advisory data without user interaction",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Replay recorded guide answers to enter advisory data without user interaction.
+
+Each answer file records the answers given in a guide interview about a single
+vulnerability in a single package. Answer files can be recorded from an
+interactive session with 'wolfictl advisory guide --record-answers <dir>', which
+names them <package>-<vulnerability>.answers.yaml, or written by hand. For
+example:
+
+    package: ko
+    vulnerability: CVE-2023-45288
+    answers:
+      - question: Is this a false positive?
+        choice: 1
+        choice-text: "Yes"
+      - question: Why is this a false positive?
+        choice: 1
+        choice-text: This is specific to another distro, not ours.
+      - question: Which other distro is this specific to?
+        choice: 2
+        choice-text: Debian
+
+Choices are identified by their index, starting at 0. The "question" and
+"choice-text" fields are optional, but if they're set, they must match the
+guide's questions, which guards against replaying answers against a question
+tree that has changed since they were recorded.
+
+The answers are replayed through the same questions as the interactive guide,
+and the resulting advisory events are added to the advisories repository.
+Interviews that end without a result (e.g. by asking for help) add nothing.
+`,
+		SilenceErrors: true,
+		Args:          cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			logger := clog.FromContext(ctx)
+
+			rootQuestion, err := loadGuideQuestions(ctx, p.questionsFile)
+			if err != nil {
+				return err
+			}
+
+			var putter *advisory.FSPutter
+			if !p.dryRun {
+				advisoriesRepoDir := resolveAdvisoriesDirInput(p.advisoriesRepoDir)
+				if advisoriesRepoDir == "" {
+					if p.doNotDetectDistro {
+						return fmt.Errorf("no advisories repo dir specified")
+					}
+
+					d, err := distro.Detect()
+					if err != nil {
+						return fmt.Errorf("no advisories repo dir specified, and distro auto-detection failed: %w", err)
+					}
+
+					advisoriesRepoDir = d.Local.AdvisoriesRepo.Dir
+					_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+				}
+
+				encodeOpts, err := yam.TryReadingEncodeOptions(advisoriesRepoDir)
+				if err != nil {
+					return fmt.Errorf("getting yam encode options: %w", err)
+				}
+				putter = advisory.NewFSPutter(rwos.DirFS(advisoriesRepoDir), advisory.NewYamDocumentEncoder(encodeOpts))
+			}
+
+			var af advisory.AliasFinder
+			if !p.skipAliasResolution {
+				af = advisory.NewHTTPAliasFinder(http.DefaultClient)
+			}
+
+			w := cmd.OutOrStdout()
+
+			for _, path := range args {
+				f, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("opening answer file: %w", err)
+				}
+
+				answers, err := question.ReadAnswerFile(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("reading %s: %w", path, err)
+				}
+
+				req := question.InitialRequest(answers.Package, answers.Vulnerability)
+				if af != nil {
+					resolvedReq, err := req.ResolveAliases(ctx, af)
+					if err != nil {
+						// Carry on with the unresolved request, whose aliases can be filled in
+						// later.
+						logger.Warnf("resolving aliases for advisory request: %v", err)
+					} else {
+						req = *resolvedReq
+					}
+				}
+
+				req, err = answers.Replay(rootQuestion, req)
+				if err != nil {
+					if errors.Is(err, question2.ErrTerminate) {
+						fmt.Fprintf(w, "⏭️  %s: no advisory data for %s in %s\n", path, answers.Vulnerability, answers.Package)
+						continue
+					}
+
+					return fmt.Errorf("%s: %w", path, err)
+				}
+
+				if putter != nil {
+					req.Provenance = advisory.NewProvenance(
+						cmd.CommandPath(),
+						advisory.Evidence{Type: "answer-file", Value: path},
+					)
+					if _, err := putter.Upsert(ctx, req); err != nil {
+						return fmt.Errorf("%s: adding advisory data: %w", path, err)
+					}
+				}
+
+				fmt.Fprintf(w, "✅ %s: marked %s in %s as %s\n", path, answers.Vulnerability, answers.Package, humanizeAdvisoryEventType(req.Event.Type))
+			}
+
+			return nil
+		},
+	}
+
+	p.addFlagsTo(cmd)
+	return cmd
+}
+
+type guideReplayParams struct {
+	doNotDetectDistro   bool
+	advisoriesRepoDir   string
+	questionsFile       string
+	skipAliasResolution bool
+	dryRun              bool
+}
+
+func (p *guideReplayParams) addFlagsTo(cmd *cobra.Command) {
+	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
+	addAdvisoriesDirFlag(&p.advisoriesRepoDir, cmd)
+	addGuideQuestionsFlag(&p.questionsFile, cmd)
+	cmd.Flags().BoolVar(&p.skipAliasResolution, "skip-alias-resolution", false, "don't look up aliases for the vulnerabilities (e.g. when offline)")
+	cmd.Flags().BoolVar(&p.dryRun, "dry-run", false, "replay the answers without writing any advisory data")
+}
+
+// writeAnswerFile writes the answer file to the given directory, named after
+// its package and vulnerability.
+func writeAnswerFile(dir string, answers question.AnswerFile) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating answer file directory: %w", err)
+	}
+
+	path := filepath.Join(dir, answers.FileName())
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating answer file: %w", err)
+	}
+	defer f.Close()
+
+	if err := answers.Write(f); err != nil {
+		return fmt.Errorf("writing answer file %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+)
+
+func cmdAdvisoryMigrate() *cobra.Command {
+	p := &migrateParams{}
+	cmd := &cobra.Command{
+		Use:        "migrate",
+		Short:      "Migrate advisory documents to a newer schema version",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Migrate advisory documents to a newer schema version.
+
+This command applies the registered schema migrations, in order, to each
+advisory document whose schema version is older than the target version.
+Documents are updated in place, preserving comments and formatting where
+possible. Documents already at or beyond the target version are left alone, so
+it's safe to run this command repeatedly.
+
+By default, documents are migrated to the latest schema version supported by
+this version of wolfictl. Use --to to migrate to a specific version instead.
+
+Use --dry-run to print a diff of the changes that would be made, without
+modifying any files.
+
+You may pass one or more instances of -p/--package to have the command operate
+on only one or more packages, rather than on the entire advisory data set.
+`,
+		Example: `
+wolfictl adv migrate --dry-run
+
+wolfictl adv migrate --to 2.0.2 -p ko`,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			advisoriesRepoDir := resolveAdvisoriesDirInput(p.advisoriesRepoDir)
+			if advisoriesRepoDir == "" {
+				if p.doNotDetectDistro {
+					return fmt.Errorf("no advisories repo dir specified")
+				}
+
+				d, err := distro.Detect()
+				if err != nil {
+					return fmt.Errorf("no advisories repo dir specified, and distro auto-detection failed: %w", err)
+				}
+
+				advisoriesRepoDir = d.Local.AdvisoriesRepo.Dir
+				_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+			}
+
+			var advisoriesFsys rwfs.FS = rwos.DirFS(advisoriesRepoDir)
+			if p.dryRun {
+				// Keep all changes in memory, so we can diff them against what's on disk.
+				advisoriesFsys = memfs.New(os.DirFS(advisoriesRepoDir))
+			}
+
+			advisoryDocs, err := adv2.NewIndex(ctx, advisoriesFsys)
+			if err != nil {
+				return fmt.Errorf("unable to index advisory documents for directory %q: %w", advisoriesRepoDir, err)
+			}
+
+			selectedPackageSet := make(map[string]struct{})
+			for _, pkg := range p.packages {
+				selectedPackageSet[pkg] = struct{}{}
+			}
+
+			results, err := advisory.MigrateSchema(ctx, advisory.MigrateSchemaOptions{
+				AdvisoryDocs:     advisoryDocs,
+				To:               p.to,
+				SelectedPackages: selectedPackageSet,
+			})
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+
+			var errs []error
+			for _, r := range results {
+				if r.Err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", r.Path, r.Err))
+					continue
+				}
+
+				from := r.From
+				if from == "" {
+					from = "(none)"
+				}
+				fmt.Fprintf(w, "%s: %s -> %s\n", r.Path, from, r.To)
+				for _, m := range r.Applied {
+					fmt.Fprintf(w, "  %s: %s\n", m.Version, m.Description)
+				}
+
+				if p.dryRun {
+					if err := printFileDiff(w, os.DirFS(advisoriesRepoDir), advisoriesFsys, r.Path); err != nil {
+						return err
+					}
+				}
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+
+	p.addFlagsTo(cmd)
+	return cmd
+}
+
+// printFileDiff writes a diff of the file at the given path, comparing its
+// content in the "before" filesystem to its content in the "after" filesystem.
+func printFileDiff(w io.Writer, before, after fs.FS, path string) error {
+	beforeBytes, err := fs.ReadFile(before, path)
+	if err != nil {
+		return fmt.Errorf("reading original %q: %w", path, err)
+	}
+
+	afterBytes, err := fs.ReadFile(after, path)
+	if err != nil {
+		return fmt.Errorf("reading updated %q: %w", path, err)
+	}
+
+	if diff := cmp.Diff(string(beforeBytes), string(afterBytes)); diff != "" {
+		fmt.Fprintf(w, "\n%s\n", diff)
+	}
+
+	return nil
+}
+
+type migrateParams struct {
+	advisoriesRepoDir string
+	doNotDetectDistro bool
+
+	packages []string
+	to       string
+	dryRun   bool
+}
+
+func (p *migrateParams) addFlagsTo(cmd *cobra.Command) {
+	addAdvisoriesDirFlag(&p.advisoriesRepoDir, cmd)
+	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
+
+	cmd.Flags().StringSliceVarP(&p.packages, flagNamePackage, "p", nil, "packages to operate on")
+	cmd.Flags().StringVar(&p.to, "to", "", fmt.Sprintf("schema version to migrate to (default %q)", advisory.DefaultSchemaMigrations.Latest()))
+	cmd.Flags().BoolVar(&p.dryRun, "dry-run", false, "print the changes that would be made without modifying any files")
+}
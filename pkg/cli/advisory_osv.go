@@ -35,8 +35,19 @@ The user must also specify directories for all package repositories associated w
 given advisory data. This is used to make sure the OSV data includes all relevant packages
 and subpackages.
 
+When more than one advisory repository has data for the same package, the data is
+merged. Advisories describing the same vulnerability are combined, and their events are
+interleaved by timestamp. If two events have the same timestamp but otherwise differ, the
+event from the repository listed first is kept. Use --strict to fail instead.
+
 The output directory for the OSV dataset is specified using the --output flag. This
 directory must already exist before running the command.
+
+Use --incremental to rewrite only the OSV entries that changed since the last
+incremental build, and delete entries for advisories that no longer exist. This
+relies on a manifest (manifest.json) that the build keeps in the output directory.
+Incremental builds also write an all.zip archive of all entries. Files whose content
+hasn't changed are left untouched, so that they don't need to be published again.
 `,
 		SilenceErrors: true,
 		Args:          cobra.NoArgs,
@@ -91,6 +102,8 @@ directory must already exist before running the command.
 				PackageConfigIndices: packageIndices,
 				AddedEcosystems:      addedEcosystems,
 				OutputDirectory:      p.outputDirectory,
+				Strict:               p.strict,
+				Incremental:          p.incremental,
 			}
 
 			err := advisory.BuildOSVDataset(ctx, opts)
@@ -110,10 +123,14 @@ type osvParams struct {
 	advisoriesRepoDirs []string
 	packagesRepoDirs   []string
 	outputDirectory    string
+	strict             bool
+	incremental        bool
 }
 
 func (p *osvParams) addFlagsTo(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVarP(&p.advisoriesRepoDirs, "advisories-repo-dir", "a", nil, "path to the directory(ies) containing Chainguard advisory data")
 	cmd.Flags().StringSliceVarP(&p.packagesRepoDirs, "packages-repo-dir", "p", nil, "path to the directory(ies) containing Chainguard package data")
 	cmd.Flags().StringVarP(&p.outputDirectory, "output", "o", "", "path to a local directory in which the OSV dataset will be written")
+	cmd.Flags().BoolVar(&p.strict, "strict", false, "fail if advisory data for the same package conflicts across advisory repositories")
+	cmd.Flags().BoolVar(&p.incremental, "incremental", false, "only rewrite entries that changed since the last incremental build, and delete entries for removed advisories")
 }
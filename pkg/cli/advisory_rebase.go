@@ -1,14 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"path/filepath"
 
+	"chainguard.dev/apko/pkg/apk/client"
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/chainguard-dev/clog"
 	"github.com/spf13/cobra"
 	"github.com/wolfi-dev/wolfictl/pkg/advisory"
 	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	buildconfigs "github.com/wolfi-dev/wolfictl/pkg/configs/build"
 	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
 )
 
@@ -28,11 +32,23 @@ By default this command will "rebase" all advisories from the source location
 onto the corresponding advisories file in the destination directory. But it's
 also possible to rebase one advisory at a time, by using the -V flag to specify
 a vulnerability ID or advisory ID for one particular advisory.
+
+Advisories whose latest event is "fixed" are skipped by default, because the
+fixed version describes a package in the source repository. Use
+--translate-fixed to carry them over anyway: the fixed version is translated to
+the earliest version of the package in the destination that contains the same
+upstream fix. The destination's versions are taken from the APKINDEXes of the
+destination package repository (-r) and the destination's build configuration
+(-d). Fixed versions that can't be translated (for example, because the source
+fix was a distro patch rather than an upstream release) are skipped with a
+warning.
 `,
 		Example: `
 wolfictl adv rebase ./argo-cd-2.8.yaml ../enterprise-advisories
 
 wolfictl adv rebase ./argo-cd-2.8.yaml ../enterprise-advisories -V CVE-2021-25743
+
+wolfictl adv rebase ./argo-cd-2.8.yaml ../enterprise-advisories --translate-fixed -d ../enterprise-packages -r https://apk.cgr.dev/chainguard-private
 `,
 		SilenceErrors: true,
 		Deprecated:    advisoryDeprecationMessage,
@@ -79,6 +95,12 @@ wolfictl adv rebase ./argo-cd-2.8.yaml ../enterprise-advisories -V CVE-2021-2574
 				CurrentTime:      v2.Now(),
 			}
 
+			if p.translateFixed {
+				if err := p.addFixedVersionTranslationOptions(ctx, &opts); err != nil {
+					return err
+				}
+			}
+
 			log.Debug("attempting rebase")
 
 			if err := advisory.Rebase(clog.WithLogger(ctx, log), opts); err != nil {
@@ -97,8 +119,47 @@ wolfictl adv rebase ./argo-cd-2.8.yaml ../enterprise-advisories -V CVE-2021-2574
 
 type rebaseParams struct {
 	vuln string
+
+	translateFixed       bool
+	dstDistroRepoDir     string
+	dstPackageRepoURL    string
+	dstPackageRepoArches []string
 }
 
 func (p *rebaseParams) addFlagsTo(cmd *cobra.Command) {
 	addVulnFlag(&p.vuln, cmd)
+
+	cmd.Flags().BoolVar(&p.translateFixed, "translate-fixed", false, "translate fixed versions from the source to the destination, instead of skipping fixed advisories")
+	cmd.Flags().StringVarP(&p.dstDistroRepoDir, flagNameDistroRepoDir, "d", "", "directory containing the destination's distro repository (used with --translate-fixed)")
+	cmd.Flags().StringVarP(&p.dstPackageRepoURL, flagNamePackageRepoURL, "r", "", "URL of the destination's APK package repository (used with --translate-fixed)")
+	cmd.Flags().StringSliceVar(&p.dstPackageRepoArches, "arch", []string{"x86_64", "aarch64"}, "architectures of the destination's APK package repository to get APKINDEXes for (used with --translate-fixed)")
+}
+
+func (p *rebaseParams) addFixedVersionTranslationOptions(ctx context.Context, opts *advisory.RebaseOptions) error {
+	if p.dstPackageRepoURL == "" && p.dstDistroRepoDir == "" {
+		return fmt.Errorf("--translate-fixed requires the destination's package repository URL (-r) and/or distro repository directory (-d)")
+	}
+
+	opts.TranslateFixedVersions = true
+
+	if p.dstPackageRepoURL != "" {
+		c := client.New(http.DefaultClient)
+		for _, arch := range p.dstPackageRepoArches {
+			idx, err := c.GetRemoteIndex(ctx, p.dstPackageRepoURL, arch)
+			if err != nil {
+				return fmt.Errorf("getting destination APKINDEX for %s: %w", arch, err)
+			}
+			opts.DestinationAPKIndexes = append(opts.DestinationAPKIndexes, idx)
+		}
+	}
+
+	if p.dstDistroRepoDir != "" {
+		buildCfgs, err := buildconfigs.NewIndex(ctx, rwos.DirFS(p.dstDistroRepoDir))
+		if err != nil {
+			return fmt.Errorf("indexing destination build configurations: %w", err)
+		}
+		opts.DestinationBuildCfgs = buildCfgs
+	}
+
+	return nil
 }
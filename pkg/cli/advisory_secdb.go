@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	v2 "github.com/chainguard-dev/advisory-schema/pkg/advisory/v2"
 	"github.com/chainguard-dev/clog"
@@ -18,9 +20,26 @@ import (
 func cmdAdvisorySecDB() *cobra.Command {
 	p := &dbParams{}
 	cmd := &cobra.Command{
-		Use:           "secdb",
-		Aliases:       []string{"db"},
-		Short:         "Build an Alpine-style security database from advisory data",
+		Use:     "secdb",
+		Aliases: []string{"db"},
+		Short:   "Build an Alpine-style security database from advisory data",
+		Long: `Build an Alpine-style security database from advisory data.
+
+By default, a single security database is written to the --output file (or stdout)
+for all of the given architectures.
+
+Use --split-arch and/or --branch to write several security databases at once, laid
+out in the --output directory as "[<branch>/][<arch>/]<repo>.json", like Alpine's
+security databases. The branch is recorded in each database as its "distroversion".
+
+Every security database is validated against the secdb JSON schema before it's
+written.
+
+Use --check to verify that the security database(s) already at the output location
+(e.g. a local copy of the published database) are byte-for-byte what the current
+advisory data produces. Nothing is written, and the command fails if any file
+differs, or if a published file is no longer produced by the advisory data.
+`,
 		SilenceErrors: true,
 		Deprecated:    advisoryDeprecationMessage,
 		Args:          cobra.NoArgs,
@@ -57,6 +76,11 @@ func cmdAdvisorySecDB() *cobra.Command {
 				URLPrefix:          p.urlPrefix,
 				Archs:              p.archs,
 				Repo:               p.repo,
+				Strict:             p.strict,
+			}
+
+			if p.splitByArch || len(p.branches) > 0 {
+				return p.buildFiles(ctx, opts)
 			}
 
 			database, err := advisory.BuildSecurityDatabase(ctx, opts)
@@ -64,6 +88,15 @@ func cmdAdvisorySecDB() *cobra.Command {
 				return err
 			}
 
+			if p.check {
+				if p.outputLocation == "" {
+					return fmt.Errorf("the published security database to check must be specified with --output")
+				}
+
+				files := []advisory.SecurityDatabaseFile{{Path: filepath.Base(p.outputLocation), Data: database}}
+				return advisory.CheckSecurityDatabaseFiles(files, os.DirFS(filepath.Dir(p.outputLocation)))
+			}
+
 			var outputFile *os.File
 			if p.outputLocation == "" {
 				outputFile = os.Stdout
@@ -98,6 +131,12 @@ type dbParams struct {
 	urlPrefix string
 	archs     []string
 	repo      string
+
+	strict bool
+
+	splitByArch bool
+	branches    []string
+	check       bool
 }
 
 func (p *dbParams) addFlagsTo(cmd *cobra.Command) {
@@ -110,4 +149,42 @@ func (p *dbParams) addFlagsTo(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&p.urlPrefix, "url-prefix", "https://packages.wolfi.dev", "URL scheme and hostname for the package repository")
 	cmd.Flags().StringSliceVar(&p.archs, "arch", []string{"x86_64"}, "the package architectures the security database is for")
 	cmd.Flags().StringVar(&p.repo, "repo", "os", "the name of the package repository")
+	cmd.Flags().BoolVar(&p.strict, "strict", false, "fail if advisory data for the same package conflicts across advisory repositories")
+	cmd.Flags().BoolVar(&p.splitByArch, "split-arch", false, "write a separate security database for each architecture (requires --output to be a directory)")
+	cmd.Flags().StringSliceVar(&p.branches, "branch", nil, "write a separate security database for each given branch of the package repository (requires --output to be a directory)")
+	cmd.Flags().BoolVar(&p.check, "check", false, "instead of writing the security database, verify that the one at the output location is exactly what the advisory data produces")
+}
+
+// buildFiles builds the security database split across multiple files in the
+// output directory, or checks the files already there.
+func (p *dbParams) buildFiles(ctx context.Context, opts advisory.BuildSecurityDatabaseOptions) error {
+	if p.outputLocation == "" {
+		return fmt.Errorf("an output directory must be specified with --output when splitting the security database")
+	}
+
+	files, err := advisory.BuildSecurityDatabaseFiles(ctx, advisory.BuildSecurityDatabaseFilesOptions{
+		BuildSecurityDatabaseOptions: opts,
+		SplitByArch:                  p.splitByArch,
+		Branches:                     p.branches,
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.check {
+		return advisory.CheckSecurityDatabaseFiles(files, os.DirFS(p.outputLocation))
+	}
+
+	for _, f := range files {
+		path := filepath.Join(p.outputLocation, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", f.Path, err)
+		}
+
+		if err := os.WriteFile(path, f.Data, 0o644); err != nil { //nolint:gosec // The security database is meant to be readable by all.
+			return fmt.Errorf("unable to write the security database to %s: %w", path, err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	adv2 "github.com/wolfi-dev/wolfictl/pkg/configs/advisory/v2"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+)
+
+func cmdAdvisorySite() *cobra.Command {
+	p := &siteParams{}
+	cmd := &cobra.Command{
+		Use:        "site",
+		Short:      "Generate a static website from advisory data",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Generate a static website from advisory data.
+
+This command renders the advisory data as a set of static HTML pages that can be
+served by any web server, or browsed locally. The site has:
+
+  - an index page listing all packages and vulnerabilities, with a filter box,
+  - one page per package, showing each advisory's event timeline, aliases and
+    fixed versions,
+  - one page per vulnerability ID (including aliases), showing the advisories
+    for that vulnerability across all packages, and
+  - a search.json file with metadata about each advisory, for use by search
+    tools and other clients.
+
+Links to external vulnerability databases are included where available.
+
+The site is written to the directory given by --out, which is created if it
+doesn't exist.
+`,
+		Example: `
+wolfictl adv site --out ./site
+
+wolfictl adv site -a ../advisories --out ./site --title "Wolfi Security Advisories"`,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			if p.outputDirectory == "" {
+				return fmt.Errorf("output directory must be specified with --out")
+			}
+
+			title := p.title
+
+			advisoriesRepoDir := resolveAdvisoriesDirInput(p.advisoriesRepoDir)
+			if advisoriesRepoDir == "" {
+				if p.doNotDetectDistro {
+					return fmt.Errorf("no advisories repo dir specified")
+				}
+
+				d, err := distro.Detect()
+				if err != nil {
+					return fmt.Errorf("no advisories repo dir specified, and distro auto-detection failed: %w", err)
+				}
+
+				advisoriesRepoDir = d.Local.AdvisoriesRepo.Dir
+				_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+
+				if title == "" {
+					title = fmt.Sprintf("%s Security Advisories", d.Absolute.Name)
+				}
+			}
+
+			advisoryDocs, err := adv2.NewIndex(ctx, rwos.DirFS(advisoriesRepoDir))
+			if err != nil {
+				return fmt.Errorf("unable to index advisory documents for directory %q: %w", advisoriesRepoDir, err)
+			}
+
+			opts := advisory.SiteOptions{
+				AdvisoryDocs:    advisoryDocs,
+				OutputDirectory: p.outputDirectory,
+				Title:           title,
+			}
+
+			if err := advisory.BuildSite(ctx, opts); err != nil {
+				return fmt.Errorf("building advisory site: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	p.addFlagsTo(cmd)
+	return cmd
+}
+
+type siteParams struct {
+	doNotDetectDistro bool
+	advisoriesRepoDir string
+	outputDirectory   string
+	title             string
+}
+
+func (p *siteParams) addFlagsTo(cmd *cobra.Command) {
+	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
+	addAdvisoriesDirFlag(&p.advisoriesRepoDir, cmd)
+	cmd.Flags().StringVarP(&p.outputDirectory, "out", "o", "", "directory in which to write the website")
+	cmd.Flags().StringVar(&p.title, "title", "", "title shown on every page of the website")
+}
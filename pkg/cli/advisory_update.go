@@ -253,6 +253,7 @@ required fields are missing.`,
 					return fmt.Errorf("completing alias set for advisory request (package %q): %w", r.Package, err)
 				}
 				r.Aliases = aliases
+				r.Provenance = advisory.NewProvenance(cmd.CommandPath())
 
 				_, err = advPutter.Upsert(ctx, r)
 				if err != nil {
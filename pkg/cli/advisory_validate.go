@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -60,6 +62,11 @@ specify the following flags:
 
 More information about these flags is shown in the documentation for each flag.
 
+Use --fix to automatically resolve mechanical issues before validating. This
+fills in missing aliases, sorts aliases, removes duplicate events, sorts events
+chronologically, and renames documents whose file names don't match their
+package names.
+
 If any issues are found in the advisory data, the command will exit 1, and will
 print an error message that specifies where and how the data is invalid.`,
 		SilenceErrors: true,
@@ -162,6 +169,17 @@ print an error message that specifies where and how the data is invalid.`,
 				}
 			}
 
+			selectedPackageSet := make(map[string]struct{})
+			for _, pkg := range p.packages {
+				selectedPackageSet[pkg] = struct{}{}
+			}
+
+			if p.fix {
+				if err := p.autoFix(ctx, advisoriesRepoDir, selectedPackageSet); err != nil {
+					return err
+				}
+			}
+
 			advisoriesIndex, err := adv2.NewIndex(cmd.Context(), rwos.DirFS(advisoriesRepoDir))
 			if err != nil {
 				return fmt.Errorf("unable to create index of advisories repo: %w", err)
@@ -186,11 +204,6 @@ print an error message that specifies where and how the data is invalid.`,
 				af = advisory.NewHTTPAliasFinder(http.DefaultClient)
 			}
 
-			selectedPackageSet := make(map[string]struct{})
-			for _, pkg := range p.packages {
-				selectedPackageSet[pkg] = struct{}{}
-			}
-
 			opts := advisory.ValidateOptions{
 				AdvisoryDocs:          advisoriesIndex,
 				BaseAdvisoryDocs:      baseAdvisoriesIndex,
@@ -234,6 +247,45 @@ type validateParams struct {
 	skipAliasCompletenessValidation bool
 	skipPackageExistenceValidation  bool
 	packageRepositoryURL            string
+	fix                             bool
+}
+
+// autoFix resolves mechanical issues in the advisory data before validation.
+func (p *validateParams) autoFix(ctx context.Context, advisoriesRepoDir string, selectedPackages map[string]struct{}) error {
+	index, err := adv2.NewIndex(ctx, rwos.DirFS(advisoriesRepoDir))
+	if err != nil {
+		return fmt.Errorf("unable to create index of advisories repo: %w", err)
+	}
+
+	// Like validation, alias completeness is only checked on request, since it
+	// looks up every advisory's aliases over the network.
+	var af advisory.AliasFinder
+	if !p.skipAliasCompletenessValidation {
+		af = advisory.NewHTTPAliasFinder(http.DefaultClient)
+	}
+
+	opts := advisory.AutoFixOptions{
+		AdvisoryDocs:     index,
+		SelectedPackages: selectedPackages,
+		AliasFinder:      af,
+		RenameFile: func(oldPath, newPath string) error {
+			return os.Rename(filepath.Join(advisoriesRepoDir, oldPath), filepath.Join(advisoriesRepoDir, newPath))
+		},
+	}
+
+	changes, err := advisory.AutoFix(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("fixing advisory data: %w", err)
+	}
+
+	for _, c := range changes {
+		fmt.Fprintf(os.Stderr, "🔧 %s\n", c)
+	}
+	if len(changes) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	return nil
 }
 
 const (
@@ -255,6 +307,7 @@ func (p *validateParams) addFlagsTo(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&p.skipAliasCompletenessValidation, flagNameSkipAliasCompleteness, true, "skip alias completeness validation")
 	cmd.Flags().BoolVar(&p.skipPackageExistenceValidation, flagNameSkipPackageExistence, false, "skip package configuration existence validation")
 	addPackageRepoURLFlag(&p.packageRepositoryURL, cmd)
+	cmd.Flags().BoolVar(&p.fix, "fix", false, "automatically fix mechanical issues in the advisory data before validating")
 }
 
 func renderValidationError(err error, depth int) string {
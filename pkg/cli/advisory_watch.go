@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory"
+	"github.com/wolfi-dev/wolfictl/pkg/advisory/watch"
+	"github.com/wolfi-dev/wolfictl/pkg/distro"
+	"github.com/wolfi-dev/wolfictl/pkg/scan"
+)
+
+func cmdAdvisoryWatch() *cobra.Command {
+	p := &watchParams{}
+	cmd := &cobra.Command{
+		Use:        "watch",
+		Short:      "Continuously reconcile vulnerability scans of published packages with advisory data",
+		Deprecated: advisoryDeprecationMessage,
+		Long: `Continuously reconcile vulnerability scans of published packages with advisory data.
+
+On each cycle, this command reads the APKINDEX of each architecture from a local
+mirror of the distro's package repository (laid out as <arch>/APKINDEX.tar.gz
+and <arch>/<name>-<version>.apk), and scans the APKs of every package version
+it hasn't scanned before.
+
+For each finding that has no advisory yet, a "detection" event is created. For
+each finding from the previous version's scan that's no longer present, and
+whose advisory is still open, a "fixed" event is proposed with the new version.
+
+All of a cycle's changes are written together. If the advisories repository
+is a git repository, they're recorded in a single commit. With --session, the
+changes are instead committed to a new branch of a fresh clone of the
+advisories repository, which is pushed after each cycle.
+
+What has been scanned so far is kept in a state file, so that the command can be
+stopped and restarted (or run with --once from a scheduler) without rescanning
+or duplicating advisory data.`,
+		Example: `
+# Watch a local mirror, checking for new packages every 30 minutes
+wolfictl adv watch --mirror ./mirror --interval 30m
+
+# Run a single cycle against a specific advisories repository
+wolfictl adv watch --mirror ./mirror -a ./advisories --once`,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if p.mirrorDir == "" {
+				return fmt.Errorf("need --mirror")
+			}
+
+			distroID := p.distroID
+			advisoriesRepoDir := resolveAdvisoriesDirInput(p.advisoriesRepoDir)
+
+			var d distro.Distro
+			needsDistro := distroID == "" ||
+				(!p.session && advisoriesRepoDir == "") ||
+				(p.session && p.advisoriesRemote == "")
+			if needsDistro {
+				if p.doNotDetectDistro {
+					return fmt.Errorf("distro auto-detection is disabled, so --distro-id and either --%s or --advisories-remote must be specified", flagNameAdvisoriesRepoDir)
+				}
+
+				var err error
+				d, err = distro.Detect()
+				if err != nil {
+					return fmt.Errorf("distro auto-detection failed: %w", err)
+				}
+				_, _ = fmt.Fprint(os.Stderr, renderDetectedDistro(d))
+
+				if distroID == "" {
+					distroID = strings.ToLower(d.Absolute.Name)
+				}
+				if advisoriesRepoDir == "" {
+					advisoriesRepoDir = d.Local.AdvisoriesRepo.Dir
+				}
+			}
+
+			var sink watch.Sink
+			if p.session {
+				sessOpts := advisory.DataSessionOptions{
+					Distro:       d,
+					GitHubClient: github.NewClient(nil).WithAuthToken(os.Getenv("GITHUB_TOKEN")),
+				}
+				if p.advisoriesRemote != "" {
+					sessOpts.Remote = advisory.LocalRemote{Path: p.advisoriesRemote}
+				}
+
+				sess, err := advisory.NewDataSession(ctx, sessOpts)
+				if err != nil {
+					return fmt.Errorf("initializing advisory data session: %w", err)
+				}
+				defer sess.Close()
+
+				sink = watch.SessionSink{Session: sess}
+			} else {
+				sink = watch.NewFSSink(advisoriesRepoDir)
+			}
+
+			scanner, err := scan.NewScanner(scan.DefaultOptions)
+			if err != nil {
+				return fmt.Errorf("failed to create vulnerability scanner: %w", err)
+			}
+			defer scanner.Close()
+
+			w, err := watch.New(watch.Options{
+				MirrorDir:  p.mirrorDir,
+				Arches:     p.arches,
+				DistroID:   distroID,
+				Scanner:    scanner,
+				Sink:       sink,
+				StatePath:  p.statePath,
+				Interval:   p.interval,
+				Provenance: advisory.NewProvenance(cmd.CommandPath()),
+			})
+			if err != nil {
+				return err
+			}
+
+			if !p.once {
+				return w.Run(ctx)
+			}
+
+			result, err := w.RunCycle(ctx)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Scanned %d package(s), applied %d advisory request(s)\n", len(result.Scanned), len(result.Requests))
+			for _, req := range result.Requests {
+				fmt.Fprintf(out, "  %s: %s %s\n", req.Package, req.Event.Type, strings.Join(req.VulnerabilityIDs(), ", "))
+			}
+
+			return nil
+		},
+	}
+
+	p.addFlagsTo(cmd)
+	return cmd
+}
+
+type watchParams struct {
+	doNotDetectDistro bool
+	advisoriesRepoDir string
+
+	mirrorDir string
+	arches    []string
+	distroID  string
+	statePath string
+	interval  time.Duration
+	once      bool
+
+	session          bool
+	advisoriesRemote string
+}
+
+func (p *watchParams) addFlagsTo(cmd *cobra.Command) {
+	addNoDistroDetectionFlag(&p.doNotDetectDistro, cmd)
+	addAdvisoriesDirFlag(&p.advisoriesRepoDir, cmd)
+
+	cmd.Flags().StringVar(&p.mirrorDir, "mirror", "", "directory containing a local mirror of the distro's package repository")
+	cmd.Flags().StringSliceVar(&p.arches, "arch", []string{"x86_64", "aarch64"}, "architectures in the mirror to watch")
+	cmd.Flags().StringVar(&p.distroID, "distro-id", "", "distro ID to use when scanning (defaults to the detected distro)")
+	cmd.Flags().StringVar(&p.statePath, "state", "wolfictl-watch-state.json", "file in which to keep track of the packages already scanned")
+	cmd.Flags().DurationVar(&p.interval, "interval", time.Hour, "how long to wait between cycles")
+	cmd.Flags().BoolVar(&p.once, "once", false, "run a single cycle and exit")
+	cmd.Flags().BoolVar(&p.session, "session", false, "commit changes to a new branch of a fresh clone of the advisories repository, and push it after each cycle")
+	cmd.Flags().StringVar(&p.advisoriesRemote, "advisories-remote", "", "with --session, path to a local (e.g. bare) git repository to use instead of the distro's advisories repository on GitHub")
+}
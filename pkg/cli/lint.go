@@ -3,16 +3,34 @@ package cli
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/chainguard-dev/clog"
 	"github.com/spf13/cobra"
+	rwos "github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+	"github.com/wolfi-dev/wolfictl/pkg/git"
 	"github.com/wolfi-dev/wolfictl/pkg/lint"
 )
 
 type lintOptions struct {
-	args      []string
-	list      bool
-	skipRules []string
-	severity  string
+	args         []string
+	list         bool
+	skipRules    []string
+	severity     string
+	output       string
+	fix          bool
+	dryRun       bool
+	config       string
+	cacheDir     string
+	noCache      bool
+	since        string
+	pipelineDirs []string
+	sourceCache  string
 }
 
 func cmdLint() *cobra.Command {
@@ -33,14 +51,30 @@ func cmdLint() *cobra.Command {
 	cmd.Flags().BoolVarP(&o.list, "list", "l", false, "prints the all of available rules and exits")
 	cmd.Flags().StringArrayVarP(&o.skipRules, "skip-rule", "", []string{}, "list of rules to skip")
 	cmd.Flags().StringVarP(&o.severity, "severity", "s", "warning", "minimum severity level to report (error, warning, info)")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "text", "output format (text, json, sarif, github)")
+	cmd.Flags().BoolVar(&o.fix, "fix", false, "automatically fix violations of rules that support it")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "with --fix, print the fixes that would be made without modifying any files")
+	cmd.Flags().StringVar(&o.config, "config", "", fmt.Sprintf("path to the lint configuration file (default: %s in the linted directory, if present)", lint.ConfigFileName))
+
+	cmd.Flags().StringArrayVar(&o.pipelineDirs, "pipeline-dir", nil, "directory used to extend defined built-in pipelines (default: pipelines in the linted directory, if present)")
+	cmd.Flags().StringVar(&o.since, "since", "", "only report problems in configuration files changed since the given git revision (e.g. origin/main)")
+	cmd.Flags().StringVar(&o.cacheDir, "cache-dir", lint.DefaultCacheDir, "directory in which to cache lint results for unchanged files")
+	cmd.Flags().BoolVar(&o.noCache, "no-cache", false, "don't use or update the lint result cache")
+
+	cmd.Flags().StringVar(&o.sourceCache, "source-cache", "", "directory of source artifacts (like melange's source cache) to check fetch digests against")
 
 	cmd.AddCommand(cmdLintYam())
+	cmd.AddCommand(cmdLintFillDigests())
 
 	return cmd
 }
 
 func (o lintOptions) LintCmd(ctx context.Context) error {
-	linter := lint.New(o.makeLintOptions()...)
+	opts, err := o.makeLintOptions()
+	if err != nil {
+		return err
+	}
+	linter := lint.New(opts...)
 
 	// If the list flag is set, print the list of available rules and exit.
 	if o.list {
@@ -56,12 +90,51 @@ func (o lintOptions) LintCmd(ctx context.Context) error {
 	case "info", "INFO":
 		minSeverity = lint.SeverityInfo
 	}
+	var write func(io.Writer, lint.Result) error
+	switch o.output {
+	case "", "text":
+	case "json":
+		write = lint.WriteJSON
+	case "sarif":
+		write = func(w io.Writer, result lint.Result) error {
+			rules, err := linter.Rules()
+			if err != nil {
+				return err
+			}
+			return lint.WriteSARIF(w, result, rules)
+		}
+	case "github":
+		write = lint.WriteGitHub
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: text, json, sarif, github", o.output)
+	}
+
+	if o.dryRun && !o.fix {
+		return errors.New("--dry-run requires --fix")
+	}
+
 	result, err := linter.Lint(ctx, minSeverity)
 	if err != nil {
 		return err
 	}
+
+	if o.fix {
+		result, err = o.fixAndRelint(ctx, linter, result, minSeverity, write != nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if write != nil {
+		// Machine-readable formats are written even when there's nothing to report.
+		if err := write(os.Stdout, result); err != nil {
+			return fmt.Errorf("writing lint results: %w", err)
+		}
+	}
 	if result.HasErrors() {
-		linter.Print(ctx, result)
+		if write == nil {
+			linter.Print(ctx, result)
+		}
 		// only count errors as failures, not warnings.
 		failed := false
 		for _, res := range result {
@@ -79,14 +152,87 @@ func (o lintOptions) LintCmd(ctx context.Context) error {
 	return nil
 }
 
-func (o lintOptions) makeLintOptions() []lint.Option {
+// fixAndRelint applies the fixes for the violations in the result, and returns
+// the result of linting again afterward. In dry-run mode, the fixes are made in
+// memory and printed as diffs, and the original result is returned.
+func (o lintOptions) fixAndRelint(ctx context.Context, linter *lint.Linter, result lint.Result, minSeverity lint.Severity, machineReadable bool) (lint.Result, error) {
+	log := clog.FromContext(ctx)
+
+	dir := linter.Dir()
+	fsys := rwos.DirFS(dir)
+	if o.dryRun {
+		fsys = memfs.New(os.DirFS(dir))
+	}
+
+	fixed, err := linter.Fix(ctx, result, fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep stdout clean for machine-readable output.
+	var w io.Writer = os.Stdout
+	if machineReadable {
+		w = os.Stderr
+	}
+
+	for _, f := range fixed {
+		log.Infof("Fixed %s: %s", filepath.Join(dir, f.Path), strings.Join(f.Rules, ", "))
+		if o.dryRun {
+			if err := printFileDiff(w, os.DirFS(dir), fsys, f.Path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(fixed) == 0 {
+		log.Infof("Nothing to fix")
+	}
+
+	if o.dryRun || len(fixed) == 0 {
+		return result, nil
+	}
+
+	return linter.Lint(ctx, minSeverity)
+}
+
+func (o lintOptions) makeLintOptions() ([]lint.Option, error) {
 	if len(o.args) == 0 {
 		// Lint the current directory by default.
 		o.args = []string{"."}
 	}
 
-	return []lint.Option{
+	var cfg *lint.Config
+	var err error
+	if o.config != "" {
+		cfg, err = lint.ReadConfig(o.config)
+	} else {
+		cfg, err = lint.FindConfig(o.args[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lint configuration: %w", err)
+	}
+
+	opts := []lint.Option{
 		lint.WithPath(o.args[0]),
 		lint.WithSkipRules(o.skipRules),
+		lint.WithConfig(cfg),
+	}
+	if !o.noCache {
+		opts = append(opts, lint.WithCacheDir(o.cacheDir))
 	}
+	if o.sourceCache != "" {
+		opts = append(opts, lint.WithSourceCacheDir(o.sourceCache))
+	}
+	if len(o.pipelineDirs) > 0 {
+		opts = append(opts, lint.WithPipelineDirs(o.pipelineDirs))
+	}
+	if o.since != "" {
+		files, err := git.ChangedFilesSince(o.args[0], o.since)
+		if err != nil {
+			return nil, fmt.Errorf("finding files changed since %s: %w", o.since, err)
+		}
+		// A nil list would mean all files, but nothing has changed.
+		opts = append(opts, lint.WithFiles(append([]string{}, files...)))
+	}
+
+	return opts, nil
 }
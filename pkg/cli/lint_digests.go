@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/wolfi-dev/wolfictl/pkg/lint"
+)
+
+func cmdLintFillDigests() *cobra.Command {
+	var sourceCache string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "fill-digests [path]",
+		Short: "Fill in the expected digests of fetch steps from a local source cache",
+		Long: `Fill in the expected digests of fetch steps from a local source cache.
+
+The source cache is a directory of the artifacts that fetch steps download, like
+melange's source cache. Artifacts are found by the host and path of the fetched
+URI, like "example.com/foo/foo-1.2.3.tar.gz", and their digests are computed
+from their content.
+
+Fetch steps that have no expected digest get an expected-sha256, and expected
+digests that don't match the cached artifact are corrected. Nothing is
+downloaded.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if sourceCache == "" {
+				return errors.New("a source cache directory must be specified with --source-cache")
+			}
+
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			enabled := true
+			linter := lint.New(
+				lint.WithPath(path),
+				lint.WithSourceCacheDir(sourceCache),
+				lint.WithConfig(&lint.Config{
+					DisableAll: true,
+					Rules: map[string]lint.RuleConfig{
+						"valid-pipeline-fetch-digest":       {Enabled: &enabled},
+						"fetch-digest-matches-source-cache": {Enabled: &enabled},
+					},
+				}),
+			)
+
+			result, err := linter.Lint(ctx, lint.SeverityError)
+			if err != nil {
+				return err
+			}
+
+			result, err = lintOptions{dryRun: dryRun}.fixAndRelint(ctx, linter, result, lint.SeverityError, false)
+			if err != nil {
+				return err
+			}
+
+			if !dryRun && result.HasErrors() {
+				linter.Print(ctx, result)
+				return errors.New("some fetch digests could not be filled in from the source cache")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sourceCache, "source-cache", "", "directory of source artifacts to compute digests from")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes that would be made without modifying any files")
+
+	return cmd
+}
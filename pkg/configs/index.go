@@ -157,6 +157,11 @@ func (i *Index[T]) Create(ctx context.Context, filepath string, cfg T) error {
 	return nil
 }
 
+// FS returns the filesystem that contains the Index's configuration files.
+func (i *Index[T]) FS() rwfs.FS {
+	return i.fsys
+}
+
 // Path returns the path to the configuration file for the given name.
 func (i *Index[T]) Path(name string) string {
 	idx, ok := i.byName[name]
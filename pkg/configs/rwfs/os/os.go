@@ -19,6 +19,12 @@ func (fsys FS) Create(name string) (rwfs.File, error) {
 	return os.Create(p)
 }
 
+// MkdirAll creates the named directory, along with any necessary parents.
+func (fsys FS) MkdirAll(name string) error {
+	p := fsys.fullPath(name)
+	return os.MkdirAll(p, DefaultFilePerm)
+}
+
 var _ rwfs.FS = (*FS)(nil)
 
 func (fsys FS) Open(name string) (fs.File, error) {
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ChangedFilesSince returns the absolute paths of the files in the working tree
+// of the git repository that contains the given path whose content differs from their
+// content where HEAD forked from the given revision (such as a branch name, tag
+// or commit hash), that is, at the merge base of HEAD and the revision. Changes
+// made on the revision's side since then aren't included. This includes files
+// that have been added since the merge base, whether or not they've been
+// committed, but not files that have been deleted.
+func ChangedFilesSince(path, rev string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository at %s: %w", path, err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", rev, err)
+	}
+	revCommit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("getting commit for %q: %w", rev, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("getting HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("getting commit for HEAD: %w", err)
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("getting tree for HEAD: %w", err)
+	}
+
+	bases, err := headCommit.MergeBase(revCommit)
+	if err != nil {
+		return nil, fmt.Errorf("finding the merge base of HEAD and %q: %w", rev, err)
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("HEAD and %q have no common ancestor", rev)
+	}
+	baseTree, err := bases[0].Tree()
+	if err != nil {
+		return nil, fmt.Errorf("getting tree for the merge base of HEAD and %q: %w", rev, err)
+	}
+
+	// Files can differ from the merge base either because of commits since the
+	// merge base, or because of uncommitted changes in the working tree.
+	candidates := map[string]struct{}{}
+
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("comparing the merge base of HEAD and %q with HEAD: %w", rev, err)
+	}
+	for _, c := range changes {
+		if c.To.Name != "" {
+			candidates[c.To.Name] = struct{}{}
+		}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("getting worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("getting worktree status: %w", err)
+	}
+	for name, s := range status {
+		if s.Worktree != git.Unmodified || s.Staging != git.Unmodified {
+			candidates[name] = struct{}{}
+		}
+	}
+
+	// A candidate may have been changed back to how it was at the merge base, so
+	// compare the content in the working tree with the content at the merge base.
+	root := wt.Filesystem.Root()
+	var changed []string
+	for name := range candidates {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		b, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		f, err := baseTree.File(name)
+		if err == nil && f.Hash == plumbing.ComputeHash(plumbing.BlobObject, b) {
+			continue
+		}
+		if err != nil && !errors.Is(err, object.ErrFileNotFound) {
+			return nil, fmt.Errorf("looking up %s at the merge base of HEAD and %q: %w", name, rev, err)
+		}
+
+		changed = append(changed, file)
+	}
+
+	sort.Strings(changed)
+	return changed, nil
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFilesSince(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	commit := func() {
+		t.Helper()
+		_, err := w.Add(".")
+		require.NoError(t, err)
+		_, err = w.Commit("test", &git.CommitOptions{
+			Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+		})
+		require.NoError(t, err)
+	}
+
+	write("committed.yaml", "a")
+	write("uncommitted.yaml", "b")
+	write("reverted.yaml", "c")
+	write("deleted.yaml", "d")
+	write("unchanged/unchanged.yaml", "e")
+	commit()
+	base, err := r.Head()
+	require.NoError(t, err)
+	_, err = r.CreateTag("base", base.Hash(), nil)
+	require.NoError(t, err)
+
+	write("committed.yaml", "a2")
+	write("reverted.yaml", "c2")
+	require.NoError(t, os.Remove(filepath.Join(dir, "deleted.yaml")))
+	commit()
+
+	write("uncommitted.yaml", "b2")
+	write("reverted.yaml", "c")
+	write("new/new.yaml", "f")
+
+	for _, path := range []string{dir, filepath.Join(dir, "unchanged"), filepath.Join(dir, "committed.yaml")} {
+		got, err := ChangedFilesSince(path, "base")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "committed.yaml"),
+			filepath.Join(dir, "new", "new.yaml"),
+			filepath.Join(dir, "uncommitted.yaml"),
+		}, got)
+	}
+
+	got, err := ChangedFilesSince(dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "new", "new.yaml"),
+		filepath.Join(dir, "reverted.yaml"),
+		filepath.Join(dir, "uncommitted.yaml"),
+	}, got)
+
+	_, err = ChangedFilesSince(dir, "no-such-ref")
+	assert.Error(t, err)
+}
+
+func TestChangedFilesSince_MergeBase(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	commit := func() {
+		t.Helper()
+		_, err := w.Add(".")
+		require.NoError(t, err)
+		_, err = w.Commit("test", &git.CommitOptions{
+			Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()},
+		})
+		require.NoError(t, err)
+	}
+
+	write("ours.yaml", "a")
+	write("theirs.yaml", "b")
+	commit()
+	fork, err := r.Head()
+	require.NoError(t, err)
+
+	// The target branch moves on after the fork.
+	write("theirs.yaml", "b2")
+	commit()
+	target, err := r.Head()
+	require.NoError(t, err)
+	require.NoError(t, r.Storer.SetReference(plumbing.NewHashReference("refs/heads/target", target.Hash())))
+
+	require.NoError(t, w.Checkout(&git.CheckoutOptions{
+		Hash:   fork.Hash(),
+		Branch: "refs/heads/feature",
+		Create: true,
+	}))
+	write("ours.yaml", "a2")
+	commit()
+
+	// Only the changes made on this branch are reported, not the ones made on
+	// the target branch since the fork.
+	got, err := ChangedFilesSince(dir, "target")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "ours.yaml")}, got)
+}
@@ -0,0 +1,150 @@
+// Package goreachability determines whether the code affected by a Go
+// vulnerability is present in Go binaries, similar to the binary mode of
+// govulncheck. It compares the vulnerable symbols listed in a local snapshot of
+// the Go vulnerability database against the functions found in each binary's
+// pclntab and symbol table.
+package goreachability
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoSymbolInformation is returned when a vulnerability database entry
+// doesn't identify the affected packages of every affected module, and so
+// reachability can't be determined.
+var ErrNoSymbolInformation = errors.New("vulnerability entry has no affected package or symbol information")
+
+// Result is the outcome of analyzing a set of binaries for a single
+// vulnerability.
+type Result struct {
+	// Entry is the vulnerability database entry that was analyzed.
+	Entry *Entry
+
+	// Binaries holds the result for each binary analyzed.
+	Binaries []BinaryResult
+}
+
+// BinaryResult is the outcome of analyzing a single binary.
+type BinaryResult struct {
+	Binary *Binary
+
+	// Checked lists the qualified symbols (or package paths, for affected
+	// imports that don't list symbols) that were looked for.
+	Checked []string
+
+	// Found lists the entries from Checked that are present in the binary.
+	Found []string
+}
+
+// Present reports whether any vulnerable code was found in any binary.
+func (r Result) Present() bool {
+	for _, br := range r.Binaries {
+		if len(br.Found) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Analyze determines which of the vulnerable symbols described by the entry
+// are present in each of the given binaries.
+func Analyze(entry *Entry, binaries []*Binary) (*Result, error) {
+	// An affected module without affected imports is vulnerable as a whole, so
+	// leaving it out would make the vulnerable code look absent.
+	for _, a := range entry.Affected {
+		if len(a.EcosystemSpecific.Imports) == 0 {
+			return nil, fmt.Errorf("%w: %s (module %s)", ErrNoSymbolInformation, entry.ID, a.Module.Path)
+		}
+	}
+	imports := entry.Imports()
+	if len(imports) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoSymbolInformation, entry.ID)
+	}
+
+	result := &Result{Entry: entry}
+
+	for _, b := range binaries {
+		br := BinaryResult{Binary: b}
+
+		for _, imp := range imports {
+			if len(imp.Symbols) == 0 {
+				// The whole package is considered vulnerable.
+				br.Checked = append(br.Checked, imp.Path)
+				if b.hasPackage(imp.Path) {
+					br.Found = append(br.Found, imp.Path)
+				}
+				continue
+			}
+
+			for _, s := range imp.qualifiedSymbols() {
+				br.Checked = append(br.Checked, s)
+				if b.hasFunction(s) {
+					br.Found = append(br.Found, s)
+				}
+			}
+		}
+
+		sort.Strings(br.Checked)
+		sort.Strings(br.Found)
+
+		result.Binaries = append(result.Binaries, br)
+	}
+
+	return result, nil
+}
+
+// Summary returns a human-readable explanation of the result, suitable as
+// evidence in an advisory note.
+func (r Result) Summary() string {
+	if len(r.Binaries) == 0 {
+		return fmt.Sprintf("No Go binaries were found to analyze for %s.", r.Entry.ID)
+	}
+
+	var checked []string
+	seen := make(map[string]struct{})
+	for _, br := range r.Binaries {
+		for _, c := range br.Checked {
+			if _, ok := seen[c]; !ok {
+				seen[c] = struct{}{}
+				checked = append(checked, c)
+			}
+		}
+	}
+
+	binaryDescriptions := make([]string, 0, len(r.Binaries))
+	for _, br := range r.Binaries {
+		sources := make([]string, 0, len(br.Binary.Sources))
+		for _, s := range br.Binary.Sources {
+			sources = append(sources, string(s))
+		}
+
+		desc := fmt.Sprintf("%s (%s", br.Binary.Path, br.Binary.GoVersion)
+		if br.Binary.MainModule != "" {
+			desc += ", " + br.Binary.MainModule
+		}
+		desc += "; " + strings.Join(sources, ", ") + ")"
+
+		if len(br.Found) > 0 {
+			desc += ": found " + strings.Join(br.Found, ", ")
+		}
+
+		binaryDescriptions = append(binaryDescriptions, desc)
+	}
+
+	verdict := "none of which were found in"
+	if r.Present() {
+		verdict = "some of which were found in"
+	}
+
+	return fmt.Sprintf(
+		"%s affects %s, %s the Go binaries in this package: %s.",
+		r.Entry.ID,
+		strings.Join(checked, ", "),
+		verdict,
+		strings.Join(binaryDescriptions, "; "),
+	)
+}
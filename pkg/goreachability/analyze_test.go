@@ -0,0 +1,165 @@
+package goreachability
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeFunctionName(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{
+			name:     "net/http.(*Server).Serve",
+			expected: "net/http.Server.Serve",
+		},
+		{
+			name:     "vendor/golang.org/x/net/http2.(*Framer).ReadFrame",
+			expected: "golang.org/x/net/http2.Framer.ReadFrame",
+		},
+		{
+			name:     "example.com/m/vendor/golang.org/x/text/unicode/norm.Form.String",
+			expected: "golang.org/x/text/unicode/norm.Form.String",
+		},
+		{
+			name:     "example.com/m.Map[...]",
+			expected: "example.com/m.Map",
+		},
+		{
+			name:     "example.com/m.(*Set[go.shape.string]).Add",
+			expected: "example.com/m.Set.Add",
+		},
+		{
+			name:     "example.com/vendorized.Parse",
+			expected: "example.com/vendorized.Parse",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeFunctionName(tt.name); got != tt.expected {
+				t.Errorf("normalizeFunctionName(%q) = %q, want %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSnapshot_Get(t *testing.T) {
+	s, err := NewSnapshot(os.DirFS("testdata/vulndb"))
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+
+	for _, id := range []string{"GO-2099-0001", "CVE-2099-0001", "GHSA-aaaa-bbbb-cccc"} {
+		t.Run(id, func(t *testing.T) {
+			entry, err := s.Get(id)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if entry.ID != "GO-2099-0001" {
+				t.Errorf("Get() returned entry %q, want %q", entry.ID, "GO-2099-0001")
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.Get("CVE-2099-9999")
+		if !errors.Is(err, ErrVulnerabilityNotFound) {
+			t.Errorf("Get() error = %v, want %v", err, ErrVulnerabilityNotFound)
+		}
+	})
+}
+
+func TestAnalyze(t *testing.T) {
+	// The test binary itself is a convenient Go binary to analyze, since we know
+	// which of this package's functions it contains.
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("unable to locate test binary: %v", err)
+	}
+
+	f, err := os.Open(exe)
+	if err != nil {
+		t.Fatalf("opening test binary: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := elf.NewFile(f); err != nil {
+		t.Skipf("test binary is not an ELF file: %v", err)
+	}
+
+	b, err := ReadBinary("wolfictl.test", f)
+	if err != nil {
+		t.Fatalf("ReadBinary() error = %v", err)
+	}
+
+	s, err := NewSnapshot(os.DirFS("testdata/vulndb"))
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+
+	cases := []struct {
+		id            string
+		expectedFound []string
+		expectedErr   error
+	}{
+		{
+			id: "CVE-2099-0001",
+			expectedFound: []string{
+				"github.com/wolfi-dev/wolfictl/pkg/goreachability.Analyze",
+				"github.com/wolfi-dev/wolfictl/pkg/goreachability.Binary.Functions",
+			},
+		},
+		{
+			id:            "CVE-2099-0002",
+			expectedFound: nil,
+		},
+		{
+			id:          "GO-2099-0003",
+			expectedErr: ErrNoSymbolInformation,
+		},
+		{
+			// One of the affected modules has symbol information, but the other
+			// doesn't, so the absence of the first one's symbols isn't conclusive.
+			id:          "GO-2099-0004",
+			expectedErr: ErrNoSymbolInformation,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.id, func(t *testing.T) {
+			entry, err := s.Get(tt.id)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			result, err := Analyze(entry, []*Binary{b})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Analyze() error = %v, want %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.expectedFound, result.Binaries[0].Found); diff != "" {
+				t.Errorf("unexpected found symbols (-want +got):\n%s", diff)
+			}
+
+			if present := len(tt.expectedFound) > 0; result.Present() != present {
+				t.Errorf("Present() = %t, want %t", result.Present(), present)
+			}
+		})
+	}
+
+	t.Run("functions", func(t *testing.T) {
+		if len(b.Functions()) == 0 {
+			t.Error("expected the test binary to contain functions")
+		}
+	})
+}
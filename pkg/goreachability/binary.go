@@ -0,0 +1,243 @@
+package goreachability
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/gosym"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SymbolSource identifies where a binary's function names were read from.
+type SymbolSource string
+
+const (
+	// SymbolSourcePclntab means function names were read from the Go runtime's
+	// pclntab, which is present even in stripped binaries.
+	SymbolSourcePclntab SymbolSource = "pclntab"
+
+	// SymbolSourceSymtab means function names were read from the ELF symbol table.
+	SymbolSourceSymtab SymbolSource = "symtab"
+)
+
+// Binary is a Go executable, along with the function names it contains.
+type Binary struct {
+	// Path is the binary's path (e.g. within an APK).
+	Path string
+
+	// GoVersion is the version of Go used to build the binary.
+	GoVersion string
+
+	// MainModule is the path and version of the binary's main module.
+	MainModule string
+
+	// Sources lists where the binary's function names were read from.
+	Sources []SymbolSource
+
+	// functions is the set of normalized, fully qualified function names in the
+	// binary (e.g. "net/http.Server.Serve").
+	functions map[string]struct{}
+}
+
+// ReadBinary reads the Go binary at the given reader. It returns an error if
+// the content isn't an ELF file built by Go.
+func ReadBinary(p string, r io.ReaderAt) (*Binary, error) {
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading Go build info for %s: %w", p, err)
+	}
+
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ELF file %s: %w", p, err)
+	}
+	defer f.Close()
+
+	b := &Binary{
+		Path:       p,
+		GoVersion:  info.GoVersion,
+		MainModule: strings.TrimSpace(info.Main.Path + " " + info.Main.Version),
+		functions:  make(map[string]struct{}),
+	}
+
+	if names, err := pclntabFunctions(f); err == nil {
+		b.addFunctions(names)
+		b.Sources = append(b.Sources, SymbolSourcePclntab)
+	}
+
+	if names, err := symtabFunctions(f); err == nil {
+		b.addFunctions(names)
+		b.Sources = append(b.Sources, SymbolSourceSymtab)
+	}
+
+	if len(b.Sources) == 0 {
+		return nil, fmt.Errorf("no pclntab or symbol table found in %s", p)
+	}
+
+	return b, nil
+}
+
+func (b *Binary) addFunctions(names []string) {
+	for _, n := range names {
+		b.functions[normalizeFunctionName(n)] = struct{}{}
+	}
+}
+
+func pclntabFunctions(f *elf.File) ([]string, error) {
+	pclntab := f.Section(".gopclntab")
+	text := f.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, errors.New("no pclntab")
+	}
+
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(data, text.Addr))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(table.Funcs))
+	for i := range table.Funcs {
+		names = append(names, table.Funcs[i].Name)
+	}
+
+	return names, nil
+}
+
+func symtabFunctions(f *elf.File) ([]string, error) {
+	symbols, err := f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, s := range symbols {
+		if elf.ST_TYPE(s.Info) == elf.STT_FUNC {
+			names = append(names, s.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// normalizeFunctionName converts a function name as found in a Go binary to the
+// form used by the Go vulnerability database, qualified by its package path.
+// For example, "vendor/golang.org/x/net/http2.(*Framer).ReadFrame" becomes
+// "golang.org/x/net/http2.Framer.ReadFrame", and "example.com/m.Map[...]"
+// becomes "example.com/m.Map".
+func normalizeFunctionName(name string) string {
+	if i := strings.LastIndex(name, "vendor/"); i == 0 || (i > 0 && name[i-1] == '/') {
+		name = name[i+len("vendor/"):]
+	}
+
+	// Remove type parameters.
+	if i := strings.Index(name, "["); i != -1 {
+		if j := strings.LastIndex(name, "]"); j > i {
+			name = name[:i] + name[j+1:]
+		}
+	}
+
+	name = strings.ReplaceAll(name, "(*", "")
+	name = strings.ReplaceAll(name, ")", "")
+
+	return name
+}
+
+// Functions returns the binary's normalized function names, sorted.
+func (b *Binary) Functions() []string {
+	names := make([]string, 0, len(b.functions))
+	for n := range b.functions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasFunction reports whether the binary contains the given function, or a
+// closure defined within it.
+func (b *Binary) hasFunction(qualifiedSymbol string) bool {
+	if _, ok := b.functions[qualifiedSymbol]; ok {
+		return true
+	}
+
+	prefix := qualifiedSymbol + ".func"
+	for f := range b.functions {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPackage reports whether the binary contains any function from the given
+// package.
+func (b *Binary) hasPackage(importPath string) bool {
+	prefix := importPath + "."
+	for f := range b.functions {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BinariesFromAPK reads all Go binaries from the given APK file. Files that
+// aren't Go binaries are ignored.
+func BinariesFromAPK(apk io.Reader) ([]*Binary, error) {
+	// An APK is a concatenation of gzipped tar streams, which the gzip reader
+	// handles as a single stream by default.
+	zr, err := gzip.NewReader(apk)
+	if err != nil {
+		return nil, fmt.Errorf("opening APK: %w", err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+
+	var binaries []*Binary
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading APK: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || hdr.Size < int64(len(elf.ELFMAG)) {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from APK: %w", hdr.Name, err)
+		}
+
+		if !bytes.HasPrefix(data, []byte(elf.ELFMAG)) {
+			continue
+		}
+
+		b, err := ReadBinary(hdr.Name, bytes.NewReader(data))
+		if err != nil {
+			// Not a Go binary (or not one we can read), so there's nothing to analyze.
+			continue
+		}
+
+		binaries = append(binaries, b)
+	}
+
+	return binaries, nil
+}
@@ -0,0 +1,121 @@
+package goreachability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ErrVulnerabilityNotFound is returned when a vulnerability can't be found in
+// the vulndb snapshot.
+var ErrVulnerabilityNotFound = errors.New("vulnerability not found in vulndb snapshot")
+
+// Entry is a Go vulnerability database entry, in the OSV format used by
+// https://vuln.go.dev. Only the fields needed for reachability analysis are
+// included.
+type Entry struct {
+	ID       string     `json:"id"`
+	Aliases  []string   `json:"aliases,omitempty"`
+	Affected []Affected `json:"affected"`
+}
+
+// Affected describes a Go module affected by the vulnerability.
+type Affected struct {
+	Module struct {
+		Path string `json:"name"`
+	} `json:"package"`
+
+	EcosystemSpecific struct {
+		Imports []AffectedImport `json:"imports,omitempty"`
+	} `json:"ecosystem_specific"`
+}
+
+// AffectedImport is a package that contains vulnerable code, along with the
+// vulnerable symbols within that package. Symbols are given as either a function
+// name (e.g. "Parse") or a method name qualified by its receiver type (e.g.
+// "Reader.Read").
+type AffectedImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// Imports returns all of the entry's affected imports, across all affected
+// modules.
+func (e Entry) Imports() []AffectedImport {
+	var imports []AffectedImport
+	for _, a := range e.Affected {
+		imports = append(imports, a.EcosystemSpecific.Imports...)
+	}
+	return imports
+}
+
+// Snapshot is a local copy of the Go vulnerability database, laid out as it is
+// served by https://vuln.go.dev (i.e. with an "index/vulns.json" file and an
+// "ID" directory containing one JSON file per vulnerability).
+type Snapshot struct {
+	fsys  fs.FS
+	index []snapshotIndexEntry
+}
+
+type snapshotIndexEntry struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// NewSnapshot returns a Snapshot for the vulndb files in the given filesystem.
+func NewSnapshot(fsys fs.FS) (*Snapshot, error) {
+	b, err := fs.ReadFile(fsys, path.Join("index", "vulns.json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading vulndb index: %w", err)
+	}
+
+	s := &Snapshot{fsys: fsys}
+	if err := json.Unmarshal(b, &s.index); err != nil {
+		return nil, fmt.Errorf("parsing vulndb index: %w", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the entry for the given vulnerability ID. The ID can be a Go
+// vulnerability ID (e.g. "GO-2023-1234") or any of its aliases (e.g. a CVE or
+// GHSA ID).
+func (s *Snapshot) Get(id string) (*Entry, error) {
+	goID := ""
+	for _, e := range s.index {
+		if e.ID == id || slices.Contains(e.Aliases, id) {
+			goID = e.ID
+			break
+		}
+	}
+
+	if goID == "" {
+		return nil, fmt.Errorf("%w: %s", ErrVulnerabilityNotFound, id)
+	}
+
+	b, err := fs.ReadFile(s.fsys, path.Join("ID", goID+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading vulndb entry %s: %w", goID, err)
+	}
+
+	entry := new(Entry)
+	if err := json.Unmarshal(b, entry); err != nil {
+		return nil, fmt.Errorf("parsing vulndb entry %s: %w", goID, err)
+	}
+
+	return entry, nil
+}
+
+// qualifiedSymbols returns the import's symbols, each qualified by the import
+// path (e.g. "net/http.Server.Serve").
+func (i AffectedImport) qualifiedSymbols() []string {
+	symbols := make([]string, 0, len(i.Symbols))
+	for _, s := range i.Symbols {
+		symbols = append(symbols, i.Path+"."+strings.TrimSpace(s))
+	}
+	return symbols
+}
@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesVersion is the version of the rule set. Cached lint results are only
+// used by the same version of the rules, so it must be incremented whenever a
+// change to a rule could change the result of linting a configuration file.
+const RulesVersion = "1"
+
+// DefaultCacheDir is the default directory in which lint results are cached.
+var DefaultCacheDir = path.Join(xdg.CacheHome, "wolfictl", "lint")
+
+// cachedViolation is the cached form of a violation of a single-configuration
+// rule.
+type cachedViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Range   *Range `json:"range,omitempty"`
+}
+
+// resultCache caches the violations of the single-configuration rules found in
+// configuration files, keyed by the files' content.
+type resultCache struct {
+	dir string
+
+	// fingerprint identifies the rules and their configuration, so that changing
+	// either invalidates the cached results.
+	fingerprint string
+}
+
+// newResultCache returns a cache in the given directory for the results of the
+// given rules, as configured by cfg. The environment identifies anything else
+// that the results depend on, such as the pipelines that configurations can
+// use.
+func newResultCache(dir string, rules Rules, cfg *Config, environment string) (*resultCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating lint cache directory: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "rules-version=%s\n", RulesVersion)
+	fmt.Fprintf(h, "environment=%s\n", environment)
+	for _, rule := range rules {
+		if rule.LintFunc != nil {
+			fmt.Fprintf(h, "rule=%s severity=%s\n", rule.Name, rule.Severity.Name)
+		}
+	}
+	if cfg != nil {
+		b, err := yaml.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("encoding lint configuration: %w", err)
+		}
+		h.Write(b)
+	}
+
+	return &resultCache{
+		dir:         dir,
+		fingerprint: fmt.Sprintf("%x", h.Sum(nil)),
+	}, nil
+}
+
+// file returns the path of the cache file for the input.
+func (c *resultCache) file(in Input) string {
+	key := sha256.Sum256([]byte(c.fingerprint + "\n" + in.Path + "\n" + in.hash))
+	return filepath.Join(c.dir, fmt.Sprintf("%x.json", key))
+}
+
+// get returns the cached violations of the input, keyed by rule name, and
+// whether there were any cached results.
+func (c *resultCache) get(in Input) (map[string]error, bool) {
+	if in.hash == "" {
+		return nil, false
+	}
+
+	b, err := os.ReadFile(c.file(in))
+	if err != nil {
+		return nil, false
+	}
+
+	var cached []cachedViolation
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return nil, false
+	}
+
+	violations := make(map[string]error, len(cached))
+	for _, v := range cached {
+		err := errors.New(v.Message)
+		if v.Range != nil {
+			err = &Violation{Range: *v.Range, Err: err}
+		}
+		violations[v.Rule] = err
+	}
+
+	return violations, true
+}
+
+// put caches the violations of the input, keyed by rule name.
+func (c *resultCache) put(in Input, violations map[string]error) error {
+	if in.hash == "" {
+		return nil
+	}
+
+	cached := make([]cachedViolation, 0, len(violations))
+	for rule, err := range violations {
+		cached = append(cached, cachedViolation{
+			Rule:    rule,
+			Message: err.Error(),
+			Range:   rangeOf(err),
+		})
+	}
+
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that concurrent runs never see a
+	// partially written entry.
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.file(in))
+}
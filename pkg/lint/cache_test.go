@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinter_Cache(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+	src, err := os.ReadFile("testdata/files/bad-version.yaml")
+	require.NoError(t, err)
+	file := filepath.Join(dir, "bad-version.yaml")
+	require.NoError(t, os.WriteFile(file, src, 0o600))
+
+	lintRules := func(opts ...Option) []string {
+		t.Helper()
+		l := New(append([]Option{WithPath(file), WithCacheDir(cacheDir)}, opts...)...)
+		result, err := l.Lint(ctx, SeverityInfo)
+		require.NoError(t, err)
+
+		var rules []string
+		for _, res := range result {
+			for _, e := range res.Errors {
+				rules = append(rules, e.Rule.Name)
+			}
+		}
+		return rules
+	}
+
+	want := lintRules()
+	require.Contains(t, want, "bad-version")
+
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Replace the cached result with an empty one, so that using the cache is
+	// observable.
+	require.NoError(t, os.WriteFile(entries[0], []byte("[]"), 0o600))
+	assert.Empty(t, lintRules(), "unchanged file should use the cached result")
+
+	// Rule configuration is part of the cache key.
+	cfg := &Config{Rules: map[string]RuleConfig{"bad-version": {Severity: "warning"}}}
+	assert.Equal(t, want, lintRules(WithConfig(cfg)), "changed rule configuration should invalidate the cache")
+
+	// So is the file's content.
+	require.NoError(t, os.WriteFile(file, append(src, []byte("\n# changed\n")...), 0o600))
+	assert.Equal(t, want, lintRules(), "changed file should invalidate the cache")
+	assert.Equal(t, want, lintRules(), "cached result should match the evaluated one")
+}
+
+func TestLinter_Deterministic(t *testing.T) {
+	ctx := context.Background()
+
+	want, err := newTestLinterWithDir("dirs/repository/").Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	require.NotEmpty(t, want)
+
+	for i := 0; i < 5; i++ {
+		got, err := newTestLinterWithDir("dirs/repository/").Lint(ctx, SeverityInfo)
+		require.NoError(t, err)
+		require.Len(t, got, len(want))
+		for j := range want {
+			assert.Equal(t, want[j].File, got[j].File)
+			assert.Equal(t, want[j].Errors.WrapErrors().Error(), got[j].Errors.WrapErrors().Error())
+		}
+	}
+}
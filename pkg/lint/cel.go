@@ -0,0 +1,148 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"gopkg.in/yaml.v3"
+)
+
+// CustomRulesDirName is the name of the directory, alongside the linter's
+// configuration file, from whose YAML files custom rules are read.
+const CustomRulesDirName = ".wolfictl-lint.d"
+
+// CustomRulesFile is a file that defines custom rules.
+type CustomRulesFile struct {
+	Rules []CustomRule `yaml:"rules"`
+}
+
+// CustomRule is a rule defined by a CEL expression rather than Go code. The
+// expression is evaluated with the variable "config" set to the configuration
+// file's content as a map, and the configuration violates the rule if the
+// expression evaluates to false.
+//
+// For example, this rule requires packages to set package.options.no-provides:
+//
+//	name: no-provides-required
+//	description: packages must not provide anything
+//	severity: error
+//	expression: has(config.package.options) && config.package.options["no-provides"] == true
+//
+// Custom rules can be configured like any other rule, for instance to restrict
+// them to some paths.
+type CustomRule struct {
+	// Name is the name of the rule. It must not be the name of a built-in rule.
+	Name string `yaml:"name"`
+
+	// Description describes the rule.
+	Description string `yaml:"description"`
+
+	// Severity is the rule's severity ("error", "warning" or "info").
+	Severity string `yaml:"severity"`
+
+	// Expression is the CEL expression, which must evaluate to a bool.
+	Expression string `yaml:"expression"`
+
+	// Message is reported when the rule is violated. If not set, the description
+	// is reported.
+	Message string `yaml:"message,omitempty"`
+}
+
+// readCustomRules reads the custom rules from the YAML files in the given
+// directory, in order of file name. It returns nil if there's no such
+// directory.
+func readCustomRules(dir string) ([]CustomRule, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var rules []CustomRule
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var f CustomRulesFile
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("decoding custom rules %s: %w", file, err)
+		}
+		rules = append(rules, f.Rules...)
+	}
+
+	return rules, nil
+}
+
+// compile returns the built-in rule that evaluates the custom rule.
+func (r CustomRule) compile() (Rule, error) {
+	if r.Name == "" {
+		return Rule{}, errors.New("custom rule has no name")
+	}
+
+	severity, err := parseSeverity(r.Severity)
+	if err != nil {
+		return Rule{}, fmt.Errorf("custom rule %s: %w", r.Name, err)
+	}
+
+	env, err := cel.NewEnv(cel.Variable("config", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return Rule{}, err
+	}
+
+	ast, iss := env.Compile(r.Expression)
+	if iss.Err() != nil {
+		return Rule{}, fmt.Errorf("custom rule %s: compiling expression: %w", r.Name, iss.Err())
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return Rule{}, fmt.Errorf("custom rule %s: expression must evaluate to a bool, not %s", r.Name, ast.OutputType())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return Rule{}, fmt.Errorf("custom rule %s: %w", r.Name, err)
+	}
+
+	message := r.Message
+	if message == "" {
+		message = r.Description
+	}
+
+	return Rule{
+		Name:        r.Name,
+		Description: r.Description,
+		Severity:    severity,
+		LintFunc: func(in Input) error {
+			cfg := map[string]any{}
+			if in.AST != nil {
+				if err := in.AST.Decode(&cfg); err != nil {
+					return fmt.Errorf("decoding configuration: %w", err)
+				}
+			}
+
+			out, _, err := prg.Eval(map[string]any{"config": cfg})
+			if err != nil {
+				return violation(in.NodeAt("package"), "evaluating expression: %v", err)
+			}
+
+			ok, isBool := out.Value().(bool)
+			if !isBool {
+				return violation(in.NodeAt("package"), "expression evaluated to %v, not a bool", out.Value())
+			}
+			if !ok {
+				return violation(in.NodeAt("package"), "%s", strings.TrimSpace(message))
+			}
+			return nil
+		},
+	}, nil
+}
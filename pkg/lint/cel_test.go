@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomRules(t *testing.T) {
+	ctx := context.Background()
+	dir := "testdata/dirs/custom-rules"
+
+	cfg, err := FindConfig(dir)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Len(t, cfg.CustomRules, 2)
+
+	l := New(WithPath(dir), WithConfig(cfg))
+	result, err := l.Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+
+	got := map[string][]string{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			if e.Rule.Name == "no-provides-required" || e.Rule.Name == "description-required" {
+				got[res.File] = append(got[res.File], e.Rule.Name+": "+e.Message)
+			}
+		}
+	}
+	assert.Empty(t, got["provides-nothing"])
+	assert.Contains(t, got["provides-something"], "no-provides-required: this package must set package.options.no-provides")
+	assert.Contains(t, got["provides-something"], "description-required: packages must have a description")
+
+	rules, err := l.Rules()
+	require.NoError(t, err)
+	var custom Rule
+	for _, r := range rules {
+		if r.Name == "description-required" {
+			custom = r
+		}
+	}
+	assert.Equal(t, SeverityWarning, custom.Severity)
+
+	t.Run("configured like built-in rules", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+custom-rules:
+  - name: no-provides-required
+    severity: info
+    expression: has(config.package.options)
+rules:
+  no-provides-required:
+    exclude-paths:
+      - provides-something.yaml
+`)
+		l := New(WithPath(dir), WithConfig(cfg))
+		result, err := l.Lint(ctx, SeverityInfo)
+		require.NoError(t, err)
+		for _, res := range result {
+			for _, e := range res.Errors {
+				assert.NotEqual(t, "no-provides-required", e.Rule.Name)
+			}
+		}
+	})
+}
+
+func TestCustomRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "syntax error",
+			content: `
+custom-rules:
+  - name: broken
+    severity: error
+    expression: config.package.(
+`,
+		},
+		{
+			name: "not a bool",
+			content: `
+custom-rules:
+  - name: not-a-bool
+    severity: error
+    expression: config.package.name + "x"
+`,
+		},
+		{
+			name: "built-in name",
+			content: `
+custom-rules:
+  - name: bad-version
+    severity: error
+    expression: "true"
+`,
+		},
+		{
+			name: "unknown severity",
+			content: `
+custom-rules:
+  - name: custom
+    severity: fatal
+    expression: "true"
+`,
+		},
+		{
+			name: "no name",
+			content: `
+custom-rules:
+  - severity: error
+    expression: "true"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := readTestConfig(t, tt.content)
+			_, err := New(WithPath("testdata/files/bad-version.yaml"), WithConfig(cfg)).Rules()
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, CustomRulesDirName), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, CustomRulesDirName, "rules.yaml"), []byte("rules:\n  - nme: typo\n"), 0o600))
+		_, err := FindConfig(dir)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,223 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the file, at the root of the directory being
+// linted, from which the linter's configuration is read.
+const ConfigFileName = ".wolfictl-lint.yaml"
+
+// Config is the configuration of the linter. It lets a repository set its own
+// policy without changing the set of available rules.
+type Config struct {
+	// DisableAll disables every rule that isn't explicitly enabled in Rules.
+	DisableAll bool `yaml:"disable-all,omitempty"`
+
+	// Rules configures individual rules, keyed by rule name.
+	Rules map[string]RuleConfig `yaml:"rules,omitempty"`
+
+	// CustomRules are rules defined by CEL expressions, in addition to the
+	// built-in rules. ReadConfig adds the custom rules defined in the
+	// CustomRulesDirName directory to those defined in the configuration file.
+	CustomRules []CustomRule `yaml:"custom-rules,omitempty"`
+}
+
+// RuleConfig configures a single rule.
+type RuleConfig struct {
+	// Enabled enables or disables the rule. If not set, the rule is enabled
+	// unless the configuration's DisableAll is set.
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	// Severity overrides the rule's severity ("error", "warning" or "info").
+	Severity string `yaml:"severity,omitempty"`
+
+	// Paths are glob patterns of the configuration files the rule applies to,
+	// relative to the directory being linted. As in path.Match, "*" doesn't
+	// match "/", but "**" matches any number of directories. If not set, the
+	// rule applies to all configuration files.
+	Paths []string `yaml:"paths,omitempty"`
+
+	// ExcludePaths are glob patterns of configuration files the rule doesn't
+	// apply to, even if they match Paths.
+	ExcludePaths []string `yaml:"exclude-paths,omitempty"`
+
+	// With sets the rule's parameters, for rules that have them.
+	With yaml.Node `yaml:"with,omitempty"`
+}
+
+// ReadConfig reads the linter's configuration from the file at the given path.
+func ReadConfig(file string) (*Config, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding lint configuration %s: %w", file, err)
+	}
+
+	custom, err := readCustomRules(filepath.Join(filepath.Dir(file), CustomRulesDirName))
+	if err != nil {
+		return nil, err
+	}
+	cfg.CustomRules = append(cfg.CustomRules, custom...)
+
+	return cfg, nil
+}
+
+// FindConfig reads the linter's configuration from the ConfigFileName file in
+// the directory to be linted (or the directory of the file to be linted). If
+// there's no such file, FindConfig returns nil, unless the directory has custom
+// rules (see CustomRulesDirName).
+func FindConfig(lintPath string) (*Config, error) {
+	dir := dirOf(lintPath)
+	cfg, err := ReadConfig(filepath.Join(dir, ConfigFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		custom, err := readCustomRules(filepath.Join(dir, CustomRulesDirName))
+		if err != nil || len(custom) == 0 {
+			return nil, err
+		}
+		return &Config{CustomRules: custom}, nil
+	}
+
+	return cfg, err
+}
+
+// dirOf returns the directory that contains the configuration files at the
+// given path to be linted.
+func dirOf(lintPath string) string {
+	fi, err := os.Stat(lintPath)
+	if err == nil && !fi.IsDir() {
+		return filepath.Dir(lintPath)
+	}
+
+	return lintPath
+}
+
+// parseSeverity returns the severity with the given name.
+func parseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if strings.EqualFold(name, s.Name) {
+			return s, nil
+		}
+	}
+
+	return Severity{}, fmt.Errorf("unknown severity %q, must be one of: error, warning, info", name)
+}
+
+// apply returns the rules as configured, with the custom rules added and
+// disabled rules left out.
+func (c *Config) apply(rules Rules) (Rules, error) {
+	if c == nil {
+		return rules, nil
+	}
+
+	for _, cr := range c.CustomRules {
+		if slices.ContainsFunc(rules, func(r Rule) bool { return r.Name == cr.Name }) {
+			return nil, fmt.Errorf("custom rule %s has the same name as another rule", cr.Name)
+		}
+		rule, err := cr.compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	for name := range c.Rules {
+		if !slices.ContainsFunc(rules, func(r Rule) bool { return r.Name == name }) {
+			return nil, fmt.Errorf("lint configuration refers to unknown rule %q", name)
+		}
+	}
+
+	configured := make(Rules, 0, len(rules))
+	for _, rule := range rules {
+		rc, ok := c.Rules[rule.Name]
+		enabled := !c.DisableAll
+		if ok && rc.Enabled != nil {
+			enabled = *rc.Enabled
+		}
+		if !enabled {
+			continue
+		}
+
+		if rc.Severity != "" {
+			s, err := parseSeverity(rc.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("configuring rule %s: %w", rule.Name, err)
+			}
+			rule.Severity = s
+		}
+
+		for _, pattern := range append(append([]string{}, rc.Paths...), rc.ExcludePaths...) {
+			if !doublestar.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("configuring rule %s: invalid path pattern %q: %w", rule.Name, pattern, doublestar.ErrBadPattern)
+			}
+		}
+
+		if !rc.With.IsZero() {
+			if rule.Parameters == nil {
+				return nil, fmt.Errorf("configuring rule %s: rule has no parameters", rule.Name)
+			}
+			if err := decodeStrict(&rc.With, rule.Parameters); err != nil {
+				return nil, fmt.Errorf("configuring rule %s: %w", rule.Name, err)
+			}
+		}
+
+		configured = append(configured, rule)
+	}
+
+	return configured, nil
+}
+
+// appliesTo reports whether the named rule applies to the configuration file at
+// the given path, relative to the directory being linted.
+func (c *Config) appliesTo(rule, file string) bool {
+	if c == nil {
+		return true
+	}
+
+	rc := c.Rules[rule]
+	file = filepath.ToSlash(file)
+
+	match := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := doublestar.Match(pattern, file); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(rc.Paths) > 0 && !match(rc.Paths) {
+		return false
+	}
+
+	return !match(rc.ExcludePaths)
+}
+
+// decodeStrict decodes the node into v, failing on fields that v doesn't
+// have.
+func decodeStrict(node *yaml.Node, v any) error {
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	return dec.Decode(v)
+}
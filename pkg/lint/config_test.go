@@ -0,0 +1,261 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := ReadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func lintWithConfig(t *testing.T, path string, cfg *Config) map[string][]EvalRuleError {
+	t.Helper()
+
+	l := New(WithPath(filepath.Join("testdata", path)), WithConfig(cfg))
+	result, err := l.Lint(context.Background(), SeverityInfo)
+	require.NoError(t, err)
+
+	got := map[string][]EvalRuleError{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			got[e.Rule.Name] = append(got[e.Rule.Name], e)
+		}
+	}
+	return got
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("no config", func(t *testing.T) {
+		got := lintWithConfig(t, "files/forbidden-repository.yaml", nil)
+		require.Len(t, got["forbidden-repository-used"], 1)
+		assert.Equal(t, SeverityError, got["forbidden-repository-used"][0].Rule.Severity)
+	})
+
+	t.Run("severity override", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  forbidden-repository-used:
+    severity: warning
+`)
+		got := lintWithConfig(t, "files/forbidden-repository.yaml", cfg)
+		require.Len(t, got["forbidden-repository-used"], 1)
+		assert.Equal(t, SeverityWarning, got["forbidden-repository-used"][0].Rule.Severity)
+	})
+
+	t.Run("disabled rule", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  forbidden-repository-used:
+    enabled: false
+`)
+		got := lintWithConfig(t, "files/forbidden-repository.yaml", cfg)
+		assert.Empty(t, got["forbidden-repository-used"])
+		assert.NotEmpty(t, got["valid-package-or-subpackage-test"])
+	})
+
+	t.Run("disable all", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+disable-all: true
+rules:
+  forbidden-repository-used:
+    enabled: true
+`)
+		got := lintWithConfig(t, "files/forbidden-repository.yaml", cfg)
+		assert.Len(t, got["forbidden-repository-used"], 1)
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("excluded path", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  forbidden-repository-used:
+    exclude-paths:
+      - forbidden-*.yaml
+`)
+		got := lintWithConfig(t, "files/forbidden-repository.yaml", cfg)
+		assert.Empty(t, got["forbidden-repository-used"])
+	})
+
+	t.Run("unmatched path", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  uri-mimic:
+    paths:
+      - normal.yaml
+`)
+		got := lintWithConfig(t, "dirs/tld-swap/", cfg)
+		assert.Empty(t, got["uri-mimic"])
+	})
+
+	t.Run("excluded path doesn't set the legitimate host", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  uri-mimic:
+    exclude-paths:
+      - foo.yaml
+      - normal.yaml
+`)
+		got := lintWithConfig(t, "dirs/tld-swap/", cfg)
+		assert.Empty(t, got["uri-mimic"])
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		got := lintWithConfig(t, "dirs/similar-domains/", nil)
+		require.Len(t, got["uri-mimic"], 1)
+
+		cfg := readTestConfig(t, `
+rules:
+  uri-mimic:
+    with:
+      host-edit-distance-exceptions:
+        www.libshh2.org: www.libssh2.org
+`)
+		got = lintWithConfig(t, "dirs/similar-domains/", cfg)
+		assert.Empty(t, got["uri-mimic"])
+
+		cfg = readTestConfig(t, `
+rules:
+  forbidden-repository-used:
+    with:
+      repositories:
+        - https://example.com/os
+`)
+		got = lintWithConfig(t, "files/forbidden-repository.yaml", cfg)
+		assert.Empty(t, got["forbidden-repository-used"])
+
+		got = lintWithConfig(t, "dirs/repository/", nil)
+		require.Len(t, got["dependencies-provided"], 1)
+
+		cfg = readTestConfig(t, `
+rules:
+  dependencies-provided:
+    with:
+      exempt:
+        - missing
+`)
+		got = lintWithConfig(t, "dirs/repository/", cfg)
+		assert.Empty(t, got["dependencies-provided"])
+	})
+}
+
+func TestConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "unknown rule",
+			content: `
+rules:
+  no-such-rule:
+    enabled: false
+`,
+		},
+		{
+			name: "unknown severity",
+			content: `
+rules:
+  bad-version:
+    severity: fatal
+`,
+		},
+		{
+			name: "invalid pattern",
+			content: `
+rules:
+  bad-version:
+    paths: ["["]
+`,
+		},
+		{
+			name: "unknown parameter",
+			content: `
+rules:
+  uri-mimic:
+    with:
+      max-host-edit-distance: 3
+`,
+		},
+		{
+			name: "rule without parameters",
+			content: `
+rules:
+  bad-version:
+    with:
+      strict: true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := readTestConfig(t, tt.content)
+			l := New(WithPath("testdata/files/bad-version.yaml"), WithConfig(cfg))
+			_, err := l.Lint(context.Background(), SeverityInfo)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte("rulez: {}\n"), 0o600))
+		_, err := ReadConfig(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_AppliesTo(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{pattern: "*.yaml", file: "foo.yaml", want: true},
+		{pattern: "*.yaml", file: "sub/foo.yaml", want: false},
+		{pattern: "sub/*.yaml", file: "sub/foo.yaml", want: true},
+		{pattern: "**/*.yaml", file: "foo.yaml", want: true},
+		{pattern: "**/*.yaml", file: "sub/dir/foo.yaml", want: true},
+		{pattern: "sub/**", file: "sub/dir/foo.yaml", want: true},
+		{pattern: "sub/**", file: "other/foo.yaml", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.file, func(t *testing.T) {
+			cfg := &Config{Rules: map[string]RuleConfig{"bad-version": {Paths: []string{tt.pattern}}}}
+			assert.Equal(t, tt.want, cfg.appliesTo("bad-version", tt.file))
+
+			cfg = &Config{Rules: map[string]RuleConfig{"bad-version": {ExcludePaths: []string{tt.pattern}}}}
+			assert.Equal(t, !tt.want, cfg.appliesTo("bad-version", tt.file))
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := FindConfig(dir)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ConfigFileName), []byte("disable-all: true\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), nil, 0o600))
+
+	for _, path := range []string{dir, filepath.Join(dir, "foo.yaml")} {
+		cfg, err := FindConfig(path)
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+		assert.True(t, cfg.DisableAll)
+	}
+}
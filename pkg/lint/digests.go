@@ -0,0 +1,272 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"chainguard.dev/melange/pkg/config"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+// sourceCache is a local directory of the source artifacts that fetch steps
+// download, like melange's source cache. An artifact is found by the host and
+// path of its URI, like "example.com/foo/foo-1.2.3.tar.gz", and its digests are
+// computed from its content. Artifacts aren't found by the file name of their
+// URI alone, since different packages' artifacts can have the same file name,
+// nor by melange's content-addressed names, like "sha256:<digest>", since those
+// are named after the digest being checked.
+type sourceCache struct {
+	dir string
+
+	mu      sync.Mutex
+	digests map[string]artifactDigests
+}
+
+// artifactDigests are the digests of a source artifact.
+type artifactDigests struct {
+	sha256 string
+	sha512 string
+}
+
+// newSourceCache returns the source cache in the given directory.
+func newSourceCache(dir string) *sourceCache {
+	return &sourceCache{
+		dir:     dir,
+		digests: map[string]artifactDigests{},
+	}
+}
+
+// find returns the path of the cached artifact fetched from the given URI, and
+// whether there is one.
+func (c *sourceCache) find(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	// Cleaning the path as an absolute one keeps it within the cache.
+	name := path.Clean("/" + u.Path)
+	if name == "/" {
+		return "", false
+	}
+
+	p := filepath.Join(c.dir, filepath.FromSlash(u.Host+name))
+	if fi, err := os.Stat(p); err != nil || !fi.Mode().IsRegular() {
+		return "", false
+	}
+
+	return p, true
+}
+
+// digestsOf returns the digests of the cached artifact at the given path.
+// Digests are computed once per artifact, since many configurations can fetch
+// the same one.
+func (c *sourceCache) digestsOf(p string) (artifactDigests, error) {
+	c.mu.Lock()
+	d, ok := c.digests[p]
+	c.mu.Unlock()
+	if ok {
+		return d, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return artifactDigests{}, err
+	}
+	defer f.Close()
+
+	h256, h512 := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(h256, h512), f); err != nil {
+		return artifactDigests{}, fmt.Errorf("reading %s: %w", p, err)
+	}
+	d = artifactDigests{
+		sha256: fmt.Sprintf("%x", h256.Sum(nil)),
+		sha512: fmt.Sprintf("%x", h512.Sum(nil)),
+	}
+
+	c.mu.Lock()
+	c.digests[p] = d
+	c.mu.Unlock()
+
+	return d, nil
+}
+
+// fingerprint identifies the artifacts in the cache, so that cached lint
+// results are invalidated when they change. It uses the artifacts' paths,
+// sizes and modification times rather than their content, to stay cheap.
+func (c *sourceCache) fingerprint() string {
+	if c == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", c.dir)
+	err := filepath.WalkDir(c.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.dir, p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %d %d\n", filepath.ToSlash(rel), fi.Size(), fi.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// cachedFetch is a fetch step whose artifact is in the source cache.
+type cachedFetch struct {
+	pipelineStep
+	digests artifactDigests
+}
+
+// cachedFetches returns the configuration's fetch steps whose artifacts are in
+// the source cache. Steps whose URI can't be resolved are left out.
+func (c *sourceCache) cachedFetches(in Input) ([]cachedFetch, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	var fetches []cachedFetch
+	for _, step := range pipelineSteps(in) {
+		if step.Uses != "fetch" {
+			continue
+		}
+		uri, ok := resolveTemplates(in, step.With["uri"])
+		if !ok || uri == "" {
+			continue
+		}
+		p, ok := c.find(uri)
+		if !ok {
+			continue
+		}
+		d, err := c.digestsOf(p)
+		if err != nil {
+			return nil, err
+		}
+		fetches = append(fetches, cachedFetch{pipelineStep: step, digests: d})
+	}
+
+	return fetches, nil
+}
+
+// checkDigests checks the expected digests of the configuration's fetch steps
+// against the artifacts in the source cache.
+func (c *sourceCache) checkDigests(in Input) error {
+	fetches, err := c.cachedFetches(in)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fetches {
+		if d, ok := f.With["expected-sha256"]; ok && !strings.EqualFold(d, f.digests.sha256) {
+			return violation(in.NodeAt(append(f.path, "with", "expected-sha256")...), "expected-sha256 does not match the cached artifact, whose sha256 is %s", f.digests.sha256)
+		}
+		if d, ok := f.With["expected-sha512"]; ok && !strings.EqualFold(d, f.digests.sha512) {
+			return violation(in.NodeAt(append(f.path, "with", "expected-sha512")...), "expected-sha512 does not match the cached artifact, whose sha512 is %s", f.digests.sha512)
+		}
+	}
+
+	return nil
+}
+
+// fixDigests sets the expected digests of the configuration's fetch steps to
+// those of the artifacts in the source cache. Steps without an expected digest
+// get an expected-sha256.
+func (c *sourceCache) fixDigests(cfg config.Configuration, root *yaml.Node) error {
+	fetches, err := c.cachedFetches(Input{Configuration: cfg})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fetches {
+		with := lookupNode(root, append(f.path, "with")...)
+		if with == nil || with.Kind != yaml.MappingNode {
+			continue
+		}
+
+		sha256Node, sha512Node := childNode(with, "expected-sha256"), childNode(with, "expected-sha512")
+		if sha256Node != nil {
+			setString(sha256Node, f.digests.sha256)
+		}
+		if sha512Node != nil {
+			setString(sha512Node, f.digests.sha512)
+		}
+		if sha256Node == nil && sha512Node == nil {
+			insertAfterKey(with, "uri",
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "expected-sha256"},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: f.digests.sha256},
+			)
+		}
+	}
+
+	return nil
+}
+
+// setString sets the value of the scalar node to the string. The node's tag is
+// reset, since a value like an all-digit digest may have been decoded as a
+// number.
+func setString(node *yaml.Node, value string) {
+	node.Tag = "!!str"
+	node.Value = value
+}
+
+// resolveTemplates substitutes the package and variable references in s, as
+// melange does when building, and reports whether there are no references
+// left.
+func resolveTemplates(in Input, s string) (string, bool) {
+	subs := map[string]string{
+		"${{package.name}}":         in.Package.Name,
+		"${{package.version}}":      in.Package.Version,
+		"${{package.epoch}}":        fmt.Sprint(in.Package.Epoch),
+		"${{package.full-version}}": fmt.Sprintf("%s-r%d", in.Package.Version, in.Package.Epoch),
+	}
+	for k, v := range in.Vars {
+		subs["${{vars."+k+"}}"] = v
+	}
+
+	replace := func(s string) string {
+		keys := maps.Keys(subs)
+		sort.Strings(keys)
+		pairs := make([]string, 0, 2*len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k, subs[k])
+		}
+		return strings.NewReplacer(pairs...).Replace(s)
+	}
+
+	for _, vt := range in.VarTransforms {
+		re, err := regexp.Compile(vt.Match)
+		if err != nil {
+			continue
+		}
+		subs["${{vars."+vt.To+"}}"] = re.ReplaceAllString(replace(vt.From), vt.Replace)
+	}
+
+	s = replace(s)
+	return s, !strings.Contains(s, "${{")
+}
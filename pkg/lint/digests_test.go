@@ -0,0 +1,217 @@
+package lint
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+)
+
+const (
+	wrongSHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+	wrongSHA512 = wrongSHA256 + wrongSHA256
+)
+
+// setUpSourceCache writes configurations that fetch from example.com, and a
+// source cache with artifacts for some of them, returning the directories of
+// each along with the digests of the cached artifacts.
+func setUpSourceCache(t *testing.T) (dir, cacheDir, sha256Digest, sha512Digest string) {
+	t.Helper()
+
+	dir, cacheDir = t.TempDir(), t.TempDir()
+	content := []byte("source code")
+	sha256Digest = fmt.Sprintf("%x", sha256.Sum256(content))
+	sha512Digest = fmt.Sprintf("%x", sha512.Sum512(content))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "example.com"), 0o755))
+	for _, name := range []string{"correct-1.2.3.tar.gz", "wrong-1.2.3.tar.gz", "missing-1.2.3.tar.gz", "vars-1_2_3.tar.gz"} {
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "example.com", name), content, 0o600))
+	}
+
+	configs := map[string]string{
+		"correct": "      uri: https://example.com/correct-${{package.version}}.tar.gz\n      expected-sha256: " + sha256Digest + "\n",
+		"wrong":   "      uri: https://example.com/wrong-${{package.version}}.tar.gz\n      expected-sha256: " + wrongSHA256 + "\n",
+		"missing": "      uri: https://example.com/missing-${{package.version}}.tar.gz\n",
+		"vars":    "      uri: https://example.com/vars-${{vars.underscored}}.tar.gz\n      expected-sha512: " + wrongSHA512 + "\n",
+		// There's no artifact in the cache for this one, so its digest can't be
+		// checked.
+		"uncached": "      uri: https://example.com/uncached-${{package.version}}.tar.gz\n      expected-sha256: " + wrongSHA256 + "\n",
+	}
+	for name, with := range configs {
+		cfg := fmt.Sprintf(`package:
+  name: %s
+  version: 1.2.3
+  epoch: 0
+  description: A package fetched from example.com
+  copyright:
+    - license: Apache-2.0
+var-transforms:
+  - from: ${{package.version}}
+    match: \.
+    replace: _
+    to: underscored
+pipeline:
+  - uses: fetch
+    with:
+%s`, name, with)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(cfg), 0o600))
+	}
+
+	return dir, cacheDir, sha256Digest, sha512Digest
+}
+
+func digestViolations(t *testing.T, result Result) map[string][]string {
+	t.Helper()
+
+	got := map[string][]string{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			if e.Rule.Name == "fetch-digest-matches-source-cache" || e.Rule.Name == "valid-pipeline-fetch-digest" {
+				got[res.File] = append(got[res.File], fmt.Sprintf("%s: %s", e.Rule.Name, e.Message))
+			}
+		}
+	}
+	return got
+}
+
+func TestLinter_SourceCache(t *testing.T) {
+	ctx := context.Background()
+	dir, cacheDir, sha256Digest, sha512Digest := setUpSourceCache(t)
+
+	result, err := New(WithPath(dir), WithSourceCacheDir(cacheDir)).Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"wrong":   {"fetch-digest-matches-source-cache: expected-sha256 does not match the cached artifact, whose sha256 is " + sha256Digest},
+		"vars":    {"fetch-digest-matches-source-cache: expected-sha512 does not match the cached artifact, whose sha512 is " + sha512Digest},
+		"missing": {"valid-pipeline-fetch-digest: expected-sha256 or expected-sha512 is missing"},
+	}, digestViolations(t, result))
+
+	// Without a source cache, digests are only checked for being well-formed.
+	result, err = New(WithPath(dir)).Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"missing": {"valid-pipeline-fetch-digest: expected-sha256 or expected-sha512 is missing"},
+	}, digestViolations(t, result))
+}
+
+func TestLinter_SourceCache_Fix(t *testing.T) {
+	ctx := context.Background()
+	dir, cacheDir, sha256Digest, sha512Digest := setUpSourceCache(t)
+
+	l := New(WithPath(dir), WithSourceCacheDir(cacheDir))
+	result, err := l.Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+
+	fsys := memfs.New(os.DirFS(dir))
+	fixed, err := l.Fix(ctx, result, fsys)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range fixed {
+		if strings.Contains(strings.Join(f.Rules, ","), "digest") {
+			paths = append(paths, f.Path)
+		}
+	}
+	assert.ElementsMatch(t, []string{"wrong.yaml", "vars.yaml", "missing.yaml"}, paths)
+
+	read := func(name string) string {
+		b, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		return string(b)
+	}
+	assert.Contains(t, read("wrong.yaml"), "expected-sha256: "+sha256Digest)
+	assert.Contains(t, read("vars.yaml"), "expected-sha512: "+sha512Digest)
+	assert.Contains(t, read("missing.yaml"), "uri: https://example.com/missing-${{package.version}}.tar.gz\n      expected-sha256: "+sha256Digest)
+	assert.Contains(t, read("uncached.yaml"), "expected-sha256: "+wrongSHA256)
+
+	// Without a source cache, there's nothing to fix digests from.
+	l = New(WithPath(dir))
+	result, err = l.Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	fixed, err = l.Fix(ctx, result, memfs.New(os.DirFS(dir)))
+	require.NoError(t, err)
+	for _, f := range fixed {
+		assert.NotContains(t, strings.Join(f.Rules, ","), "digest")
+	}
+}
+
+func TestSourceCache_Find(t *testing.T) {
+	cacheDir := t.TempDir()
+	content := []byte("source code")
+	digest := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "example.com", "foo"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "example.com", "foo", "v1.2.3.tar.gz"), []byte("other source code"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "v1.2.3.tar.gz"), content, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "sha256:"+digest), content, 0o600))
+
+	c := newSourceCache(cacheDir)
+
+	// The artifact is found by the URI's host and path, even if there's one
+	// named after the digest that it's expected to have.
+	p, ok := c.find("https://example.com/foo/v1.2.3.tar.gz")
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(cacheDir, "example.com", "foo", "v1.2.3.tar.gz"), p)
+
+	// Another package's artifact with the same file name isn't used.
+	_, ok = c.find("https://example.com/bar/v1.2.3.tar.gz")
+	assert.False(t, ok)
+
+	// The URI's path can't lead out of the cache.
+	_, ok = c.find("https://example.com/../../v1.2.3.tar.gz")
+	assert.False(t, ok)
+}
+
+func TestLinter_SourceCache_ContentAddressed(t *testing.T) {
+	ctx := context.Background()
+	dir, cacheDir, sha256Digest, _ := setUpSourceCache(t)
+
+	// An artifact named after the wrong digest doesn't make it match, nor hide
+	// that the artifact fetched from the URI doesn't.
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "sha256:"+wrongSHA256), []byte("stale source code"), 0o600))
+
+	result, err := New(WithPath(dir), WithSourceCacheDir(cacheDir)).Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	got := digestViolations(t, result)
+	assert.Equal(t, []string{"fetch-digest-matches-source-cache: expected-sha256 does not match the cached artifact, whose sha256 is " + sha256Digest}, got["wrong"])
+	assert.Empty(t, got["uncached"])
+}
+
+func TestSourceCache_Fingerprint(t *testing.T) {
+	cacheDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "example.com", "foo"), 0o755))
+	artifact := filepath.Join(cacheDir, "example.com", "foo", "v1.2.3.tar.gz")
+	require.NoError(t, os.WriteFile(artifact, []byte("source code"), 0o600))
+
+	c := newSourceCache(cacheDir)
+	before := c.fingerprint()
+	assert.Equal(t, before, c.fingerprint())
+
+	// Changes to artifacts in subdirectories change the fingerprint.
+	require.NoError(t, os.WriteFile(artifact, []byte("other source code"), 0o600))
+	assert.NotEqual(t, before, c.fingerprint())
+}
+
+func TestResolveTemplates(t *testing.T) {
+	in := Input{}
+	in.Package.Name = "foo"
+	in.Package.Version = "1.2.3"
+	in.Package.Epoch = 4
+	in.Vars = map[string]string{"base": "https://example.com"}
+
+	got, ok := resolveTemplates(in, "${{vars.base}}/${{package.name}}-${{package.full-version}}.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/foo-1.2.3-r4.tar.gz", got)
+
+	_, ok = resolveTemplates(in, "${{vars.unknown}}")
+	assert.False(t, ok)
+}
@@ -0,0 +1,273 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"chainguard.dev/melange/pkg/config"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/build"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// FixResult describes the fixes applied to a single configuration file.
+type FixResult struct {
+	// File is the name of the package the configuration file defines.
+	File string
+
+	// Path is the path to the configuration file, relative to the linter's
+	// directory (see Linter.Dir).
+	Path string
+
+	// Rules are the names of the rules whose violations were fixed.
+	Rules []string
+}
+
+// Dir returns the directory that contains the configuration files being
+// linted.
+func (l *Linter) Dir() string {
+	return dirOf(l.options.Path)
+}
+
+// Fix applies the fixers of the rules violated in the given result, which
+// should come from a previous call to Lint. The configuration files are
+// written to fsys, which must be rooted at the linter's directory (see Dir).
+// Violations of rules that have no fixer are left as they are.
+func (l *Linter) Fix(ctx context.Context, result Result, fsys rwfs.FS) ([]FixResult, error) {
+	dir := l.Dir()
+
+	var fixed []FixResult
+	for _, res := range result {
+		var rules []Rule
+		for _, e := range res.Errors {
+			if e.Rule.FixFunc == nil || slices.ContainsFunc(rules, func(r Rule) bool { return r.Name == e.Rule.Name }) {
+				continue
+			}
+			rules = append(rules, e.Rule)
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		path, err := filepath.Rel(dir, res.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", res.Path, err)
+		}
+		path = filepath.ToSlash(path)
+
+		index, err := build.NewIndexFromPaths(ctx, fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("indexing %s: %w", path, err)
+		}
+
+		entry, err := index.Select().WhereFilePath(path).First()
+		if err != nil {
+			return nil, fmt.Errorf("selecting %s: %w", path, err)
+		}
+
+		fix := func(cfg config.Configuration, root *yaml.Node) error {
+			for _, rule := range rules {
+				if err := rule.FixFunc(cfg, root); err != nil {
+					return fmt.Errorf("fixing %s: %w", rule.Name, err)
+				}
+			}
+			return nil
+		}
+
+		if err := entry.Update(ctx, configs.NewYAMLUpdateFunc(fix)); err != nil {
+			return nil, fmt.Errorf("updating %s: %w", path, err)
+		}
+
+		names := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			names = append(names, rule.Name)
+		}
+		sort.Strings(names)
+
+		fixed = append(fixed, FixResult{
+			File:  res.File,
+			Path:  path,
+			Rules: names,
+		})
+	}
+
+	return fixed, nil
+}
+
+// lookupNode returns the node at the given path from the given node, using the
+// same path elements as Input.NodeAt, or nil if there's no such node.
+func lookupNode(node *yaml.Node, path ...any) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+
+	for _, elem := range path {
+		if node == nil {
+			return nil
+		}
+		node = childNode(node, elem)
+	}
+
+	return node
+}
+
+// insertAfterKey inserts the key and value into the mapping node, after the
+// given existing key if it's present, and at the end otherwise.
+func insertAfterKey(mapping *yaml.Node, after string, key, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == after {
+			content := append([]*yaml.Node{}, mapping.Content[:i+2]...)
+			content = append(content, key, value)
+			mapping.Content = append(content, mapping.Content[i+2:]...)
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+// fixMissingEpoch adds an epoch of 0 to the package.
+func fixMissingEpoch(_ config.Configuration, root *yaml.Node) error {
+	pkg := lookupNode(root, "package")
+	if pkg == nil || pkg.Kind != yaml.MappingNode {
+		return fmt.Errorf("no package mapping to add an epoch to")
+	}
+	if childNode(pkg, "epoch") != nil {
+		return nil
+	}
+
+	insertAfterKey(pkg, "version",
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "epoch"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"},
+	)
+	return nil
+}
+
+// fixRepeatedDeps removes all but the first occurrence of each package in the
+// build environment.
+func fixRepeatedDeps(_ config.Configuration, root *yaml.Node) error {
+	packages := lookupNode(root, "environment", "contents", "packages")
+	if packages == nil || packages.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	content := make([]*yaml.Node, 0, len(packages.Content))
+	for _, n := range packages.Content {
+		if _, ok := seen[n.Value]; ok {
+			continue
+		}
+		seen[n.Value] = struct{}{}
+		content = append(content, n)
+	}
+	packages.Content = content
+
+	return nil
+}
+
+// fixTaggedRepositories removes the tags from tagged repositories in the build
+// environment, along with the references to those tags from the environment's
+// packages.
+func fixTaggedRepositories(_ config.Configuration, root *yaml.Node) error {
+	tags := map[string]struct{}{}
+	for _, key := range []string{"build_repositories", "repositories"} {
+		repos := lookupNode(root, "environment", "contents", key)
+		if repos == nil || repos.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, n := range repos.Content {
+			tag, repo, ok := strings.Cut(n.Value, " ")
+			if !ok || !strings.HasPrefix(tag, "@") {
+				continue
+			}
+			tags[tag] = struct{}{}
+			n.Value = strings.TrimSpace(repo)
+		}
+	}
+
+	packages := lookupNode(root, "environment", "contents", "packages")
+	if packages == nil || packages.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, n := range packages.Content {
+		i := strings.LastIndex(n.Value, "@")
+		if i < 0 {
+			continue
+		}
+		if _, ok := tags[n.Value[i:]]; ok {
+			n.Value = n.Value[:i]
+		}
+	}
+
+	return nil
+}
+
+// updateDisabledReasonPlaceholder is the exclude-reason inserted by
+// fixUpdateDisabledReason, to be replaced by a real reason.
+const updateDisabledReasonPlaceholder = "TODO: explain why auto-update is disabled"
+
+// fixUpdateDisabledReason adds a placeholder reason for disabling updates.
+func fixUpdateDisabledReason(_ config.Configuration, root *yaml.Node) error {
+	update := lookupNode(root, "update")
+	if update == nil || update.Kind != yaml.MappingNode {
+		return nil
+	}
+	if reason := childNode(update, "exclude-reason"); reason != nil {
+		if reason.Value == "" {
+			reason.Value = updateDisabledReasonPlaceholder
+		}
+		return nil
+	}
+
+	insertAfterKey(update, "enabled",
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "exclude-reason"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: updateDisabledReasonPlaceholder},
+	)
+	return nil
+}
+
+// fixBadTemplateVars replaces shell-style variables that are commonly carried
+// over from other build systems with their melange equivalents.
+func fixBadTemplateVars(_ config.Configuration, root *yaml.Node) error {
+	replacer := func(destdir string) *strings.Replacer {
+		return strings.NewReplacer(
+			"$pkgdir", destdir,
+			"$pkgver", "${{package.version}}",
+			"$pkgname", "${{package.name}}",
+			"$srcdir", ".",
+		)
+	}
+
+	var fixSteps func(steps *yaml.Node, r *strings.Replacer)
+	fixSteps = func(steps *yaml.Node, r *strings.Replacer) {
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, step := range steps.Content {
+			if runs := childNode(step, "runs"); runs != nil && runs.Kind == yaml.ScalarNode {
+				runs.Value = r.Replace(runs.Value)
+			}
+			fixSteps(childNode(step, "pipeline"), r)
+		}
+	}
+
+	fixSteps(lookupNode(root, "pipeline"), replacer("${{targets.destdir}}"))
+
+	if subpackages := lookupNode(root, "subpackages"); subpackages != nil && subpackages.Kind == yaml.SequenceNode {
+		r := replacer("${{targets.subpkgdir}}")
+		for _, sp := range subpackages.Content {
+			fixSteps(childNode(sp, "pipeline"), r)
+		}
+	}
+
+	return nil
+}
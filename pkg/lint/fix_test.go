@@ -0,0 +1,99 @@
+package lint
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfi-dev/wolfictl/pkg/configs/rwfs/os/memfs"
+)
+
+func TestLinter_Fix(t *testing.T) {
+	tests := []struct {
+		file       string
+		rule       string
+		contains   []string
+		notContain []string
+	}{
+		{
+			file:     "no-epoch.yaml",
+			rule:     "contains-epoch",
+			contains: []string{"  version: 1.2.3\n  epoch: 0\n"},
+		},
+		{
+			file:       "duplicated-package.yaml",
+			rule:       "no-repeated-deps",
+			contains:   []string{"      - foo\n      - bar\n"},
+			notContain: []string{"      - bar\n      - foo\n"},
+		},
+		{
+			file:       "forbidden-repository-tagged.yaml",
+			rule:       "tagged-repository-in-environment-repos",
+			contains:   []string{`- "./foo"`},
+			notContain: []string{"@local"},
+		},
+		{
+			file:     "update-disabled.yaml",
+			rule:     "update-disabled-reason",
+			contains: []string{"  enabled: false\n  exclude-reason: 'TODO: explain why auto-update is disabled'\n"},
+		},
+		{
+			file:       "bad-template-var.yaml",
+			rule:       "bad-template-var",
+			contains:   []string{"cat ${{targets.destdir}}"},
+			notContain: []string{"$pkgdir"},
+		},
+		{
+			file: "bad-template-var-nested.yaml",
+			rule: "bad-template-var",
+			contains: []string{
+				"cp foo ${{targets.destdir}}",
+				"echo ${{package.version}}",
+				"cd .",
+			},
+			notContain: []string{"$pkgdir", "$pkgver", "$srcdir"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			ctx := context.Background()
+			l := newTestLinterWithFile(tt.file)
+
+			result, err := l.Lint(ctx, SeverityInfo)
+			require.NoError(t, err)
+
+			fsys := memfs.New(os.DirFS(l.Dir()))
+			fixed, err := l.Fix(ctx, result, fsys)
+			require.NoError(t, err)
+			require.Len(t, fixed, 1)
+			assert.Equal(t, tt.file, fixed[0].Path)
+			assert.Equal(t, []string{tt.rule}, fixed[0].Rules)
+
+			b, err := fs.ReadFile(fsys, tt.file)
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, string(b), s)
+			}
+			for _, s := range tt.notContain {
+				assert.NotContains(t, string(b), s)
+			}
+		})
+	}
+}
+
+func TestLinter_Fix_NoFixer(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLinterWithFile("bad-version.yaml")
+
+	result, err := l.Lint(ctx, SeverityInfo)
+	require.NoError(t, err)
+	require.True(t, result.HasErrors())
+
+	fixed, err := l.Fix(ctx, result, memfs.New(os.DirFS(l.Dir())))
+	require.NoError(t, err)
+	assert.Empty(t, fixed)
+}
@@ -0,0 +1,236 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Diagnostic is a single rule violation, in a form suitable for
+// machine-readable output.
+type Diagnostic struct {
+	// Path is the path to the configuration file.
+	Path string `json:"path"`
+
+	// Package is the name of the package the configuration file defines.
+	Package string `json:"package"`
+
+	// Rule is the name of the violated rule.
+	Rule string `json:"rule"`
+
+	// Severity is the name of the rule's severity.
+	Severity string `json:"severity"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+
+	// Range is where in the configuration file the violation is, if known.
+	Range *Range `json:"range,omitempty"`
+}
+
+// Diagnostics returns one Diagnostic for each error in the result.
+func (r Result) Diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, res := range r {
+		for _, e := range res.Errors {
+			diagnostics = append(diagnostics, Diagnostic{
+				Path:     res.Path,
+				Package:  res.File,
+				Rule:     e.Rule.Name,
+				Severity: e.Rule.Severity.Name,
+				Message:  e.Message,
+				Range:    e.Range,
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// WriteJSON writes the result's diagnostics to w as a JSON array.
+func WriteJSON(w io.Writer, result Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result.Diagnostics())
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// sarifLevel returns the SARIF level for the severity.
+func sarifLevel(s Severity) string {
+	switch s.Value {
+	case SeverityErrorLevel:
+		return "error"
+	case SeverityWarningLevel:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteSARIF writes the result to w as a SARIF 2.1.0 log. The given rules are
+// described in the log, and should include every rule that was evaluated.
+func WriteSARIF(w io.Writer, result Result, rules Rules) error {
+	driver := sarifDriver{
+		Name:           "wolfictl",
+		InformationURI: "https://github.com/wolfi-dev/wolfictl",
+		Rules:          []sarifRule{},
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: res.Path},
+			}
+			if r := e.Range; r != nil {
+				loc.Region = &sarifRegion{
+					StartLine:   r.Start.Line,
+					StartColumn: r.Start.Column,
+					EndLine:     r.End.Line,
+					EndColumn:   r.End.Column,
+				}
+			}
+
+			results = append(results, sarifResult{
+				RuleID:    e.Rule.Name,
+				Level:     sarifLevel(e.Rule.Severity),
+				Message:   sarifMessage{Text: e.Message},
+				Locations: []sarifLocation{{PhysicalLocation: loc}},
+			})
+		}
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// WriteGitHub writes the result to w as GitHub Actions workflow commands, so
+// that each violation is shown as an annotation on the offending line.
+func WriteGitHub(w io.Writer, result Result) error {
+	for _, d := range result.Diagnostics() {
+		command := "error"
+		switch d.Severity {
+		case SeverityWarning.Name:
+			command = "warning"
+		case SeverityInfo.Name:
+			command = "notice"
+		}
+
+		props := []string{"file=" + escapeGitHubProperty(d.Path)}
+		if r := d.Range; r != nil {
+			props = append(props, fmt.Sprintf("line=%d", r.Start.Line))
+			if r.Start.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", r.Start.Column))
+			}
+			if r.End.Line > 0 {
+				props = append(props, fmt.Sprintf("endLine=%d", r.End.Line))
+			}
+			if r.End.Column > 0 {
+				props = append(props, fmt.Sprintf("endColumn=%d", r.End.Column))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(d.Rule))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeGitHubData(d.Message)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// escapeGitHubData escapes a workflow command's message.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command's property value.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
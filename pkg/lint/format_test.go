@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFormatResult = Result{
+	{
+		File: "foo",
+		Path: "foo.yaml",
+		Errors: EvalRuleErrors{
+			{
+				Rule:    Rule{Name: "bad-version", Description: "version is malformed", Severity: SeverityError},
+				Error:   fmt.Errorf("[bad-version]: invalid version 1.0.0rc1, could not parse (ERROR)"),
+				Message: "invalid version 1.0.0rc1, could not parse",
+				Range:   &Range{Start: Position{Line: 3, Column: 12}, End: Position{Line: 3, Column: 20}},
+			},
+			{
+				Rule:    Rule{Name: "valid-package-or-subpackage-test", Description: "every package should have a valid main or subpackage test", Severity: SeverityWarning},
+				Error:   fmt.Errorf("[valid-package-or-subpackage-test]: no main package or subpackage test found (WARNING)"),
+				Message: "no main package or subpackage test found",
+			},
+		},
+	},
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, testFormatResult))
+
+	var got []Diagnostic
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, testFormatResult.Diagnostics(), got)
+	assert.Equal(t, "bad-version", got[0].Rule)
+	assert.Equal(t, 3, got[0].Range.Start.Line)
+	assert.Nil(t, got[1].Range)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, testFormatResult, Rules{testFormatResult[0].Errors[0].Rule}))
+
+	var got sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "2.1.0", got.Version)
+	require.Len(t, got.Runs, 1)
+
+	run := got.Runs[0]
+	assert.Equal(t, "wolfictl", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 1)
+	assert.Equal(t, "bad-version", run.Tool.Driver.Rules[0].ID)
+
+	require.Len(t, run.Results, 2)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 12, EndLine: 3, EndColumn: 20}, run.Results[0].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "foo.yaml", run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteGitHub(&buf, testFormatResult))
+
+	want := "::error file=foo.yaml,line=3,col=12,endLine=3,endColumn=20,title=bad-version::invalid version 1.0.0rc1, could not parse\n" +
+		"::warning file=foo.yaml,title=valid-package-or-subpackage-test::no main package or subpackage test found\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestEscapeGitHub(t *testing.T) {
+	assert.Equal(t, "a%25b%0Ac", escapeGitHubData("a%b\nc"))
+	assert.Equal(t, "a%3Ab%2Cc", escapeGitHubProperty("a:b,c"))
+}
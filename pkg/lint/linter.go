@@ -3,9 +3,14 @@ package lint
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strings"
 
 	"golang.org/x/exp/slices"
+	"golang.org/x/sync/errgroup"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -18,6 +23,14 @@ import (
 type Linter struct {
 	// options are the options to configure the linter.
 	options Options
+
+	// pipelines are the pipelines that configurations can use. They're loaded by
+	// Lint.
+	pipelines *pipelineCatalog
+
+	// sources is the source cache that fetch digests are checked against, or nil
+	// if there isn't one.
+	sources *sourceCache
 }
 
 // New initializes a new instance of Linter.
@@ -26,23 +39,35 @@ func New(opts ...Option) *Linter {
 	for _, opt := range opts {
 		opt(&o)
 	}
-	return &Linter{options: o}
+	l := &Linter{options: o}
+	if o.SourceCacheDir != "" {
+		l.sources = newSourceCache(o.SourceCacheDir)
+	}
+	return l
 }
 
-// Lint evaluates all rules and returns the result.
+// Lint evaluates all rules and returns the result. Configurations are linted
+// concurrently, and if the linter has a cache directory, the results of
+// single-configuration rules are cached by the configuration file's content.
 func (l *Linter) Lint(ctx context.Context, minSeverity Severity) (Result, error) {
 	log := clog.FromContext(ctx)
-	rules := AllRules(l)
-
-	namesToPkg, err := melange.ReadAllPackagesFromRepo(ctx, l.options.Path)
+	rules, err := l.Rules()
 	if err != nil {
 		return Result{}, err
 	}
 
-	// global shared between config files for rule evaluation :(
-	seenHosts = map[string]bool{}
+	pipelineDirs := l.options.PipelineDirs
+	if pipelineDirs == nil {
+		pipelineDirs = []string{filepath.Join(l.Dir(), "pipelines")}
+	}
+	if l.pipelines, err = loadPipelines(pipelineDirs); err != nil {
+		return Result{}, err
+	}
 
-	results := make(Result, 0)
+	namesToPkg, err := melange.ReadAllPackagesFromRepo(ctx, l.options.Path)
+	if err != nil {
+		return Result{}, err
+	}
 
 	// sort for consistent ordering
 	sortedNames := []string{}
@@ -52,69 +77,239 @@ func (l *Linter) Lint(ctx context.Context, minSeverity Severity) (Result, error)
 
 	sort.Strings(sortedNames)
 
-	for _, name := range sortedNames {
-		failedRules := make(EvalRuleErrors, 0)
-		for _, rule := range rules {
-			// Check if we should skip this rule.
-			shouldEvaluate := true
-			if len(rule.ConditionFuncs) > 0 {
-				for _, cond := range rule.ConditionFuncs {
-					if !cond() {
-						shouldEvaluate = false
-						break
-					}
-				}
+	repo := Repository{
+		Names:  sortedNames,
+		Inputs: make(map[string]Input, len(sortedNames)),
+	}
+	inputs := make([]Input, len(sortedNames))
+
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i, name := range sortedNames {
+		g.Go(func() error {
+			pkg := namesToPkg[name]
+			path := filepath.Join(pkg.Dir, pkg.Filename)
+			input, err := newInput(path, pkg.Config)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", path, err)
 			}
+			inputs[i] = input
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return Result{}, err
+	}
+	for i, name := range sortedNames {
+		repo.Inputs[name] = inputs[i]
+	}
+	repo.applies = func(rule, name string) bool {
+		if rel, err := filepath.Rel(l.Dir(), repo.Inputs[name].Path); err == nil && !l.options.Config.appliesTo(rule, rel) {
+			return false
+		}
+		return !slices.Contains(namesToPkg[name].NoLint, rule)
+	}
 
-			// If one of the conditions is not met we skip the evaluation process.
-			if !shouldEvaluate {
-				log.Debugf("%s: skipping rule %s because condition is not met\n", name, rule.Name)
-				continue
+	// Find the rules that apply to this run as a whole.
+	active := make(Rules, 0, len(rules))
+	for _, rule := range rules {
+		// Check if we should skip this rule.
+		shouldEvaluate := true
+		for _, cond := range rule.ConditionFuncs {
+			if !cond() {
+				shouldEvaluate = false
+				break
 			}
+		}
+
+		// If one of the conditions is not met we skip the evaluation process.
+		if !shouldEvaluate {
+			log.Debugf("skipping rule %s because condition is not met\n", rule.Name)
+			continue
+		}
+
+		// Allow users to override rules when running lint command
+		if slices.Contains(l.options.SkipRules, rule.Name) {
+			log.Debugf("skipping rule %s because --skip-rule flag set\n", rule.Name)
+			continue
+		}
+
+		active = append(active, rule)
+	}
+
+	// Repository rules look across all configurations, so they're evaluated up
+	// front and never cached.
+	repoViolations := map[string]map[string]error{}
+	for _, rule := range active {
+		if rule.RepositoryLintFunc != nil {
+			repoViolations[rule.Name] = rule.RepositoryLintFunc(repo)
+		}
+	}
 
-			// Allow users to override rules when running lint command
-			if slices.Contains(l.options.SkipRules, rule.Name) {
-				log.Debugf("%s: skipping rule %s because --skip-rule flag set\n", name, rule.Name)
-				continue
+	var cache *resultCache
+	if l.options.CacheDir != "" {
+		cache, err = newResultCache(l.options.CacheDir, active, l.options.Config, l.pipelines.digest+l.sources.fingerprint())
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	var selected map[string]bool
+	if l.options.Files != nil {
+		selected = make(map[string]bool, len(l.options.Files))
+		for _, f := range l.options.Files {
+			if abs, err := filepath.Abs(f); err == nil {
+				selected[abs] = true
 			}
+		}
+	}
 
-			if slices.Contains(namesToPkg[name].NoLint, rule.Name) {
-				log.Debugf("%s: skipping rule %s because file contains #nolint:%s\n", name, rule.Name, rule.Name)
-				continue
+	evalResults := make([]EvalResult, len(sortedNames))
+	for i, name := range sortedNames {
+		g.Go(func() error {
+			pkg := namesToPkg[name]
+			input := inputs[i]
+
+			if selected != nil {
+				if abs, err := filepath.Abs(input.Path); err != nil || !selected[abs] {
+					return nil
+				}
 			}
 
-			// Evaluate the rule.
-			if err := rule.LintFunc(namesToPkg[name].Config); err != nil {
+			applicable := make(Rules, 0, len(active))
+			for _, rule := range active {
+				if rel, err := filepath.Rel(l.Dir(), input.Path); err == nil && !l.options.Config.appliesTo(rule.Name, rel) {
+					log.Debugf("%s: skipping rule %s because the lint configuration excludes its path\n", name, rule.Name)
+					continue
+				}
+
+				if slices.Contains(pkg.NoLint, rule.Name) {
+					log.Debugf("%s: skipping rule %s because file contains #nolint:%s\n", name, rule.Name, rule.Name)
+					continue
+				}
+
+				applicable = append(applicable, rule)
+			}
+
+			violations, err := l.evalConfiguration(ctx, cache, applicable, input)
+			if err != nil {
+				return err
+			}
+
+			failedRules := make(EvalRuleErrors, 0)
+			for _, rule := range applicable {
+				err := violations[rule.Name]
+				if rule.RepositoryLintFunc != nil {
+					err = repoViolations[rule.Name][name]
+				}
+				if err == nil {
+					continue
+				}
+
 				// Only add to failedRules if the severity is inclusive of the minSeverity
 				if rule.Severity.Value <= minSeverity.Value {
 					msg := fmt.Sprintf("[%s]: %s (%s)", rule.Name, err.Error(), rule.Severity.Name)
 
 					failedRules = append(failedRules, EvalRuleError{
-						Rule:  rule,
-						Error: fmt.Errorf("%s", msg),
+						Rule:    rule,
+						Error:   fmt.Errorf("%s", msg),
+						Message: err.Error(),
+						Range:   rangeOf(err),
 					})
 				}
 			}
-		}
-		// If we have errors we append them to the result.
-		if failedRules.WrapErrors() != nil {
-			results = append(results, EvalResult{
+
+			evalResults[i] = EvalResult{
 				File:   name,
+				Path:   input.Path,
 				Errors: failedRules,
-			})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return Result{}, err
+	}
+
+	results := make(Result, 0)
+	for _, res := range evalResults {
+		// If we have errors we append them to the result.
+		if res.Errors.WrapErrors() != nil {
+			results = append(results, res)
 		}
 	}
 
 	return results, nil
 }
 
+// evalConfiguration evaluates the single-configuration rules among the given
+// rules against the configuration, returning the violations keyed by rule
+// name. If a cache is given, cached violations are used if there are any, and
+// new violations are cached.
+func (l *Linter) evalConfiguration(ctx context.Context, cache *resultCache, rules Rules, input Input) (map[string]error, error) {
+	log := clog.FromContext(ctx)
+
+	if cache != nil {
+		if violations, ok := cache.get(input); ok {
+			log.Debugf("%s: using cached lint results\n", input.Path)
+			return violations, nil
+		}
+	}
+
+	violations := map[string]error{}
+	for _, rule := range rules {
+		if rule.LintFunc == nil {
+			continue
+		}
+		if err := rule.LintFunc(input); err != nil {
+			violations[rule.Name] = err
+		}
+	}
+
+	if cache != nil {
+		if err := cache.put(input, violations); err != nil {
+			// The cache is only an optimization, so failing to write to it isn't fatal.
+			log.Warnf("caching lint results for %s: %v", input.Path, err)
+		}
+	}
+
+	return violations, nil
+}
+
+// Rules returns the rules that the linter evaluates, as set by the linter's
+// configuration.
+func (l *Linter) Rules() (Rules, error) {
+	return l.options.Config.apply(AllRules(l))
+}
+
+// hasSourceCache reports whether the linter checks fetch digests against a
+// source cache.
+func (l *Linter) hasSourceCache() bool {
+	return l.sources != nil
+}
+
+// lintsDirectory reports whether the linter's path is a directory of
+// configurations, rather than a single configuration file.
+func (l *Linter) lintsDirectory() bool {
+	fi, err := os.Stat(l.options.Path)
+	return err == nil && fi.IsDir()
+}
+
 func (l *Linter) Print(ctx context.Context, result Result) {
 	log := clog.FromContext(ctx)
 	foundAny := false
 	for _, res := range result {
 		if res.Errors.WrapErrors() != nil {
 			foundAny = true
-			log.Errorf("Package: %s: %s", res.File, res.Errors.WrapErrors())
+			errs := make([]string, 0, len(res.Errors))
+			for _, e := range res.Errors {
+				if e.Range != nil {
+					errs = append(errs, fmt.Sprintf("%s:%s: %s", res.Path, e.Range, e.Error))
+				} else {
+					errs = append(errs, e.Error.Error())
+				}
+			}
+			log.Errorf("Package: %s: %s", res.File, strings.Join(errs, "\n"))
 		}
 	}
 	if !foundAny {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 func newTestLinterWithDir(path string) *Linter {
@@ -54,6 +56,7 @@ func TestLinter_Dir(t *testing.T) {
 			want: Result{
 				{
 					File: "tld-swap",
+					Path: "testdata/dirs/tld-swap/tld-swap.yaml",
 					Errors: EvalRuleErrors{
 						EvalRuleError{
 							Rule: Rule{
@@ -61,7 +64,9 @@ func TestLinter_Dir(t *testing.T) {
 								Description: "every config should use a consistent hostname",
 								Severity:    SeverityError,
 							},
-							Error: fmt.Errorf("[uri-mimic]: \"test.org\" shares components with \"test.com\" (ERROR)"),
+							Error:   fmt.Errorf("[uri-mimic]: \"test.org\" shares components with \"test.com\" (ERROR)"),
+							Message: "\"test.org\" shares components with \"test.com\"",
+							Range:   &Range{Start: Position{Line: 15, Column: 12}, End: Position{Line: 15, Column: 74}},
 						},
 					},
 				},
@@ -74,6 +79,7 @@ func TestLinter_Dir(t *testing.T) {
 			want: Result{
 				{
 					File: "libssh2",
+					Path: "testdata/dirs/similar-domains/libssh2.yaml",
 					Errors: EvalRuleErrors{
 						EvalRuleError{
 							Rule: Rule{
@@ -81,7 +87,9 @@ func TestLinter_Dir(t *testing.T) {
 								Description: "every config should use a consistent hostname",
 								Severity:    SeverityError,
 							},
-							Error: fmt.Errorf("[uri-mimic]: \"www.libssh2.org\" too similar to \"www.libshh2.org\" (ERROR)"),
+							Error:   fmt.Errorf("[uri-mimic]: \"www.libssh2.org\" too similar to \"www.libshh2.org\" (ERROR)"),
+							Message: "\"www.libssh2.org\" too similar to \"www.libshh2.org\"",
+							Range:   &Range{Start: Position{Line: 14, Column: 12}, End: Position{Line: 14, Column: 63}},
 						},
 					},
 				},
@@ -97,9 +105,102 @@ func TestLinter_Dir(t *testing.T) {
 				t.Errorf("Lint() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			if diff := cmp.Diff(got, tt.want, EquateErrorsByString(), cmpopts.IgnoreFields(Rule{}, "LintFunc")); diff != "" {
+			if diff := cmp.Diff(got, tt.want, EquateErrorsByString(), cmpopts.IgnoreFields(Rule{}, "LintFunc", "RepositoryLintFunc", "Parameters")); diff != "" {
 				t.Errorf("unexpected diff: %s\ngot: %+v", diff, got)
 			}
 		})
 	}
 }
+
+func TestLinter_RepositoryRules(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLinterWithDir("dirs/repository/")
+	result, err := l.Lint(ctx, SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repositoryRules := []string{"no-duplicate-subpackages", "no-provides-collision", "dependencies-provided"}
+	got := map[string][]string{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			if slices.Contains(repositoryRules, e.Rule.Name) {
+				got[res.File] = append(got[res.File], fmt.Sprintf("%s: %s (line %d)", e.Rule.Name, e.Message, e.Range.Start.Line))
+			}
+		}
+	}
+
+	want := map[string][]string{
+		"alpha": {
+			"no-duplicate-subpackages: subpackage shared is also defined by beta (line 20)",
+			"no-provides-collision: virt is also provided by gamma, and not every provider sets a provider-priority (line 14)",
+		},
+		"beta": {
+			"no-duplicate-subpackages: subpackage shared is also defined by alpha (line 9)",
+			"no-provides-collision: alpha is provided, but it's also a package defined by alpha (line 13)",
+		},
+		"gamma": {
+			"no-provides-collision: virt is also provided by alpha, and not every provider sets a provider-priority (line 12)",
+			"dependencies-provided: missing is not provided by any config (line 10)",
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinter_RepositoryRules_SingleFile(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLinterWithDir("dirs/repository/gamma.yaml")
+	result, err := l.Lint(ctx, SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dependencies can't be checked without the rest of the repository.
+	for _, res := range result {
+		for _, e := range res.Errors {
+			if e.Rule.Name == "dependencies-provided" {
+				t.Errorf("unexpected violation: %s", e.Error)
+			}
+		}
+	}
+}
+
+func TestLinter_Files(t *testing.T) {
+	ctx := context.Background()
+	l := New(WithPath("testdata/dirs/repository/"), WithFiles([]string{"testdata/dirs/repository/gamma.yaml"}))
+	result, err := l.Lint(ctx, SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only gamma is reported, but the repository rules still see the other
+	// configurations.
+	got := map[string][]string{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			got[res.File] = append(got[res.File], e.Rule.Name)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"gamma"}, maps.Keys(got)); diff != "" {
+		t.Errorf("unexpected diff (-want +got):\n%s", diff)
+	}
+	for _, rule := range []string{"no-provides-collision", "dependencies-provided"} {
+		if !slices.Contains(got["gamma"], rule) {
+			t.Errorf("expected a violation of %s, got %v", rule, got["gamma"])
+		}
+	}
+
+	// An empty list of files means there's nothing to report.
+	l = New(WithPath("testdata/dirs/repository/"), WithFiles([]string{}))
+	result, err = l.Lint(ctx, SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected no results, got %v", result)
+	}
+}
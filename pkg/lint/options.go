@@ -7,6 +7,30 @@ type Options struct {
 
 	// Skip rules removes the given slice of rules to be checked
 	SkipRules []string
+
+	// Config configures the rules. If nil, all rules are enabled with their
+	// defaults.
+	Config *Config
+
+	// CacheDir is the directory in which the results of linting configuration
+	// files are cached. If empty, results aren't cached.
+	CacheDir string
+
+	// Files, if not nil, restricts the results to the configuration files at
+	// these paths. All of the configurations are still read, so that repository
+	// rules can check the given files against the rest of the repository.
+	Files []string
+
+	// PipelineDirs are the directories that define the pipelines that
+	// configurations can use, in addition to melange's built-in pipelines. If
+	// nil, the "pipelines" directory in the directory being linted is used, if
+	// there is one.
+	PipelineDirs []string
+
+	// SourceCacheDir is a directory of source artifacts, like melange's source
+	// cache. If set, the expected digests of fetch steps are checked against the
+	// artifacts in it, and can be fixed from them.
+	SourceCacheDir string
 }
 
 // Option represents a linter option.
@@ -25,3 +49,41 @@ func WithSkipRules(skipRules []string) Option {
 		o.SkipRules = skipRules
 	}
 }
+
+// WithConfig sets the configuration of the rules.
+func WithConfig(cfg *Config) Option {
+	return func(o *Options) {
+		o.Config = cfg
+	}
+}
+
+// WithCacheDir sets the directory in which lint results are cached.
+func WithCacheDir(dir string) Option {
+	return func(o *Options) {
+		o.CacheDir = dir
+	}
+}
+
+// WithFiles restricts the results to the configuration files at the given
+// paths.
+func WithFiles(files []string) Option {
+	return func(o *Options) {
+		o.Files = files
+	}
+}
+
+// WithPipelineDirs sets the directories that define the pipelines that
+// configurations can use.
+func WithPipelineDirs(dirs []string) Option {
+	return func(o *Options) {
+		o.PipelineDirs = dirs
+	}
+}
+
+// WithSourceCacheDir sets the directory of source artifacts that the expected
+// digests of fetch steps are checked against.
+func WithSourceCacheDir(dir string) Option {
+	return func(o *Options) {
+		o.SourceCacheDir = dir
+	}
+}
@@ -0,0 +1,278 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"embed"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"chainguard.dev/melange/pkg/config"
+	"github.com/texttheater/golang-levenshtein/levenshtein"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinPipelines are melange's built-in pipelines, copied from the version of
+// the melange module that wolfictl depends on by "go generate". A test fails
+// when they differ from melange's, so they're kept up to date as the module
+// is. A definition without an "inputs" key only records that the pipeline
+// exists, and its inputs aren't checked.
+//
+//go:generate go test -run ^TestBuiltinPipelines$ -update-pipelines .
+//go:embed pipelines
+var builtinPipelines embed.FS
+
+// pipelineDefinition is the part of a pipeline definition that the linter
+// checks "uses" steps against.
+type pipelineDefinition struct {
+	// Inputs are the pipeline's inputs, or nil if they aren't known.
+	Inputs map[string]config.Input `yaml:"inputs"`
+
+	// Deprecated, if set, explains why the pipeline shouldn't be used anymore,
+	// and what to use instead. melange ignores this key.
+	Deprecated string `yaml:"deprecated,omitempty"`
+}
+
+// pipelineCatalog is the set of pipelines that configurations can use.
+type pipelineCatalog struct {
+	definitions map[string]pipelineDefinition
+
+	// digest identifies the definitions, so that cached lint results are
+	// invalidated when they change.
+	digest string
+}
+
+// loadPipelines loads the built-in pipelines and then those in the given
+// directories, where a pipeline named "foo/bar" is defined in "foo/bar.yaml".
+// Like in melange, pipelines in the directories take precedence over built-in
+// pipelines. Directories that don't exist are skipped.
+func loadPipelines(dirs []string) (*pipelineCatalog, error) {
+	c := &pipelineCatalog{definitions: map[string]pipelineDefinition{}}
+	h := sha256.New()
+
+	builtin, err := fs.Sub(builtinPipelines, "pipelines")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.add(builtin, h); err != nil {
+		return nil, fmt.Errorf("loading built-in pipelines: %w", err)
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := c.add(os.DirFS(dir), h); err != nil {
+			return nil, fmt.Errorf("loading pipelines from %s: %w", dir, err)
+		}
+	}
+
+	c.digest = fmt.Sprintf("%x", h.Sum(nil))
+	return c, nil
+}
+
+// add adds the pipelines defined in fsys, and writes their definitions to h.
+func (c *pipelineCatalog) add(fsys fs.FS, h hash.Hash) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".yaml") {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		var def pipelineDefinition
+		if err := yaml.Unmarshal(b, &def); err != nil {
+			return fmt.Errorf("decoding %s: %w", p, err)
+		}
+
+		name := strings.TrimSuffix(p, ".yaml")
+		c.definitions[name] = def
+		fmt.Fprintf(h, "%s\n%x\n", name, sha256.Sum256(b))
+		return nil
+	})
+}
+
+// get returns the definition of the named pipeline, and whether there is one.
+func (c *pipelineCatalog) get(name string) (pipelineDefinition, bool) {
+	def, ok := c.definitions[name]
+	return def, ok
+}
+
+// closest returns the candidate that's closest to name, if it's close enough
+// to be a likely typo of it.
+func closest(name string, candidates []string) (string, bool) {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		dist := levenshtein.DistanceForStrings([]rune(name), []rune(c), levenshtein.DefaultOptions)
+		if dist < bestDist || (dist == bestDist && best != "" && c < best) {
+			best, bestDist = c, dist
+		}
+	}
+
+	return best, best != ""
+}
+
+// pipelineStep is a step of a pipeline in a configuration.
+type pipelineStep struct {
+	config.Pipeline
+
+	// path is the step's path in the configuration (see Input.NodeAt).
+	path []any
+}
+
+// pipelineSteps returns the steps of all of the configuration's pipelines,
+// including those of subpackages and tests, and steps nested in other steps.
+func pipelineSteps(in Input) []pipelineStep {
+	var steps []pipelineStep
+
+	var add func(pipeline []config.Pipeline, path ...any)
+	add = func(pipeline []config.Pipeline, path ...any) {
+		for i, p := range pipeline {
+			stepPath := append(append([]any{}, path...), i)
+			steps = append(steps, pipelineStep{Pipeline: p, path: stepPath})
+			add(p.Pipeline, append(stepPath, "pipeline")...)
+		}
+	}
+
+	add(in.Pipeline, "pipeline")
+	if in.Test != nil {
+		add(in.Test.Pipeline, "test", "pipeline")
+	}
+	for i, sp := range in.Subpackages {
+		add(sp.Pipeline, "subpackages", i, "pipeline")
+		if sp.Test != nil {
+			add(sp.Test.Pipeline, "subpackages", i, "test", "pipeline")
+		}
+	}
+
+	return steps
+}
+
+// usedPipeline is a pipeline step that uses a pipeline from the catalog.
+type usedPipeline struct {
+	pipelineStep
+	definition pipelineDefinition
+}
+
+// usedPipelines returns the configuration's steps that use pipelines defined in
+// the catalog. Steps whose "uses" is templated are left out.
+func (c *pipelineCatalog) usedPipelines(in Input) []usedPipeline {
+	if c == nil {
+		return nil
+	}
+
+	var used []usedPipeline
+	for _, step := range pipelineSteps(in) {
+		if step.Uses == "" || strings.Contains(step.Uses, "${{") {
+			continue
+		}
+		if def, ok := c.get(step.Uses); ok {
+			used = append(used, usedPipeline{pipelineStep: step, definition: def})
+		}
+	}
+
+	return used
+}
+
+// validPipelineUsesParameters are the parameters of the valid-pipeline-uses
+// rule.
+type validPipelineUsesParameters struct {
+	// KnownPipelines are the names of pipelines that are known to exist even
+	// though they aren't defined in the linter's pipeline catalog, such as
+	// built-in pipelines added in newer versions of melange.
+	KnownPipelines []string `yaml:"known-pipelines"`
+}
+
+// checkPipelineUses checks that the pipelines used by the configuration exist.
+func checkPipelineUses(in Input, c *pipelineCatalog, params *validPipelineUsesParameters) error {
+	if c == nil {
+		return nil
+	}
+
+	for _, step := range pipelineSteps(in) {
+		if step.Uses == "" || strings.Contains(step.Uses, "${{") {
+			continue
+		}
+		if _, ok := c.get(step.Uses); ok || slices.Contains(params.KnownPipelines, step.Uses) {
+			continue
+		}
+
+		node := in.NodeAt(append(step.path, "uses")...)
+		if suggestion, ok := closest(step.Uses, maps.Keys(c.definitions)); ok {
+			return violation(node, "unknown pipeline %q, did you mean %q?", step.Uses, suggestion)
+		}
+		return violation(node, "unknown pipeline %q", step.Uses)
+	}
+
+	return nil
+}
+
+// checkPipelineRequiredInputs checks that the configuration sets the required
+// inputs of the pipelines it uses.
+func checkPipelineRequiredInputs(in Input, c *pipelineCatalog) error {
+	for _, used := range c.usedPipelines(in) {
+		names := maps.Keys(used.definition.Inputs)
+		sort.Strings(names)
+		for _, name := range names {
+			input := used.definition.Inputs[name]
+			if !input.Required || input.Default != "" {
+				continue
+			}
+			if _, ok := used.With[name]; !ok {
+				return violation(in.NodeAt(append(used.path, "uses")...), "pipeline %q requires input %q", used.Uses, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPipelineInputs checks that the configuration only sets inputs that the
+// pipelines it uses declare.
+func checkPipelineInputs(in Input, c *pipelineCatalog) error {
+	for _, used := range c.usedPipelines(in) {
+		if used.definition.Inputs == nil {
+			continue
+		}
+
+		names := maps.Keys(used.With)
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := used.definition.Inputs[name]; ok {
+				continue
+			}
+
+			node := in.NodeAt(append(used.path, "with", name)...)
+			if suggestion, ok := closest(name, maps.Keys(used.definition.Inputs)); ok {
+				return violation(node, "pipeline %q has no input %q, did you mean %q?", used.Uses, name, suggestion)
+			}
+			return violation(node, "pipeline %q has no input %q", used.Uses, name)
+		}
+	}
+
+	return nil
+}
+
+// checkDeprecatedPipelines checks that the configuration doesn't use deprecated
+// pipelines.
+func checkDeprecatedPipelines(in Input, c *pipelineCatalog) error {
+	for _, used := range c.usedPipelines(in) {
+		if used.definition.Deprecated != "" {
+			return violation(in.NodeAt(append(used.path, "uses")...), "pipeline %q is deprecated: %s", used.Uses, strings.TrimSpace(used.definition.Deprecated))
+		}
+	}
+
+	return nil
+}
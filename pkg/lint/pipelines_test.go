@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+)
+
+var pipelineRules = []string{"valid-pipeline-uses", "valid-pipeline-required-inputs", "valid-pipeline-inputs", "no-deprecated-pipelines"}
+
+func lintPipelines(t *testing.T, opts ...Option) map[string][]string {
+	t.Helper()
+
+	l := New(append([]Option{WithPath("testdata/dirs/pipelines")}, opts...)...)
+	result, err := l.Lint(context.Background(), SeverityInfo)
+	require.NoError(t, err)
+
+	got := map[string][]string{}
+	for _, res := range result {
+		for _, e := range res.Errors {
+			if slices.Contains(pipelineRules, e.Rule.Name) {
+				got[res.File] = append(got[res.File], fmt.Sprintf("%s: %s (%s)", e.Rule.Name, e.Message, e.Range))
+			}
+		}
+	}
+	return got
+}
+
+func TestLinter_Pipelines(t *testing.T) {
+	want := map[string][]string{
+		"unknown-pipeline": {
+			`valid-pipeline-uses: unknown pipeline "fetchh", did you mean "fetch"? (9:11)`,
+		},
+		"missing-input": {
+			`valid-pipeline-required-inputs: pipeline "local/build" requires input "target" (9:11)`,
+		},
+		"unknown-input": {
+			`valid-pipeline-inputs: pipeline "fetch" has no input "expected-sha265", did you mean "expected-sha256"? (12:24)`,
+		},
+		"deprecated-pipeline": {
+			`no-deprecated-pipelines: pipeline "old" is deprecated: use local/build instead (9:11)`,
+		},
+	}
+
+	if diff := cmp.Diff(want, lintPipelines(t)); diff != "" {
+		t.Errorf("unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinter_Pipelines_Options(t *testing.T) {
+	t.Run("pipeline dirs", func(t *testing.T) {
+		// Without the repository's pipelines, its local pipelines are unknown.
+		got := lintPipelines(t, WithPipelineDirs([]string{}))
+		assert.Equal(t, []string{`valid-pipeline-uses: unknown pipeline "local/build" (9:11)`}, got["missing-input"])
+		assert.Equal(t, []string{`valid-pipeline-uses: unknown pipeline "local/build" (13:11)`}, got["valid-pipelines"])
+	})
+
+	t.Run("known pipelines", func(t *testing.T) {
+		cfg := readTestConfig(t, `
+rules:
+  valid-pipeline-uses:
+    with:
+      known-pipelines:
+        - fetchh
+`)
+		got := lintPipelines(t, WithConfig(cfg))
+		assert.Empty(t, got["unknown-pipeline"])
+	})
+}
+
+func TestLoadPipelines(t *testing.T) {
+	builtin, err := loadPipelines(nil)
+	require.NoError(t, err)
+
+	fetch, ok := builtin.get("fetch")
+	require.True(t, ok)
+	assert.True(t, fetch.Inputs["uri"].Required)
+
+	local, err := loadPipelines([]string{"testdata/dirs/pipelines/pipelines", "testdata/no-such-dir"})
+	require.NoError(t, err)
+	_, ok = local.get("local/build")
+	assert.True(t, ok)
+	assert.NotEqual(t, builtin.digest, local.digest)
+
+	// Pipelines whose inputs aren't described are known to exist, but their
+	// inputs aren't checked.
+	old, ok := local.get("old")
+	require.True(t, ok)
+	assert.Nil(t, old.Inputs)
+}
+
+var updatePipelines = flag.Bool("update-pipelines", false, "copy melange's built-in pipelines to the pipelines directory instead of comparing them")
+
+// melangePipelines returns melange's built-in pipelines, from the version of
+// the melange module that wolfictl depends on.
+func melangePipelines(t *testing.T) fs.FS {
+	t.Helper()
+
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "chainguard.dev/melange").Output()
+	require.NoError(t, err, "locating the melange module")
+	dir := strings.TrimSpace(string(out))
+	require.NotEmpty(t, dir, "the melange module hasn't been downloaded")
+
+	return os.DirFS(filepath.Join(dir, "pkg", "build", "pipelines"))
+}
+
+// readPipelineFiles returns the contents of the pipeline definitions in fsys,
+// keyed by path.
+func readPipelineFiles(t *testing.T, fsys fs.FS) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".yaml") {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		files[p] = string(b)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return files
+}
+
+func TestBuiltinPipelines(t *testing.T) {
+	want := readPipelineFiles(t, melangePipelines(t))
+
+	if *updatePipelines {
+		require.NoError(t, os.RemoveAll("pipelines"))
+		for p, content := range want {
+			path := filepath.Join("pipelines", filepath.FromSlash(p))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644)) //nolint:gosec // The pipelines are checked in.
+		}
+		return
+	}
+
+	builtin, err := fs.Sub(builtinPipelines, "pipelines")
+	require.NoError(t, err)
+	if diff := cmp.Diff(want, readPipelineFiles(t, builtin)); diff != "" {
+		t.Errorf("built-in pipelines differ from melange's, run \"go generate ./pkg/lint\" (-melange +built-in):\n%s", diff)
+	}
+}
@@ -0,0 +1,232 @@
+package lint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"chainguard.dev/melange/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in a configuration file. Lines and columns start at
+// 1. A column of 0 means the column isn't known.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+// Range is a span of a configuration file.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// String returns the range's start as "line:column".
+func (r Range) String() string {
+	if r.Start.Column == 0 {
+		return fmt.Sprintf("%d", r.Start.Line)
+	}
+	return fmt.Sprintf("%d:%d", r.Start.Line, r.Start.Column)
+}
+
+// Violation is an error returned by a rule that identifies where in the
+// configuration file the rule is violated.
+type Violation struct {
+	// Range is the part of the configuration file that violates the rule.
+	Range Range
+
+	// Err describes the violation.
+	Err error
+}
+
+func (v *Violation) Error() string {
+	return v.Err.Error()
+}
+
+func (v *Violation) Unwrap() error {
+	return v.Err
+}
+
+// newInput reads the configuration file at the given path to build the Input
+// for the given decoded configuration.
+func newInput(path string, cfg config.Configuration) (Input, error) {
+	in := Input{
+		Configuration: cfg,
+		Path:          path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return in, err
+	}
+	in.lines = strings.Split(string(data), "\n")
+	in.hash = fmt.Sprintf("%x", sha256.Sum256(data))
+
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		// Not being able to parse the file here isn't fatal, since the configuration
+		// has already been decoded. Violations just won't have positions.
+		return in, nil
+	}
+	if len(doc.Content) > 0 {
+		in.AST = doc.Content[0]
+	}
+
+	return in, nil
+}
+
+// NodeAt returns the node at the given path in the configuration file's AST.
+// Each path element is either a mapping key (a string) or a sequence index (an
+// int). If the path can't be followed all the way, the deepest node found
+// along the way is returned, so that violations still point as close as
+// possible to the problem. NodeAt returns nil if there's no AST.
+func (in Input) NodeAt(path ...any) *yaml.Node {
+	node := in.AST
+	if node == nil {
+		return nil
+	}
+
+	for _, elem := range path {
+		next := childNode(node, elem)
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+
+	return node
+}
+
+func childNode(node *yaml.Node, elem any) *yaml.Node {
+	switch e := elem.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == e {
+				return node.Content[i+1]
+			}
+		}
+
+	case int:
+		if node.Kind != yaml.SequenceNode || e < 0 || e >= len(node.Content) {
+			return nil
+		}
+		return node.Content[e]
+	}
+
+	return nil
+}
+
+// violation returns an error for a violation at the given node. If the node is
+// nil, the error has no position.
+func violation(node *yaml.Node, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if node == nil {
+		return err
+	}
+
+	return &Violation{
+		Range: nodeRange(node),
+		Err:   err,
+	}
+}
+
+// violationInLine returns an error for a violation on a single line of the
+// given (possibly multi-line) scalar node, such as a pipeline step's "runs".
+// The line is identified by its index within the scalar's value. If the
+// line's position can't be determined, the violation covers the whole node.
+func (in Input) violationInLine(node *yaml.Node, lineIndex int, format string, args ...any) error {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return violation(node, format, args...)
+	}
+
+	var line int
+	switch node.Style {
+	case yaml.LiteralStyle:
+		// The content of a literal block scalar starts on the line after the
+		// indicator, and its lines correspond one-to-one with the value's lines.
+		line = node.Line + 1 + lineIndex
+
+	case 0, yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		if lineIndex != 0 || strings.Contains(node.Value, "\n") {
+			return violation(node, format, args...)
+		}
+		line = node.Line
+
+	default:
+		return violation(node, format, args...)
+	}
+
+	if line < 1 || line > len(in.lines) {
+		return violation(node, format, args...)
+	}
+
+	text := in.lines[line-1]
+	indent := len(text) - len(strings.TrimLeft(text, " \t"))
+	return &Violation{
+		Range: Range{
+			Start: Position{Line: line, Column: indent + 1},
+			End:   Position{Line: line, Column: utf8.RuneCountInString(strings.TrimRight(text, " \t\r")) + 1},
+		},
+		Err: fmt.Errorf(format, args...),
+	}
+}
+
+// nodeRange returns the range of the configuration file covered by the node.
+func nodeRange(node *yaml.Node) Range {
+	return Range{
+		Start: Position{Line: node.Line, Column: node.Column},
+		End:   nodeEnd(node),
+	}
+}
+
+func nodeEnd(node *yaml.Node) Position {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.Style {
+		case yaml.LiteralStyle, yaml.FoldedStyle:
+			// The block's content follows the indicator line, but its indentation isn't
+			// recorded in the node, so the end column isn't known.
+			n := strings.Count(strings.TrimRight(node.Value, "\n"), "\n") + 1
+			return Position{Line: node.Line + n}
+
+		case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+			if !strings.Contains(node.Value, "\n") {
+				return Position{Line: node.Line, Column: node.Column + utf8.RuneCountInString(node.Value) + 2}
+			}
+
+		default:
+			if !strings.Contains(node.Value, "\n") {
+				return Position{Line: node.Line, Column: node.Column + utf8.RuneCountInString(node.Value)}
+			}
+		}
+
+		return Position{Line: node.Line}
+
+	case yaml.MappingNode, yaml.SequenceNode, yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return Position{Line: node.Line, Column: node.Column}
+		}
+		return nodeEnd(node.Content[len(node.Content)-1])
+	}
+
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+// rangeOf returns the range attached to the error, if any.
+func rangeOf(err error) *Range {
+	var v *Violation
+	if errors.As(err, &v) {
+		r := v.Range
+		return &r
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package lint
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/texttheater/golang-levenshtein/levenshtein"
+	"golang.org/x/exp/slices"
+)
+
+// checkURIMimic checks the fetch URIs of the configuration against the given
+// hosts, which have been seen in other configurations, and then adds the
+// configuration's hosts to them.
+func checkURIMimic(in Input, seenHosts map[string]bool, params *uriMimicParameters) error {
+	for i, p := range in.Pipeline {
+		uri := p.With["uri"]
+		if uri == "" {
+			continue
+		}
+		u, err := url.ParseRequestURI(uri)
+		if err != nil {
+			// This condition is picked up by valid-pipeline-fetch-uri
+			return nil
+		}
+		host := u.Host
+		if seenHosts[host] {
+			continue
+		}
+		for k := range seenHosts {
+			// If this becomes a problem, we should filter out hosts that exist in >1 package
+			dist := levenshtein.DistanceForStrings([]rune(host), []rune(k), levenshtein.DefaultOptions)
+			if params.HostEditDistanceExceptions[host] == k || params.HostEditDistanceExceptions[k] == host {
+				continue
+			}
+			if dist <= params.MinHostEditDistance {
+				return violation(in.NodeAt("pipeline", i, "with", "uri"), "%q too similar to %q", host, k)
+			}
+
+			// Detect TLD swaps
+			hostParts := strings.Split(host, ".")
+			kParts := strings.Split(k, ".")
+			if strings.Join(hostParts[:len(hostParts)-1], ".") == strings.Join(kParts[:len(kParts)-1], ".") {
+				return violation(in.NodeAt("pipeline", i, "with", "uri"), "%q shares components with %q", host, k)
+			}
+		}
+		seenHosts[host] = true
+	}
+	return nil
+}
+
+// resolvePackageName substitutes the variables in a package or subpackage
+// name that can be known without building the package. It returns false if the
+// name still has variables in it, such as those of a ranged subpackage.
+func resolvePackageName(in Input, name string) (string, bool) {
+	name = strings.NewReplacer(
+		"${{package.name}}", in.Package.Name,
+		"${{package.version}}", in.Package.Version,
+	).Replace(name)
+
+	return name, !strings.Contains(name, "${{")
+}
+
+// packageNames returns the names of the package and subpackages that the
+// configuration defines, leaving out names that can't be resolved.
+func packageNames(in Input) []string {
+	names := []string{in.Package.Name}
+	for _, sp := range in.Subpackages {
+		if name, ok := resolvePackageName(in, sp.Name); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// otherThan returns the elements of names that aren't name, without
+// duplicates.
+func otherThan(names []string, name string) []string {
+	var others []string
+	for _, n := range names {
+		if n != name && !slices.Contains(others, n) {
+			others = append(others, n)
+		}
+	}
+
+	return others
+}
+
+// countOf returns the number of times name appears in names.
+func countOf(names []string, name string) int {
+	n := 0
+	for _, e := range names {
+		if e == name {
+			n++
+		}
+	}
+
+	return n
+}
+
+// provided is a "provides" entry of a package or subpackage.
+type provided struct {
+	// name is the provided name, without a version.
+	name string
+
+	// config is the name of the package whose configuration has the entry.
+	config string
+
+	// prioritized is true if the package or subpackage sets a provider-priority.
+	prioritized bool
+
+	// versioned is true if the entry provides a specific version of the name,
+	// like "go=1.22", as the packages of versioned streams do.
+	versioned bool
+
+	// path is the entry's path in the configuration (see Input.NodeAt).
+	path []any
+}
+
+// providesOf returns the resolvable "provides" entries of the configuration's
+// package and subpackages.
+func providesOf(in Input) []provided {
+	var provides []provided
+
+	for j, p := range in.Package.Dependencies.Provides {
+		if name, ok := resolvePackageName(in, dependencyName(p)); ok {
+			provides = append(provides, provided{
+				name:        name,
+				config:      in.Package.Name,
+				prioritized: in.Package.Dependencies.ProviderPriority != "",
+				versioned:   strings.Contains(p, "="),
+				path:        []any{"package", "dependencies", "provides", j},
+			})
+		}
+	}
+
+	for i, sp := range in.Subpackages {
+		for j, p := range sp.Dependencies.Provides {
+			if name, ok := resolvePackageName(in, dependencyName(p)); ok {
+				provides = append(provides, provided{
+					name:        name,
+					config:      in.Package.Name,
+					prioritized: sp.Dependencies.ProviderPriority != "",
+					versioned:   strings.Contains(p, "="),
+					path:        []any{"subpackages", i, "dependencies", "provides", j},
+				})
+			}
+		}
+	}
+
+	return provides
+}
+
+// reVersionConstraint matches the version constraint of a dependency, such as
+// "=1.2.3-r0" or ">=1.2".
+var reVersionConstraint = regexp.MustCompile(`[=<>~].*$`)
+
+// dependencyName returns the package name of a dependency or "provides" entry,
+// without any version constraint.
+func dependencyName(dep string) string {
+	return reVersionConstraint.ReplaceAllString(dep, "")
+}
+
+// dependency is a build or runtime dependency of a package or subpackage.
+type dependency struct {
+	// name is the name of the depended-on package, without a version
+	// constraint.
+	name string
+
+	// path is the dependency's path in the configuration (see Input.NodeAt).
+	path []any
+}
+
+// dependenciesOf returns the build environment packages and runtime
+// dependencies of the configuration that refer to packages by name. Virtual
+// dependencies (like "so:libc.so.6"), which are provided by the contents of
+// built packages, and dependencies on tagged repositories are left out.
+func dependenciesOf(in Input) []dependency {
+	var deps []dependency
+
+	add := func(dep string, path ...any) {
+		if strings.Contains(dep, ":") || strings.Contains(dep, "@") {
+			return
+		}
+		name, ok := resolvePackageName(in, dependencyName(dep))
+		if !ok || name == "" {
+			return
+		}
+		deps = append(deps, dependency{name: name, path: path})
+	}
+
+	for i, dep := range in.Environment.Contents.Packages {
+		add(dep, "environment", "contents", "packages", i)
+	}
+	for i, dep := range in.Package.Dependencies.Runtime {
+		add(dep, "package", "dependencies", "runtime", i)
+	}
+	for i, sp := range in.Subpackages {
+		for j, dep := range sp.Dependencies.Runtime {
+			add(dep, "subpackages", i, "dependencies", "runtime", j)
+		}
+	}
+
+	return deps
+}
+
+// availablePackages are the names of the packages that can be installed from
+// the packages built from a repository's configurations.
+type availablePackages struct {
+	names map[string]bool
+
+	// patterns match the names of subpackages and provides that can't be fully
+	// resolved, such as those of ranged subpackages.
+	patterns []*regexp.Regexp
+}
+
+// add adds the packages defined and provided by the configuration.
+func (a *availablePackages) add(in Input) {
+	if a.names == nil {
+		a.names = map[string]bool{}
+	}
+
+	names := []string{in.Package.Name}
+	names = append(names, in.Package.Dependencies.Provides...)
+	for _, sp := range in.Subpackages {
+		names = append(names, sp.Name)
+		names = append(names, sp.Dependencies.Provides...)
+	}
+
+	for _, n := range names {
+		name, ok := resolvePackageName(in, dependencyName(n))
+		if ok {
+			a.names[name] = true
+			continue
+		}
+
+		parts := strings.Split(name, "${{")
+		for i := range parts {
+			if i > 0 {
+				_, parts[i], _ = strings.Cut(parts[i], "}}")
+			}
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		a.patterns = append(a.patterns, regexp.MustCompile("^"+strings.Join(parts, ".+")+"$"))
+	}
+}
+
+// has reports whether the named package is available.
+func (a *availablePackages) has(name string) bool {
+	if a.names[name] {
+		return true
+	}
+	for _, re := range a.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
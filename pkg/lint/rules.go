@@ -3,18 +3,17 @@ package lint
 import (
 	"fmt"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 
-	"chainguard.dev/melange/pkg/renovate"
 	"github.com/github/go-spdx/v2/spdxexp"
-	"github.com/texttheater/golang-levenshtein/levenshtein"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
 	"github.com/wolfi-dev/wolfictl/pkg/versions"
 
 	"github.com/dprotaso/go-yit"
 	"gopkg.in/yaml.v3"
 
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"chainguard.dev/melange/pkg/config"
@@ -57,15 +56,25 @@ var (
 		"https://packages.wolfi.dev/os/wolfi-signing.rsa.pub",
 	}
 
-	// Used for comparing hosts between configs
-	seenHosts = map[string]bool{}
-	// The minimum edit distance between two hostnames
+	// The minimum edit distance between two hostnames, by default
 	minhostEditDistance = 2
-	// Exceptions to the above rule
+	// Exceptions to the above rule, by default
 	hostEditDistanceExceptions = map[string]string{
 		"www.libssh.org": "www.libssh2.org",
 	}
 
+	// Packages that builds are bootstrapped from, which needn't be provided by
+	// the repository, by default
+	bootstrapPackages = []string{
+		"busybox",
+		"build-base",
+		"ca-certificates-bundle",
+		"glibc",
+		"glibc-dev",
+		"wolfi-base",
+		"wolfi-baselayout",
+	}
+
 	// Detect background processes (commands ending with '&' or '& sleep ...') or daemonized commands
 	// reBackgroundProcess detects background processes (commands ending with '&' or '& sleep ...')
 	// We explicitly avoid matching '&&' which is commonly used for command chaining.
@@ -77,22 +86,75 @@ var (
 
 const gitCheckout = "git-checkout"
 
+// forbiddenRepositoriesParameters are the parameters of the
+// forbidden-repository-used rule.
+type forbiddenRepositoriesParameters struct {
+	Repositories []string `yaml:"repositories"`
+}
+
+// forbiddenKeyringsParameters are the parameters of the forbidden-keyring-used
+// rule.
+type forbiddenKeyringsParameters struct {
+	Keyrings []string `yaml:"keyrings"`
+}
+
+// uriMimicParameters are the parameters of the uri-mimic rule.
+type uriMimicParameters struct {
+	// MinHostEditDistance is the edit distance at or below which two hostnames
+	// are considered too similar.
+	MinHostEditDistance int `yaml:"min-host-edit-distance"`
+
+	// HostEditDistanceExceptions are pairs of hostnames that are allowed to be
+	// similar.
+	HostEditDistanceExceptions map[string]string `yaml:"host-edit-distance-exceptions"`
+}
+
+// dependenciesProvidedParameters are the parameters of the
+// dependencies-provided rule.
+type dependenciesProvidedParameters struct {
+	// Exempt are the names of packages that can be depended on without being
+	// provided by a config in the repository, like those of the base OS.
+	Exempt []string `yaml:"exempt"`
+}
+
 // AllRules is a list of all available rules to evaluate.
 var AllRules = func(l *Linter) Rules { //nolint:gocyclo
+	forbiddenRepos := &forbiddenRepositoriesParameters{
+		Repositories: slices.Clone(forbiddenRepositories),
+	}
+	forbiddenKeys := &forbiddenKeyringsParameters{
+		Keyrings: slices.Clone(forbiddenKeyrings),
+	}
+	uriMimic := &uriMimicParameters{
+		MinHostEditDistance:        minhostEditDistance,
+		HostEditDistanceExceptions: maps.Clone(hostEditDistanceExceptions),
+	}
+	dependenciesProvided := &dependenciesProvidedParameters{
+		Exempt: slices.Clone(bootstrapPackages),
+	}
+	pipelineUses := &validPipelineUsesParameters{}
+
+	// Fetch digests can only be fixed from a source cache.
+	var fixDigests configs.YAMLASTMutater[config.Configuration]
+	if l.sources != nil {
+		fixDigests = l.sources.fixDigests
+	}
+
 	return Rules{
 		{
 			Name:        "forbidden-repository-used",
 			Description: "do not specify a forbidden repository",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, repo := range config.Environment.Contents.BuildRepositories {
-					if slices.Contains(forbiddenRepositories, repo) {
-						return fmt.Errorf("forbidden repository %s is used", repo)
+			Parameters:  forbiddenRepos,
+			LintFunc: func(config Input) error {
+				for i, repo := range config.Environment.Contents.BuildRepositories {
+					if slices.Contains(forbiddenRepos.Repositories, repo) {
+						return violation(config.NodeAt("environment", "contents", "build_repositories", i), "forbidden repository %s is used", repo)
 					}
 				}
-				for _, repo := range config.Environment.Contents.RuntimeRepositories {
-					if slices.Contains(forbiddenRepositories, repo) {
-						return fmt.Errorf("forbidden repository %s is used", repo)
+				for i, repo := range config.Environment.Contents.RuntimeRepositories {
+					if slices.Contains(forbiddenRepos.Repositories, repo) {
+						return violation(config.NodeAt("environment", "contents", "repositories", i), "forbidden repository %s is used", repo)
 					}
 				}
 				return nil
@@ -102,10 +164,11 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "forbidden-keyring-used",
 			Description: "do not specify a forbidden keyring",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, keyring := range config.Environment.Contents.Keyring {
-					if slices.Contains(forbiddenKeyrings, keyring) {
-						return fmt.Errorf("forbidden keyring %s is used", keyring)
+			Parameters:  forbiddenKeys,
+			LintFunc: func(config Input) error {
+				for i, keyring := range config.Environment.Contents.Keyring {
+					if slices.Contains(forbiddenKeys.Keyrings, keyring) {
+						return violation(config.NodeAt("environment", "contents", "keyring", i), "forbidden keyring %s is used", keyring)
 					}
 				}
 				return nil
@@ -115,13 +178,13 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-copyright-header",
 			Description: "every package should have a valid copyright header",
 			Severity:    SeverityInfo,
-			LintFunc: func(config config.Configuration) error {
+			LintFunc: func(config Input) error {
 				if len(config.Package.Copyright) == 0 {
-					return fmt.Errorf("copyright header is missing")
+					return violation(config.NodeAt("package"), "copyright header is missing")
 				}
-				for _, c := range config.Package.Copyright {
+				for i, c := range config.Package.Copyright {
 					if c.License == "" {
-						return fmt.Errorf("license is missing")
+						return violation(config.NodeAt("package", "copyright", i), "license is missing")
 					}
 				}
 				return nil
@@ -131,44 +194,19 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "contains-epoch",
 			Description: "every package should have an epoch",
 			Severity:    SeverityError,
-			LintFunc: func(_ config.Configuration) error {
-				var node yaml.Node
-				fileInfo, err := os.Stat(l.options.Path)
-				if err != nil {
-					return err
-				}
-
-				// only lint files
-				if fileInfo.IsDir() {
-					return nil
-				}
-
-				yamlData, err := os.ReadFile(l.options.Path)
-				if err != nil {
-					return err
-				}
-
-				err = yaml.Unmarshal(yamlData, &node)
-				if err != nil {
-					return err
-				}
-
-				if node.Content == nil {
-					return fmt.Errorf("config %s has no yaml content", l.options.Path)
-				}
-
-				pkg, err := renovate.NodeFromMapping(node.Content[0], "package")
-				if err != nil {
-					return err
+			FixFunc:     fixMissingEpoch,
+			LintFunc: func(config Input) error {
+				if config.AST == nil {
+					return fmt.Errorf("config %s has no yaml content", config.Path)
 				}
 
+				pkg := childNode(config.AST, "package")
 				if pkg == nil {
-					return fmt.Errorf("config %s has no package content", l.options.Path)
+					return violation(config.AST, "config %s has no package content", config.Path)
 				}
 
-				err = containsKey(pkg, "epoch")
-				if err != nil {
-					return fmt.Errorf("config %s has no package.epoch", l.options.Path)
+				if err := containsKey(pkg, "epoch"); err != nil {
+					return violation(pkg, "config %s has no package.epoch", config.Path)
 				}
 
 				return nil
@@ -178,21 +216,21 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-pipeline-fetch-uri",
 			Description: "every fetch pipeline should have a valid uri",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, p := range config.Pipeline {
-					uri, err := extractURI(p)
+			LintFunc: func(config Input) error {
+				for i, p := range config.Pipeline {
+					key, uri, err := extractURI(p)
 					if err != nil {
-						return err
+						return violation(config.NodeAt("pipeline", i, "with"), "%s", err)
 					}
 					if uri == "" {
 						continue
 					}
 					u, err := url.ParseRequestURI(uri)
 					if err != nil {
-						return fmt.Errorf("uri is invalid URL structure")
+						return violation(config.NodeAt("pipeline", i, "with", key), "uri is invalid URL structure")
 					}
 					if !reValidHostname.MatchString(u.Host) {
-						return fmt.Errorf("uri hostname %q is invalid", u.Host)
+						return violation(config.NodeAt("pipeline", i, "with", key), "uri hostname %q is invalid", u.Host)
 					}
 				}
 				return nil
@@ -202,81 +240,196 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "uri-mimic",
 			Description: "every config should use a consistent hostname",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, p := range config.Pipeline {
-					uri := p.With["uri"]
-					if uri == "" {
+			Parameters:  uriMimic,
+			RepositoryLintFunc: func(repo Repository) map[string]error {
+				violations := map[string]error{}
+
+				// Hosts are compared against those of the configs before them, so the first
+				// config to use a host is taken to be the legitimate one.
+				seenHosts := map[string]bool{}
+				for _, name := range repo.Names {
+					// Configs the rule doesn't apply to can't vouch for their hosts.
+					if !repo.appliesTo("uri-mimic", name) {
 						continue
 					}
-					u, err := url.ParseRequestURI(uri)
-					if err != nil {
-						// This condition is picked up by valid-pipeline-fetch-uri
-						return nil
+					if err := checkURIMimic(repo.Inputs[name], seenHosts, uriMimic); err != nil {
+						violations[name] = err
 					}
-					host := u.Host
-					if seenHosts[host] {
-						continue
+				}
+
+				return violations
+			},
+		},
+		{
+			Name:        "no-duplicate-subpackages",
+			Description: "subpackage names should be unique across all configs",
+			Severity:    SeverityError,
+			RepositoryLintFunc: func(repo Repository) map[string]error {
+				definedBy := map[string][]string{}
+				for _, name := range repo.Names {
+					for _, pkg := range packageNames(repo.Inputs[name]) {
+						definedBy[pkg] = append(definedBy[pkg], name)
 					}
-					for k := range seenHosts {
-						// If this becomes a problem, we should filter out hosts that exist in >1 package
-						dist := levenshtein.DistanceForStrings([]rune(host), []rune(k), levenshtein.DefaultOptions)
-						if hostEditDistanceExceptions[host] == k || hostEditDistanceExceptions[k] == host {
+				}
+
+				violations := map[string]error{}
+				for _, name := range repo.Names {
+					in := repo.Inputs[name]
+					for i, sp := range in.Subpackages {
+						spName, ok := resolvePackageName(in, sp.Name)
+						if !ok {
 							continue
 						}
-						if dist <= minhostEditDistance {
-							return fmt.Errorf("%q too similar to %q", host, k)
+						if others := otherThan(definedBy[spName], name); len(others) > 0 {
+							violations[name] = violation(in.NodeAt("subpackages", i, "name"), "subpackage %s is also defined by %s", spName, strings.Join(others, ", "))
+							break
+						}
+						if countOf(definedBy[spName], name) > 1 {
+							violations[name] = violation(in.NodeAt("subpackages", i, "name"), "subpackage %s is defined more than once", spName)
+							break
+						}
+					}
+				}
+
+				return violations
+			},
+		},
+		{
+			Name:        "no-provides-collision",
+			Description: "unversioned provides should not collide with packages or provides of other configs",
+			Severity:    SeverityError,
+			RepositoryLintFunc: func(repo Repository) map[string]error {
+				// Versioned provides, like "go=1.22", are how the packages of
+				// versioned streams provide a shared name, so they're expected to
+				// collide.
+				unversionedProvidesOf := func(in Input) []provided {
+					return slices.DeleteFunc(providesOf(in), func(p provided) bool { return p.versioned })
+				}
+
+				definedBy := map[string][]string{}
+				providedBy := map[string][]provided{}
+				for _, name := range repo.Names {
+					in := repo.Inputs[name]
+					for _, pkg := range packageNames(in) {
+						definedBy[pkg] = append(definedBy[pkg], name)
+					}
+					for _, p := range unversionedProvidesOf(in) {
+						providedBy[p.name] = append(providedBy[p.name], p)
+					}
+				}
+
+				violations := map[string]error{}
+				for _, name := range repo.Names {
+					in := repo.Inputs[name]
+					for _, p := range unversionedProvidesOf(in) {
+						if others := otherThan(definedBy[p.name], name); len(others) > 0 {
+							violations[name] = violation(in.NodeAt(p.path...), "%s is provided, but it's also a package defined by %s", p.name, strings.Join(others, ", "))
+							break
 						}
 
-						// Detect TLD swaps
-						hostParts := strings.Split(host, ".")
-						kParts := strings.Split(k, ".")
-						if strings.Join(hostParts[:len(hostParts)-1], ".") == strings.Join(kParts[:len(kParts)-1], ".") {
-							return fmt.Errorf("%q shares components with %q", host, k)
+						var others []string
+						for _, o := range providedBy[p.name] {
+							if o.config == name || (p.prioritized && o.prioritized) {
+								continue
+							}
+							others = append(others, o.config)
+						}
+						if len(others) > 0 {
+							violations[name] = violation(in.NodeAt(p.path...), "%s is also provided by %s, and not every provider sets a provider-priority", p.name, strings.Join(others, ", "))
+							break
 						}
 					}
-					seenHosts[host] = true
 				}
-				return nil
+
+				return violations
 			},
 		},
+		{
+			Name:        "dependencies-provided",
+			Description: "every dependency should be provided by a config in the repository",
+			Severity:    SeverityWarning,
+			Parameters:  dependenciesProvided,
+			ConditionFuncs: []ConditionFunc{
+				// Dependencies can only be resolved against a whole repository.
+				l.lintsDirectory,
+			},
+			RepositoryLintFunc: func(repo Repository) map[string]error {
+				var available availablePackages
+				for _, name := range repo.Names {
+					available.add(repo.Inputs[name])
+				}
+
+				violations := map[string]error{}
+				for _, name := range repo.Names {
+					in := repo.Inputs[name]
+
+					// Configs that bring in their own repositories can depend on packages from them.
+					if len(in.Environment.Contents.BuildRepositories) > 0 || len(in.Environment.Contents.RuntimeRepositories) > 0 {
+						continue
+					}
 
+					for _, dep := range dependenciesOf(in) {
+						if !available.has(dep.name) && !slices.Contains(dependenciesProvided.Exempt, dep.name) {
+							violations[name] = violation(in.NodeAt(dep.path...), "%s is not provided by any config", dep.name)
+							break
+						}
+					}
+				}
+
+				return violations
+			},
+		},
 		{
 			Name:        "valid-pipeline-fetch-digest",
 			Description: "every fetch pipeline should have a valid digest",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, p := range config.Pipeline {
+			FixFunc:     fixDigests,
+			LintFunc: func(config Input) error {
+				for i, p := range config.Pipeline {
 					if p.Uses == "fetch" {
 						hashGiven := false
 						if sha256, ok := p.With["expected-sha256"]; ok {
 							if !reValidSHA256.MatchString(sha256) {
-								return fmt.Errorf("expected-sha256 is not valid SHA256")
+								return violation(config.NodeAt("pipeline", i, "with", "expected-sha256"), "expected-sha256 is not valid SHA256")
 							}
 							hashGiven = true
 						}
 						if sha512, ok := p.With["expected-sha512"]; ok {
 							if !reValidSHA512.MatchString(sha512) {
-								return fmt.Errorf("expected-sha512 is not valid SHA512")
+								return violation(config.NodeAt("pipeline", i, "with", "expected-sha512"), "expected-sha512 is not valid SHA512")
 							}
 							hashGiven = true
 						}
 						if !hashGiven {
-							return fmt.Errorf("expected-sha256 or expected-sha512 is missing")
+							return violation(config.NodeAt("pipeline", i, "with"), "expected-sha256 or expected-sha512 is missing")
 						}
 					}
 				}
 				return nil
 			},
 		},
+		{
+			Name:        "fetch-digest-matches-source-cache",
+			Description: "every fetch pipeline's digest should match the artifact in the source cache",
+			Severity:    SeverityError,
+			FixFunc:     fixDigests,
+			LintFunc: func(config Input) error {
+				return l.sources.checkDigests(config)
+			},
+			ConditionFuncs: []ConditionFunc{
+				l.hasSourceCache,
+			},
+		},
 		{
 			Name:        "no-repeated-deps",
 			Description: "no repeated dependencies",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			FixFunc:     fixRepeatedDeps,
+			LintFunc: func(config Input) error {
 				seen := map[string]struct{}{}
-				for _, p := range config.Environment.Contents.Packages {
+				for i, p := range config.Environment.Contents.Packages {
 					if _, ok := seen[p]; ok {
-						return fmt.Errorf("package %s is duplicated in environment", p)
+						return violation(config.NodeAt("environment", "contents", "packages", i), "package %s is duplicated in environment", p)
 					}
 					seen[p] = struct{}{}
 				}
@@ -287,7 +440,8 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "bad-template-var",
 			Description: "bad template variable",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			FixFunc:     fixBadTemplateVars,
+			LintFunc: func(config Input) error {
 				badTemplateVars := []string{
 					"$pkgdir",
 					"$pkgver",
@@ -295,24 +449,26 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 					"$srcdir",
 				}
 
-				hasBadVar := func(runs string) error {
+				hasBadVar := func(runs string, node *yaml.Node) error {
 					for _, badVar := range badTemplateVars {
-						if strings.Contains(runs, badVar) {
-							return fmt.Errorf("package contains likely incorrect template var %s", badVar)
+						for i, line := range strings.Split(runs, "\n") {
+							if strings.Contains(line, badVar) {
+								return config.violationInLine(node, i, "package contains likely incorrect template var %s", badVar)
+							}
 						}
 					}
 					return nil
 				}
 
-				for _, s := range config.Pipeline {
-					if err := hasBadVar(s.Runs); err != nil {
+				for i, s := range config.Pipeline {
+					if err := hasBadVar(s.Runs, config.NodeAt("pipeline", i, "runs")); err != nil {
 						return err
 					}
 				}
 
-				for _, subPkg := range config.Subpackages {
-					for _, subPipeline := range subPkg.Pipeline {
-						if err := hasBadVar(subPipeline.Runs); err != nil {
+				for i, subPkg := range config.Subpackages {
+					for j, subPipeline := range subPkg.Pipeline {
+						if err := hasBadVar(subPipeline.Runs, config.NodeAt("subpackages", i, "pipeline", j, "runs")); err != nil {
 							return err
 						}
 					}
@@ -324,10 +480,10 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "bad-version",
 			Description: "version is malformed",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			LintFunc: func(config Input) error {
 				version := config.Package.Version
 				if err := versions.ValidateWithoutEpoch(version); err != nil {
-					return fmt.Errorf("invalid version %s, could not parse", version)
+					return violation(config.NodeAt("package", "version"), "invalid version %s, could not parse", version)
 				}
 				return nil
 			},
@@ -336,15 +492,15 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-pipeline-git-checkout-commit",
 			Description: "every git-checkout pipeline should have a valid expected-commit",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, p := range config.Pipeline {
+			LintFunc: func(config Input) error {
+				for i, p := range config.Pipeline {
 					if p.Uses == gitCheckout {
 						if commit, ok := p.With["expected-commit"]; ok {
 							if !reValidSHA1.MatchString(commit) {
-								return fmt.Errorf("expected-commit is not valid SHA1")
+								return violation(config.NodeAt("pipeline", i, "with", "expected-commit"), "expected-commit is not valid SHA1")
 							}
 						} else {
-							return fmt.Errorf("expected-commit is missing")
+							return violation(config.NodeAt("pipeline", i, "with"), "expected-commit is missing")
 						}
 					}
 				}
@@ -355,11 +511,11 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-pipeline-git-checkout-tag",
 			Description: "every git-checkout pipeline should have a tag",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, p := range config.Pipeline {
+			LintFunc: func(config Input) error {
+				for i, p := range config.Pipeline {
 					if p.Uses == gitCheckout {
 						if _, ok := p.With["tag"]; !ok {
-							return fmt.Errorf("tag is missing")
+							return violation(config.NodeAt("pipeline", i, "with"), "tag is missing")
 						}
 					}
 				}
@@ -370,28 +526,31 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "check-when-version-changes",
 			Description: "check comments to make sure they are updated when version changes",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			LintFunc: func(config Input) error {
 				re := regexp.MustCompile(`# CHECK-WHEN-VERSION-CHANGES: (.+)`)
-				checkString := func(s string) error {
-					match := re.FindStringSubmatch(s)
-					if len(match) == 0 {
-						return nil
-					}
-					for _, m := range match[1:] {
-						if m != config.Package.Version {
-							return fmt.Errorf("version in comment: %s does not match version in package: %s, check that it can be updated and update the comment", m, config.Package.Version)
+				checkString := func(s string, node *yaml.Node) error {
+					for i, line := range strings.Split(s, "\n") {
+						match := re.FindStringSubmatch(line)
+						if len(match) == 0 {
+							continue
+						}
+						for _, m := range match[1:] {
+							if m != config.Package.Version {
+								return config.violationInLine(node, i, "version in comment: %s does not match version in package: %s, check that it can be updated and update the comment", m, config.Package.Version)
+							}
 						}
+						return nil
 					}
 					return nil
 				}
-				for _, p := range config.Pipeline {
-					if err := checkString(p.Runs); err != nil {
+				for i, p := range config.Pipeline {
+					if err := checkString(p.Runs, config.NodeAt("pipeline", i, "runs")); err != nil {
 						return err
 					}
 				}
-				for _, subPkg := range config.Subpackages {
-					for _, subPipeline := range subPkg.Pipeline {
-						if err := checkString(subPipeline.Runs); err != nil {
+				for i, subPkg := range config.Subpackages {
+					for j, subPipeline := range subPkg.Pipeline {
+						if err := checkString(subPipeline.Runs, config.NodeAt("subpackages", i, "pipeline", j, "runs")); err != nil {
 							return err
 						}
 					}
@@ -403,15 +562,16 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "tagged-repository-in-environment-repos",
 			Description: "remove tagged repositories like @local from the repositories block",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, repo := range config.Environment.Contents.BuildRepositories {
+			FixFunc:     fixTaggedRepositories,
+			LintFunc: func(config Input) error {
+				for i, repo := range config.Environment.Contents.BuildRepositories {
 					if repo[0] == '@' {
-						return fmt.Errorf("repository %q is tagged", repo)
+						return violation(config.NodeAt("environment", "contents", "build_repositories", i), "repository %q is tagged", repo)
 					}
 				}
-				for _, repo := range config.Environment.Contents.RuntimeRepositories {
+				for i, repo := range config.Environment.Contents.RuntimeRepositories {
 					if repo[0] == '@' {
-						return fmt.Errorf("repository %q is tagged", repo)
+						return violation(config.NodeAt("environment", "contents", "repositories", i), "repository %q is tagged", repo)
 					}
 				}
 				return nil
@@ -421,11 +581,11 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "git-checkout-must-use-github-updates",
 			Description: "when using git-checkout, must use github/git updates so we can get the expected-commit",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			LintFunc: func(config Input) error {
 				for _, p := range config.Pipeline {
 					if p.Uses == gitCheckout && strings.HasPrefix(p.With["repository"], "https://github.com/") {
 						if config.Update.Enabled && config.Update.GitHubMonitor == nil && config.Update.GitMonitor == nil {
-							return fmt.Errorf("configure update.github/update.git when using git-checkout")
+							return violation(config.NodeAt("update"), "configure update.github/update.git when using git-checkout")
 						}
 					}
 				}
@@ -436,15 +596,15 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-spdx-license",
 			Description: "every package should have a valid SPDX license",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
-				for _, c := range config.Package.Copyright {
+			LintFunc: func(config Input) error {
+				for i, c := range config.Package.Copyright {
 					switch c.License {
 					// Allow wicked licenses
 					case "custom", "PROPRIETARY":
 						continue
 					}
 					if valid, _ := spdxexp.ValidateLicenses([]string{c.License}); !valid {
-						return fmt.Errorf("license %q is not valid SPDX license", c.License)
+						return violation(config.NodeAt("package", "copyright", i, "license"), "license %q is not valid SPDX license", c.License)
 					}
 				}
 				return nil
@@ -454,7 +614,7 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Name:        "valid-package-or-subpackage-test",
 			Description: "every package should have a valid main or subpackage test",
 			Severity:    SeverityInfo,
-			LintFunc: func(c config.Configuration) error {
+			LintFunc: func(c Input) error {
 				if c.Test != nil && len(c.Test.Pipeline) > 0 {
 					// Main package has at least one test
 					return nil
@@ -474,7 +634,8 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 			Description: "packages with auto-update disabled should have a reason",
 			// TODO: Change to SeverityError when current packages are compliant.
 			Severity: SeverityWarning,
-			LintFunc: func(c config.Configuration) error {
+			FixFunc:  fixUpdateDisabledReason,
+			LintFunc: func(c Input) error {
 				cfg := c.Update
 				if cfg.Enabled {
 					return nil
@@ -483,16 +644,16 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 				if !cfg.Enabled && cfg.ExcludeReason != "" {
 					return nil
 				}
-				return fmt.Errorf("auto-update is disabled but no reason is provided")
+				return violation(c.NodeAt("update", "enabled"), "auto-update is disabled but no reason is provided")
 			},
 		},
 		{
 			Name:        "background-process-without-redirect",
 			Description: "test steps should redirect output when running background processes",
 			Severity:    SeverityWarning,
-			LintFunc: func(c config.Configuration) error {
-				checkSteps := func(steps []config.Pipeline) error {
-					for _, s := range steps {
+			LintFunc: func(c Input) error {
+				checkSteps := func(steps []config.Pipeline, path ...any) error {
+					for j, s := range steps {
 						if s.Runs == "" {
 							continue
 						}
@@ -505,7 +666,8 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 
 							needsRedirect := reBackgroundProcess.MatchString(checkLine) || reDaemonProcess.MatchString(line)
 							if needsRedirect && !reOutputRedirect.MatchString(line) {
-								return fmt.Errorf("background process missing output redirect: %s", strings.TrimSpace(line))
+								node := c.NodeAt(append(slices.Clone(path), j, "runs")...)
+								return c.violationInLine(node, i, "background process missing output redirect: %s", strings.TrimSpace(line))
 							}
 						}
 					}
@@ -513,13 +675,13 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 				}
 
 				if c.Test != nil {
-					if err := checkSteps(c.Test.Pipeline); err != nil {
+					if err := checkSteps(c.Test.Pipeline, "test", "pipeline"); err != nil {
 						return err
 					}
 				}
-				for _, sp := range c.Subpackages {
+				for i, sp := range c.Subpackages {
 					if sp.Test != nil {
-						if err := checkSteps(sp.Test.Pipeline); err != nil {
+						if err := checkSteps(sp.Test.Pipeline, "subpackages", i, "test", "pipeline"); err != nil {
 							return err
 						}
 					}
@@ -527,18 +689,83 @@ var AllRules = func(l *Linter) Rules { //nolint:gocyclo
 				return nil
 			},
 		},
+		{
+			Name:        "valid-pipeline-uses",
+			Description: "every pipeline step should use a pipeline that exists",
+			Severity:    SeverityError,
+			Parameters:  pipelineUses,
+			LintFunc: func(config Input) error {
+				return checkPipelineUses(config, l.pipelines, pipelineUses)
+			},
+		},
+		{
+			Name:        "valid-pipeline-required-inputs",
+			Description: "every pipeline step should set the required inputs of the pipeline it uses",
+			Severity:    SeverityError,
+			LintFunc: func(config Input) error {
+				return checkPipelineRequiredInputs(config, l.pipelines)
+			},
+		},
+		{
+			Name:        "valid-pipeline-inputs",
+			Description: "every pipeline step should only set inputs that the pipeline it uses declares",
+			Severity:    SeverityWarning,
+			LintFunc: func(config Input) error {
+				return checkPipelineInputs(config, l.pipelines)
+			},
+		},
+		{
+			Name:        "no-deprecated-pipelines",
+			Description: "pipeline steps should not use deprecated pipelines",
+			Severity:    SeverityWarning,
+			LintFunc: func(config Input) error {
+				return checkDeprecatedPipelines(config, l.pipelines)
+			},
+		},
 		{
 			Name:        "valid-update-schedule",
 			Description: "update schedule config should contain a valid period",
 			Severity:    SeverityError,
-			LintFunc: func(config config.Configuration) error {
+			LintFunc: func(config Input) error {
 				if config.Update.Schedule == nil {
 					return nil
 				}
-				_, err := config.Update.Schedule.GetScheduleMessage()
-				return err
+				if _, err := config.Update.Schedule.GetScheduleMessage(); err != nil {
+					return violation(config.NodeAt("update", "schedule"), "%s", err)
+				}
+				return nil
 			},
 		},
+		{
+			Name:        "no-pipe-to-shell",
+			Description: "pipeline steps should not run downloaded scripts by piping them to a shell",
+			Severity:    SeverityError,
+			LintFunc:    checkPipeToShell,
+		},
+		{
+			Name:        "no-plain-http-fetch",
+			Description: "pipelines should not fetch anything over unencrypted http",
+			Severity:    SeverityError,
+			LintFunc:    checkPlainHTTP,
+		},
+		{
+			Name:        "no-disabled-tls-verification",
+			Description: "pipeline steps should not disable TLS certificate verification",
+			Severity:    SeverityError,
+			LintFunc:    checkTLSVerification,
+		},
+		{
+			Name:        "no-world-writable-permissions",
+			Description: "pipeline steps should not make files world-writable",
+			Severity:    SeverityError,
+			LintFunc:    checkWorldWritable,
+		},
+		{
+			Name:        "no-setuid",
+			Description: "pipeline steps should not set setuid or setgid bits",
+			Severity:    SeverityError,
+			LintFunc:    checkSetuid,
+		},
 	}
 }
 
@@ -553,25 +780,27 @@ func containsKey(parentNode *yaml.Node, key string) error {
 	return fmt.Errorf("key '%s' not found in mapping", key)
 }
 
-func extractURI(p config.Pipeline) (string, error) {
+// extractURI returns the URI that the pipeline step fetches from, along with
+// the key of the step's "with" mapping that holds it.
+func extractURI(p config.Pipeline) (string, string, error) {
 	if p.Uses == "fetch" {
 		uri, ok := p.With["uri"]
 		if !ok {
-			return "", fmt.Errorf("uri is missing in fetch pipeline")
+			return "", "", fmt.Errorf("uri is missing in fetch pipeline")
 		}
 
-		return uri, nil
+		return "uri", uri, nil
 	}
 
 	if p.Uses == "git-checkout" {
 		repo, ok := p.With["repository"]
 		if !ok {
-			return "", fmt.Errorf("repository is missing in git-checkout pipeline")
+			return "", "", fmt.Errorf("repository is missing in git-checkout pipeline")
 		}
 
-		return repo, nil
+		return "repository", repo, nil
 	}
 
 	// Most pipelines won't have a URI.
-	return "", nil
+	return "", "", nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -558,6 +559,103 @@ func TestLinter_Rules(t *testing.T) {
 			wantErr:     false,
 			matches:     0,
 		},
+		{
+			file:        "pipe-to-shell.yaml",
+			minSeverity: SeverityWarning,
+			want: EvalResult{
+				File: "pipe-to-shell",
+				Errors: EvalRuleErrors{
+					{
+						Rule: Rule{
+							Name:     "no-pipe-to-shell",
+							Severity: SeverityError,
+						},
+						Error: fmt.Errorf("[no-pipe-to-shell]: downloaded script is run by a shell without being verified: curl -fsSL https://example.com/install.sh | sh -s -- --prefix=${{targets.destdir}}/usr (ERROR)"),
+					},
+				},
+			},
+			wantErr: false,
+			matches: 1,
+		},
+		{
+			file:        "plain-http-fetch.yaml",
+			minSeverity: SeverityWarning,
+			want: EvalResult{
+				File: "plain-http-fetch",
+				Errors: EvalRuleErrors{
+					{
+						Rule: Rule{
+							Name:     "no-plain-http-fetch",
+							Severity: SeverityError,
+						},
+						Error: fmt.Errorf("[no-plain-http-fetch]: fetch uses unencrypted http: http://test.com/security/${{package.version}}.tar.gz (ERROR)"),
+					},
+				},
+			},
+			wantErr: false,
+			matches: 1,
+		},
+		{
+			file:        "disabled-tls-verification.yaml",
+			minSeverity: SeverityWarning,
+			want: EvalResult{
+				File: "disabled-tls-verification",
+				Errors: EvalRuleErrors{
+					{
+						Rule: Rule{
+							Name:     "no-disabled-tls-verification",
+							Severity: SeverityError,
+						},
+						Error: fmt.Errorf("[no-disabled-tls-verification]: TLS certificate verification is disabled: curl -fsSLk -o plugin.tar.gz https://example.com/plugin.tar.gz (ERROR)"),
+					},
+				},
+			},
+			wantErr: false,
+			matches: 1,
+		},
+		{
+			file:        "world-writable-install.yaml",
+			minSeverity: SeverityWarning,
+			want: EvalResult{
+				File: "world-writable-install",
+				Errors: EvalRuleErrors{
+					{
+						Rule: Rule{
+							Name:     "no-world-writable-permissions",
+							Severity: SeverityError,
+						},
+						Error: fmt.Errorf("[no-world-writable-permissions]: file is made world-writable: install -Dm666 foo.conf ${{targets.destdir}}/etc/foo.conf (ERROR)"),
+					},
+				},
+			},
+			wantErr: false,
+			matches: 1,
+		},
+		{
+			file:        "setuid.yaml",
+			minSeverity: SeverityWarning,
+			want: EvalResult{
+				File: "setuid",
+				Errors: EvalRuleErrors{
+					{
+						Rule: Rule{
+							Name:     "no-setuid",
+							Severity: SeverityError,
+						},
+						Error: fmt.Errorf("[no-setuid]: setuid or setgid bit is set: chmod u+s ${{targets.destdir}}/usr/bin/foo (ERROR)"),
+					},
+				},
+			},
+			wantErr: false,
+			matches: 1,
+		},
+		{
+			file:        "security-nolint.yaml",
+			minSeverity: SeverityWarning,
+			want:        EvalResult{},
+			wantErr:     false,
+			matches:     0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -589,3 +687,44 @@ func TestLinter_Rules(t *testing.T) {
 		})
 	}
 }
+
+func TestLinter_RulePositions(t *testing.T) {
+	tests := []struct {
+		file string
+		rule string
+		want Position
+	}{
+		{file: "missing-copyright.yaml", rule: "valid-copyright-header", want: Position{Line: 2, Column: 3}},
+		{file: "forbidden-repository.yaml", rule: "forbidden-repository-used", want: Position{Line: 15, Column: 9}},
+		{file: "duplicated-package.yaml", rule: "no-repeated-deps", want: Position{Line: 16, Column: 9}},
+		{file: "bad-version.yaml", rule: "bad-version", want: Position{Line: 3, Column: 12}},
+		{file: "wrong-pipeline-fetch-digest.yaml", rule: "valid-pipeline-fetch-digest", want: Position{Line: 16, Column: 24}},
+		{file: "missing-pipeline-git-checkout-commit.yaml", rule: "valid-pipeline-git-checkout-commit", want: Position{Line: 15, Column: 7}},
+		{file: "update-disabled.yaml", rule: "update-disabled-reason", want: Position{Line: 29, Column: 12}},
+		{file: "bad-template-var.yaml", rule: "bad-template-var", want: Position{Line: 13, Column: 7}},
+		{file: "check-subpipeline-version-matches.yaml", rule: "check-when-version-changes", want: Position{Line: 21, Column: 11}},
+		{file: "background-process-multiline-no-redirect.yaml", rule: "background-process-without-redirect", want: Position{Line: 42, Column: 9}},
+		{file: "pipe-to-shell.yaml", rule: "no-pipe-to-shell", want: Position{Line: 18, Column: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			ctx := context.Background()
+			l := newTestLinterWithFile(tt.file)
+			got, err := l.Lint(ctx, SeverityInfo)
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, filepath.Join("testdata", "files", tt.file), got[0].Path)
+
+			for _, e := range got[0].Errors {
+				if e.Rule.Name != tt.rule {
+					continue
+				}
+				require.NotNil(t, e.Range)
+				assert.Equal(t, tt.want, e.Range.Start)
+				return
+			}
+			t.Fatalf("rule %s not violated", tt.rule)
+		})
+	}
+}
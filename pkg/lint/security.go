@@ -0,0 +1,263 @@
+package lint
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// rePipeToShell matches downloads that are piped or substituted into a shell.
+	rePipeToShell = regexp.MustCompile(`\b(?:curl|wget)\b[^|;&]*\|\s*(?:sudo\s+)?(?:env\s+)?(?:ba|z|k|da|a)?sh\b` +
+		`|\b(?:ba|z|k|da|a)?sh\s+(?:-c\s+)?["']?(?:\$\(|<\()\s*(?:curl|wget)\b`)
+
+	// rePlainHTTPDownload matches downloads from plain http URLs.
+	rePlainHTTPDownload = regexp.MustCompile(`\b(?:curl|wget|git\s+clone)\b[^|;&]*\bhttp://`)
+
+	// reDisabledTLSVerification matches commands that disable TLS certificate
+	// verification.
+	reDisabledTLSVerification = regexp.MustCompile(`\bcurl\b[^|;&]*\s(?:-[a-zA-Z]*k[a-zA-Z]*|--insecure)\b` +
+		`|\bwget\b[^|;&]*\s--no-check-certificate\b` +
+		`|\bhttp\.sslVerify[= ]\s*["']?false\b` +
+		`|\bGIT_SSL_NO_VERIFY=["']?(?i:1|true|yes|on)\b` +
+		`|\bNODE_TLS_REJECT_UNAUTHORIZED=["']?0\b`)
+
+	// reURLHost matches the hosts of http and https URLs.
+	reURLHost = regexp.MustCompile(`(?i)\bhttps?://(\[[^\]]*\]|[^/\s:'"?#]+)`)
+
+	// reChmodMode and reInstallMode match the modes set by chmod and install.
+	reChmodMode   = regexp.MustCompile(`\bchmod\s+(?:-[a-zA-Z]+\s+)*["']?([0-7]{3,4}|[ugoa]*[-+=][-+=rwxXstugoa,]*)`)
+	reInstallMode = regexp.MustCompile(`\binstall\b[^|;&]*?\s(?:-[a-zA-Z]*m\s*|--mode[=\s])["']?([0-7]{3,4}|[ugoa]*[-+=][-+=rwxXstugoa,]*)`)
+)
+
+// shellLine is a logical line of a shell script, which may span several
+// physical lines that end with a backslash.
+type shellLine struct {
+	// text is the logical line, with the continuations joined.
+	text string
+
+	// index is the index of the first physical line of the logical line.
+	index int
+}
+
+// shellLines returns the logical lines of the script, leaving out comments.
+func shellLines(script string) []shellLine {
+	var lines []shellLine
+
+	physical := strings.Split(script, "\n")
+	for i := 0; i < len(physical); i++ {
+		start := i
+		text := physical[i]
+		for strings.HasSuffix(strings.TrimRight(text, " \t"), `\`) && i+1 < len(physical) {
+			text = strings.TrimRight(strings.TrimSuffix(strings.TrimRight(text, " \t"), `\`), " \t") + " " + strings.TrimSpace(physical[i+1])
+			i++
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+		lines = append(lines, shellLine{text: text, index: start})
+	}
+
+	return lines
+}
+
+// onlyLoopbackURLs reports whether the line has URLs and all of them are of
+// loopback hosts, like those of test pipelines that check a service they
+// started. Traffic to a loopback host can't be tampered with in transit.
+func onlyLoopbackURLs(line string) bool {
+	matches := reURLHost.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return false
+	}
+
+	for _, m := range matches {
+		host := strings.Trim(m[1], "[]")
+		if strings.EqualFold(host, "localhost") {
+			continue
+		}
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// downloadsOverPlainHTTP reports whether the line downloads something over
+// plain http from a host other than a loopback one.
+func downloadsOverPlainHTTP(line string) bool {
+	return rePlainHTTPDownload.MatchString(line) && !onlyLoopbackURLs(line)
+}
+
+// disablesTLSVerification reports whether the line disables TLS certificate
+// verification, other than for requests to loopback hosts.
+func disablesTLSVerification(line string) bool {
+	return reDisabledTLSVerification.MatchString(line) && !onlyLoopbackURLs(line)
+}
+
+// checkScripts checks the "runs" scripts of all of the configuration's pipeline
+// steps line by line, returning a violation for the first line that check
+// returns a message for.
+func checkScripts(in Input, check func(line string) string) error {
+	for _, step := range pipelineSteps(in) {
+		if step.Runs == "" {
+			continue
+		}
+		for _, line := range shellLines(step.Runs) {
+			if msg := check(line.text); msg != "" {
+				node := in.NodeAt(append(step.path, "runs")...)
+				return in.violationInLine(node, line.index, "%s: %s", msg, strings.TrimSpace(line.text))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPipeToShell checks that the configuration doesn't run downloaded scripts
+// without verifying them.
+func checkPipeToShell(in Input) error {
+	return checkScripts(in, func(line string) string {
+		if rePipeToShell.MatchString(line) {
+			return "downloaded script is run by a shell without being verified"
+		}
+		return ""
+	})
+}
+
+// checkPlainHTTP checks that the configuration doesn't fetch anything over
+// plain http, where it can be tampered with in transit.
+func checkPlainHTTP(in Input) error {
+	for _, step := range pipelineSteps(in) {
+		key := ""
+		switch step.Uses {
+		case "fetch":
+			key = "uri"
+		case gitCheckout:
+			key = "repository"
+		}
+		if key == "" {
+			continue
+		}
+
+		// The URI may be templated, so its scheme is checked without parsing it.
+		uri := step.With[key]
+		for _, scheme := range []string{"http", "git"} {
+			if strings.HasPrefix(strings.ToLower(uri), scheme+"://") {
+				return violation(in.NodeAt(append(step.path, "with", key)...), "%s uses unencrypted %s: %s", step.Uses, scheme, uri)
+			}
+		}
+	}
+
+	return checkScripts(in, func(line string) string {
+		if downloadsOverPlainHTTP(line) {
+			return "download over unencrypted http"
+		}
+		return ""
+	})
+}
+
+// checkTLSVerification checks that the configuration doesn't disable TLS
+// certificate verification.
+func checkTLSVerification(in Input) error {
+	return checkScripts(in, func(line string) string {
+		if disablesTLSVerification(line) {
+			return "TLS certificate verification is disabled"
+		}
+		return ""
+	})
+}
+
+// checkWorldWritable checks that the configuration doesn't make files
+// writable by everyone. Modes with the sticky bit, like that of /tmp, are
+// allowed.
+func checkWorldWritable(in Input) error {
+	return checkScripts(in, func(line string) string {
+		for _, mode := range modesIn(line) {
+			if mode.worldWritable() {
+				return "file is made world-writable"
+			}
+		}
+		return ""
+	})
+}
+
+// checkSetuid checks that the configuration doesn't set setuid or setgid bits.
+func checkSetuid(in Input) error {
+	return checkScripts(in, func(line string) string {
+		for _, mode := range modesIn(line) {
+			if mode.setuid() {
+				return "setuid or setgid bit is set"
+			}
+		}
+		return ""
+	})
+}
+
+// fileMode is a mode given to chmod or install, either octal (like "0755") or
+// symbolic (like "u+x,go-w").
+type fileMode string
+
+// modesIn returns the modes set by the chmod and install commands in the line.
+func modesIn(line string) []fileMode {
+	var modes []fileMode
+	for _, re := range []*regexp.Regexp{reChmodMode, reInstallMode} {
+		for _, m := range re.FindAllStringSubmatch(line, -1) {
+			modes = append(modes, fileMode(m[1]))
+		}
+	}
+
+	return modes
+}
+
+// octal returns the mode's bits, and whether it's an octal mode.
+func (m fileMode) octal() (uint64, bool) {
+	bits, err := strconv.ParseUint(string(m), 8, 32)
+	return bits, err == nil
+}
+
+// symbolic calls f for each clause of a symbolic mode that adds or sets
+// permissions, with the users the clause applies to and the permissions.
+func (m fileMode) symbolic(f func(who, perms string)) {
+	for _, clause := range strings.Split(string(m), ",") {
+		i := strings.IndexAny(clause, "+=")
+		if i < 0 || strings.Contains(clause[:i], "-") {
+			continue
+		}
+		f(clause[:i], clause[i+1:])
+	}
+}
+
+func (m fileMode) worldWritable() bool {
+	if bits, ok := m.octal(); ok {
+		return bits&0o002 != 0 && bits&0o1000 == 0
+	}
+
+	writable, sticky := false, false
+	m.symbolic(func(who, perms string) {
+		// Without "o" or "a", the umask keeps others from getting write access.
+		if strings.ContainsAny(who, "oa") && strings.Contains(perms, "w") {
+			writable = true
+		}
+		if strings.Contains(perms, "t") {
+			sticky = true
+		}
+	})
+	return writable && !sticky
+}
+
+func (m fileMode) setuid() bool {
+	if bits, ok := m.octal(); ok {
+		return bits&0o6000 != 0
+	}
+
+	setuid := false
+	m.symbolic(func(who, perms string) {
+		if strings.Contains(perms, "s") {
+			setuid = true
+		}
+	})
+	return setuid
+}
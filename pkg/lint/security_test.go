@@ -0,0 +1,84 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityPatterns(t *testing.T) {
+	tests := []struct {
+		line          string
+		pipeToShell   bool
+		plainHTTP     bool
+		disabledTLS   bool
+		worldWritable bool
+		setuid        bool
+	}{
+		{line: "curl -fsSL https://example.com/install.sh | sh", pipeToShell: true},
+		{line: "wget -qO- https://example.com/install.sh | sudo bash -s", pipeToShell: true},
+		{line: `sh -c "$(curl -fsSL https://example.com/install.sh)"`, pipeToShell: true},
+		{line: "bash <(curl -fsSL https://example.com/install.sh)", pipeToShell: true},
+		{line: "curl -fsSL https://example.com/foo.tar.gz | tar -xz"},
+		{line: "curl -o foo.sh https://example.com/foo.sh; sha256sum -c foo.sha256 && sh foo.sh"},
+		{line: "wget http://example.com/foo.tar.gz", plainHTTP: true},
+		{line: "git clone http://example.com/foo.git", plainHTTP: true},
+		{line: "curl -fsSL https://example.com/foo.tar.gz"},
+		{line: "curl -fsSLk https://example.com/foo.tar.gz", disabledTLS: true},
+		{line: "curl --insecure https://example.com/foo.tar.gz", disabledTLS: true},
+		{line: "wget --no-check-certificate https://example.com/foo.tar.gz", disabledTLS: true},
+		{line: "git -c http.sslVerify=false clone https://example.com/foo.git", disabledTLS: true},
+		{line: "GIT_SSL_NO_VERIFY=1 git clone https://example.com/foo.git", disabledTLS: true},
+		{line: "export GIT_SSL_NO_VERIFY=true", disabledTLS: true},
+		{line: "GIT_SSL_NO_VERIFY=0 git clone https://example.com/foo.git"},
+		{line: "GIT_SSL_NO_VERIFY=false git clone https://example.com/foo.git"},
+		{line: "make -k check"},
+		{line: "curl -s http://localhost:8080/health"},
+		{line: "wget -qO- http://127.0.0.1:8080/metrics"},
+		{line: "curl -fsS http://[::1]:8080/health"},
+		{line: "curl -s http://localhost.example.com/foo.tar.gz", plainHTTP: true},
+		{line: "curl -o foo http://example.com/foo http://localhost:8080/", plainHTTP: true},
+		{line: "curl -k https://127.0.0.1:8443"},
+		{line: "curl --insecure https://localhost:8443/health"},
+		{line: "wget --no-check-certificate https://[::1]:8443/"},
+		{line: "curl -k https://localhost:8443 https://example.com/foo.tar.gz", disabledTLS: true},
+		{line: "chmod 777 /srv", worldWritable: true},
+		{line: "chmod -R o+w /srv", worldWritable: true},
+		{line: "chmod a=rwx /srv", worldWritable: true},
+		{line: "chmod 1777 /tmp"},
+		{line: "chmod +t,a+w /tmp"},
+		{line: "chmod go-w /srv"},
+		{line: "install -Dm666 foo /etc/foo", worldWritable: true},
+		{line: "install --mode=0777 foo /etc/foo", worldWritable: true},
+		{line: "install -Dm755 foo /usr/bin/foo"},
+		{line: "install -Dm4755 foo /usr/bin/foo", setuid: true},
+		{line: "install -m 2755 foo /usr/bin/foo", setuid: true},
+		{line: "chmod u+s /usr/bin/foo", setuid: true},
+		{line: "chmod g+s /var/lib/foo", setuid: true},
+		{line: "chmod u-s /usr/bin/foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.pipeToShell, rePipeToShell.MatchString(tt.line), "pipe to shell")
+			assert.Equal(t, tt.plainHTTP, downloadsOverPlainHTTP(tt.line), "plain http")
+			assert.Equal(t, tt.disabledTLS, disablesTLSVerification(tt.line), "disabled TLS verification")
+
+			worldWritable, setuid := false, false
+			for _, m := range modesIn(tt.line) {
+				worldWritable = worldWritable || m.worldWritable()
+				setuid = setuid || m.setuid()
+			}
+			assert.Equal(t, tt.worldWritable, worldWritable, "world-writable")
+			assert.Equal(t, tt.setuid, setuid, "setuid")
+		})
+	}
+}
+
+func TestShellLines(t *testing.T) {
+	got := shellLines("# comment\ncurl -fsSL https://example.com \\\n  | sh\necho done")
+	assert.Equal(t, []shellLine{
+		{text: "curl -fsSL https://example.com | sh", index: 1},
+		{text: "echo done", index: 3},
+	}, got)
+}
@@ -4,10 +4,58 @@ import (
 	"errors"
 
 	"chainguard.dev/melange/pkg/config"
+	"github.com/wolfi-dev/wolfictl/pkg/configs"
+	"gopkg.in/yaml.v3"
 )
 
 // Function is a function that lints a single configuration.
-type Function func(config.Configuration) error
+type Function func(Input) error
+
+// Input is a single configuration to be linted. The decoded configuration is
+// embedded, and the YAML AST of the configuration file is available so that
+// rules can point at the part of the file that violates them (see Violation).
+type Input struct {
+	config.Configuration
+
+	// Path is the path to the configuration file.
+	Path string
+
+	// AST is the top-level mapping node of the configuration file, or nil if the
+	// file couldn't be parsed into a YAML AST.
+	AST *yaml.Node
+
+	// lines are the lines of the configuration file.
+	lines []string
+
+	// hash is the SHA-256 digest of the configuration file's content.
+	hash string
+}
+
+// RepositoryFunction is a function that lints all of the configurations in a
+// repository together. It returns the violations it finds, keyed by the name of
+// the package whose configuration violates the rule.
+type RepositoryFunction func(Repository) map[string]error
+
+// Repository is the set of configurations that are linted together.
+type Repository struct {
+	// Names are the names of the packages, in sorted order.
+	Names []string
+
+	// Inputs are the configurations of the packages, keyed by package name.
+	Inputs map[string]Input
+
+	// applies reports whether the named rule applies to the named package's
+	// configuration. It's nil if every rule applies to every configuration.
+	applies func(rule, name string) bool
+}
+
+// appliesTo reports whether the named rule applies to the named package's
+// configuration, which it doesn't if the configuration's path is excluded by
+// the lint configuration or the file contains a #nolint directive for the
+// rule.
+func (r Repository) appliesTo(rule, name string) bool {
+	return r.applies == nil || r.applies(rule, name)
+}
 
 // ConditionFunc is a function that checks if a rule should be executed.
 type ConditionFunc func() bool
@@ -44,6 +92,20 @@ type Rule struct {
 	// LintFunc is the function that lints a single configuration.
 	LintFunc Function
 
+	// RepositoryLintFunc is the function that lints all of the configurations
+	// together, for rules that need to look across configuration files. A rule
+	// sets either LintFunc or RepositoryLintFunc.
+	RepositoryLintFunc RepositoryFunction
+
+	// FixFunc, if set, fixes violations of the rule by mutating the YAML AST of
+	// the configuration file. The AST is the file's document node.
+	FixFunc configs.YAMLASTMutater[config.Configuration]
+
+	// Parameters, if set, is a pointer to the rule's parameters, which the rule's
+	// functions read. It's decoded from the "with" field of the rule's
+	// configuration (see RuleConfig), on top of the rule's defaults.
+	Parameters any
+
 	// ConditionFuncs is a list of and-conditioned functions that check if the rule should be executed.
 	ConditionFuncs []ConditionFunc
 }
@@ -56,8 +118,15 @@ type EvalRuleError struct {
 	// Rule is the rule that caused the error.
 	Rule Rule
 
-	// Error is the error that occurred.
+	// Error is the error that occurred, formatted with the rule's name and
+	// severity.
 	Error error
+
+	// Message is the unformatted message of the error.
+	Message string
+
+	// Range is where in the configuration file the error occurred, if known.
+	Range *Range
 }
 
 // EvalRuleErrors returns a list of EvalError.
@@ -68,6 +137,9 @@ type EvalResult struct {
 	// File is the name of the file that was evaluated against.
 	File string
 
+	// Path is the path to the file that was evaluated against.
+	Path string
+
 	// Errors is a list of validation errors for each rule.
 	Errors EvalRuleErrors
 }
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+)
+
+var (
+	// ErrCycle is returned by Validate when a line of questioning can lead back to
+	// a question that was already asked.
+	ErrCycle = errors.New("question graph contains a cycle")
+
+	// ErrInvalidQuestion is returned by Validate when a question can't be asked,
+	// e.g. because it has no text or no way to answer it.
+	ErrInvalidQuestion = errors.New("invalid question")
+)
+
+// Validate traverses every path through the interview that starts at the given
+// root question, in the same way as Dot. It returns an error if any question is
+// malformed, if any answer function returns an error (other than
+// question.ErrTerminate), or if the graph contains a cycle.
+//
+// If validateResult is non-nil, it's called with the final state of every line
+// of questioning that concludes with a result, so that callers can check that
+// every path through the interview produces a usable result.
+func Validate[T any](ctx context.Context, root question.Question[T], initialState T, validateResult func(T) error) error {
+	return validate(ctx, root, initialState, nil, validateResult)
+}
+
+func validate[T any](
+	ctx context.Context,
+	q question.Question[T],
+	state T,
+	path []question.Question[T],
+	validateResult func(T) error,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if q.Text == "" {
+		return fmt.Errorf("%w: question has no text (after %s)", ErrInvalidQuestion, renderPath(path))
+	}
+
+	if slices.ContainsFunc(path, func(p question.Question[T]) bool { return nodeKey(p) == nodeKey(q) }) {
+		return fmt.Errorf("%w: %s", ErrCycle, renderPath(append(slices.Clone(path), q)))
+	}
+	path = append(slices.Clone(path), q)
+
+	follow := func(updatedState T, next *question.Question[T], err error, edgeLabel string) error {
+		if err != nil {
+			if errors.Is(err, question.ErrTerminate) {
+				return nil
+			}
+
+			return fmt.Errorf("answering %q with %q: %w", q.Text, edgeLabel, err)
+		}
+
+		if next != nil {
+			return validate(ctx, *next, updatedState, path, validateResult)
+		}
+
+		if validateResult != nil {
+			if err := validateResult(updatedState); err != nil {
+				return fmt.Errorf("result of %s → %s: %w", renderPath(path), edgeLabel, err)
+			}
+		}
+
+		return nil
+	}
+
+	switch a := q.Answer.(type) {
+	case question.AcceptText[T]:
+		// Simulate a text answer to advance the propagation through the graph.
+		updatedState, next, err := a(state, textInput)
+		return follow(updatedState, next, err, textInput)
+
+	case question.MultipleChoice[T]:
+		if len(a) == 0 {
+			return fmt.Errorf("%w: %q has no choices", ErrInvalidQuestion, q.Text)
+		}
+
+		for _, choice := range a {
+			if choice.Choose == nil {
+				return fmt.Errorf("%w: choice %q for %q has no Choose function", ErrInvalidQuestion, choice.Text, q.Text)
+			}
+
+			updatedState, next, err := choice.Choose(state)
+			if err := follow(updatedState, next, err, choice.Text); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case question.MessageOnly[T]:
+		updatedState, next, err := a(state)
+		return follow(updatedState, next, err, messageAccepted)
+
+	default:
+		return fmt.Errorf("%w: %q has unsupported answer type %T", ErrInvalidQuestion, q.Text, q.Answer)
+	}
+}
+
+// nodeKey identifies the question as a node of the graph, by its ID if it has
+// one, so that distinct questions with the same text aren't mistaken for each
+// other.
+func nodeKey[T any](q question.Question[T]) string {
+	if q.ID != "" {
+		return "id:" + q.ID
+	}
+
+	return "text:" + q.Text
+}
+
+func renderPath[T any](path []question.Question[T]) string {
+	if len(path) == 0 {
+		return "start"
+	}
+
+	quoted := make([]string, 0, len(path))
+	for _, p := range path {
+		quoted = append(quoted, fmt.Sprintf("%q", p.Text))
+	}
+
+	return strings.Join(quoted, " → ")
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wolfi-dev/wolfictl/pkg/question"
+)
+
+func TestValidate(t *testing.T) {
+	errNoDessert := errors.New("no dessert chosen")
+
+	var (
+		qName = question.Question[string]{
+			Text: "What's your name?",
+			Answer: question.AcceptText[string](func(_ string, text string) (string, *question.Question[string], error) {
+				return text, nil, nil
+			}),
+		}
+
+		qFavoriteDessert = question.Question[string]{
+			Text: "What is your favorite dessert?",
+			Answer: question.MultipleChoice[string]{
+				{
+					Text: "Ice cream",
+					Choose: func(_ string) (string, *question.Question[string], error) {
+						return "ice cream", nil, nil
+					},
+				},
+				{
+					Text: "Cookie",
+					Choose: func(_ string) (string, *question.Question[string], error) {
+						return "cookie", &qName, nil
+					},
+				},
+				{
+					Text:   "I don't like dessert",
+					Choose: question.NewChooseFunc[string](nil),
+				},
+			},
+		}
+
+		qGoodbye = question.NewTerminatingMessage[string]("Goodbye!")
+
+		qEmpty = question.Question[string]{
+			Text:   "What now?",
+			Answer: question.MultipleChoice[string]{},
+		}
+
+		qLoop question.Question[string]
+
+		qConfirmAgain = question.Question[string]{
+			ID:     "confirm-again",
+			Text:   "Are you sure?",
+			Answer: question.MultipleChoice[string]{{Text: "Yes", Choose: question.NewChooseFunc[string](nil)}},
+		}
+
+		qConfirm = question.Question[string]{
+			ID:     "confirm",
+			Text:   "Are you sure?",
+			Answer: question.MultipleChoice[string]{{Text: "Yes", Choose: question.NewChooseFunc(&qConfirmAgain)}},
+		}
+
+		qRenamedLoop question.Question[string]
+	)
+
+	qLoop = question.Question[string]{
+		Text: "Again?",
+		Answer: question.MultipleChoice[string]{
+			{
+				Text:   "Yes",
+				Choose: question.NewChooseFunc(&qLoop),
+			},
+			{
+				Text:   "No",
+				Choose: question.NewChooseFunc(&qGoodbye),
+			},
+		},
+	}
+
+	// A question whose text differs from the one it's reached from, but which is
+	// the same node.
+	qRenamedLoop = question.Question[string]{
+		ID:   "loop",
+		Text: "Continue?",
+		Answer: question.MultipleChoice[string]{
+			{
+				Text: "Yes",
+				Choose: func(state string) (string, *question.Question[string], error) {
+					next := qRenamedLoop
+					next.Text = "Continue again?"
+					return state, &next, nil
+				},
+			},
+		},
+	}
+
+	requireDessert := func(state string) error {
+		if state == "" {
+			return errNoDessert
+		}
+		return nil
+	}
+
+	cases := []struct {
+		name           string
+		root           question.Question[string]
+		validateResult func(string) error
+		expectedErr    error
+	}{
+		{
+			name: "valid",
+			root: qFavoriteDessert,
+		},
+		{
+			name:           "invalid result",
+			root:           qFavoriteDessert,
+			validateResult: requireDessert,
+			expectedErr:    errNoDessert,
+		},
+		{
+			name: "terminating message",
+			root: qGoodbye,
+			validateResult: func(string) error {
+				return errors.New("terminated interviews shouldn't produce results")
+			},
+		},
+		{
+			name:        "no choices",
+			root:        qEmpty,
+			expectedErr: ErrInvalidQuestion,
+		},
+		{
+			name:        "cycle",
+			root:        qLoop,
+			expectedErr: ErrCycle,
+		},
+		{
+			name: "distinct questions with the same text",
+			root: qConfirm,
+		},
+		{
+			name:        "cycle through a question with different text",
+			root:        qRenamedLoop,
+			expectedErr: ErrCycle,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(context.Background(), tt.root, "", tt.validateResult)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -3,6 +3,11 @@ package question
 import "errors"
 
 type Question[T any] struct {
+	// ID optionally identifies the question uniquely within the interview. Tools
+	// that traverse the interview's graph fall back to the question's Text when
+	// it's empty.
+	ID string
+
 	// The question to ask the user.
 	Text string
 
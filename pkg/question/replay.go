@@ -0,0 +1,187 @@
+package question
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Answer is a recorded answer to a single question. Exactly one of Choice or
+// Text is set, depending on how the question is answered. Messages don't
+// require an answer, so they're not recorded.
+type Answer struct {
+	// Question is the text of the question that was answered. When replaying, if
+	// it's set, it must match the question being asked.
+	Question string `yaml:"question,omitempty"`
+
+	// Choice is the index of the choice picked for a multiple choice question.
+	Choice *int `yaml:"choice,omitempty"`
+
+	// ChoiceText is the text of the picked choice. It makes recorded answers
+	// easier to read. When replaying, if it's set, it must match the text of the
+	// choice at the Choice index.
+	ChoiceText string `yaml:"choice-text,omitempty"`
+
+	// Text is the freeform text entered for a question that accepts text.
+	Text *string `yaml:"text,omitempty"`
+}
+
+var (
+	// ErrAnswerMismatch is returned by Replay when a recorded answer can't be used
+	// to answer the question being asked.
+	ErrAnswerMismatch = errors.New("recorded answer doesn't match question")
+
+	// ErrNoMoreAnswers is returned by Replay when the interview continues past
+	// the last recorded answer.
+	ErrNoMoreAnswers = errors.New("no more recorded answers")
+
+	// ErrUnusedAnswers is returned by Replay when the interview concludes before
+	// all recorded answers were used.
+	ErrUnusedAnswers = errors.New("interview concluded with unused recorded answers")
+)
+
+// Replay conducts the interview starting at the given root question without
+// user interaction, answering each question with the next of the given
+// answers, and returns the resulting state. Messages are acknowledged
+// automatically.
+//
+// If an answer function returns ErrTerminate, Replay returns it as is, so the
+// caller can discard the state, just as with an interactive interview.
+func Replay[T any](root Question[T], state T, answers []Answer) (T, error) {
+	q := &root
+	used := 0
+
+	nextAnswer := func() (Answer, error) {
+		if used >= len(answers) {
+			return Answer{}, fmt.Errorf("%w: %q needs an answer", ErrNoMoreAnswers, q.Text)
+		}
+
+		a := answers[used]
+		used++
+
+		if a.Question != "" && a.Question != q.Text {
+			return Answer{}, fmt.Errorf("%w: answer %d is for %q, but the question is %q", ErrAnswerMismatch, used, a.Question, q.Text)
+		}
+
+		return a, nil
+	}
+
+	for q != nil {
+		var next *Question[T]
+		var err error
+
+		switch answerFunc := q.Answer.(type) {
+		case MessageOnly[T]:
+			state, next, err = answerFunc(state)
+
+		case AcceptText[T]:
+			a, aErr := nextAnswer()
+			if aErr != nil {
+				return state, aErr
+			}
+			if a.Text == nil {
+				return state, fmt.Errorf("%w: %q needs a text answer", ErrAnswerMismatch, q.Text)
+			}
+
+			state, next, err = answerFunc(state, *a.Text)
+
+		case MultipleChoice[T]:
+			a, aErr := nextAnswer()
+			if aErr != nil {
+				return state, aErr
+			}
+			if a.Choice == nil {
+				return state, fmt.Errorf("%w: %q needs a choice", ErrAnswerMismatch, q.Text)
+			}
+			if *a.Choice < 0 || *a.Choice >= len(answerFunc) {
+				return state, fmt.Errorf("%w: choice %d is out of range for %q", ErrAnswerMismatch, *a.Choice, q.Text)
+			}
+
+			c := answerFunc[*a.Choice]
+			if a.ChoiceText != "" && a.ChoiceText != c.Text {
+				return state, fmt.Errorf("%w: choice %d for %q is %q, not %q", ErrAnswerMismatch, *a.Choice, q.Text, c.Text, a.ChoiceText)
+			}
+			if c.Choose == nil {
+				return state, fmt.Errorf("choice %q for %q can't be chosen", c.Text, q.Text)
+			}
+
+			state, next, err = c.Choose(state)
+
+		default:
+			return state, fmt.Errorf("question %q has unsupported answer type %T", q.Text, q.Answer)
+		}
+
+		if err != nil {
+			return state, err
+		}
+
+		q = next
+	}
+
+	if used < len(answers) {
+		return state, fmt.Errorf("%w: %d of %d answers used", ErrUnusedAnswers, used, len(answers))
+	}
+
+	return state, nil
+}
+
+// Transcript collects the answers given during an interview.
+type Transcript struct {
+	Answers []Answer
+}
+
+// Record returns a copy of the interview starting at the given root question
+// that records every answer given to the returned Transcript. The returned
+// question can be used anywhere the original can.
+func Record[T any](root Question[T]) (Question[T], *Transcript) {
+	t := new(Transcript)
+	return record(t, root), t
+}
+
+func record[T any](t *Transcript, q Question[T]) Question[T] {
+	wrapNext := func(next *Question[T]) *Question[T] {
+		if next == nil {
+			return nil
+		}
+
+		wrapped := record(t, *next)
+		return &wrapped
+	}
+
+	switch a := q.Answer.(type) {
+	case MessageOnly[T]:
+		q.Answer = MessageOnly[T](func(state T) (T, *Question[T], error) {
+			updated, next, err := a(state)
+			return updated, wrapNext(next), err
+		})
+
+	case AcceptText[T]:
+		q.Answer = AcceptText[T](func(state T, text string) (T, *Question[T], error) {
+			t.Answers = append(t.Answers, Answer{Question: q.Text, Text: &text})
+
+			updated, next, err := a(state, text)
+			return updated, wrapNext(next), err
+		})
+
+	case MultipleChoice[T]:
+		choices := make(MultipleChoice[T], 0, len(a))
+		for i, c := range a {
+			if c.Choose == nil {
+				choices = append(choices, c)
+				continue
+			}
+
+			choices = append(choices, Choice[T]{
+				Text: c.Text,
+				Choose: func(state T) (T, *Question[T], error) {
+					t.Answers = append(t.Answers, Answer{Question: q.Text, Choice: &i, ChoiceText: c.Text})
+
+					updated, next, err := c.Choose(state)
+					return updated, wrapNext(next), err
+				},
+			})
+		}
+		q.Answer = choices
+	}
+
+	return q
+}
@@ -0,0 +1,168 @@
+package question
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReplay(t *testing.T) {
+	var (
+		qName = Question[string]{
+			Text: "What's your name?",
+			Answer: AcceptText[string](func(state string, text string) (string, *Question[string], error) {
+				return state + " for " + text, nil, nil
+			}),
+		}
+
+		qThanks = NewMessage[string]("Thanks!", &qName)
+
+		qGoodbye = NewTerminatingMessage[string]("Goodbye!")
+
+		qFavoriteDessert = Question[string]{
+			Text: "What is your favorite dessert?",
+			Answer: MultipleChoice[string]{
+				{
+					Text: "Ice cream",
+					Choose: func(_ string) (string, *Question[string], error) {
+						return "ice cream", &qThanks, nil
+					},
+				},
+				{
+					Text: "Cookie",
+					Choose: func(_ string) (string, *Question[string], error) {
+						return "cookie", nil, nil
+					},
+				},
+				{
+					Text:   "I don't like dessert",
+					Choose: NewChooseFunc(&qGoodbye),
+				},
+			},
+		}
+	)
+
+	choice := func(i int) *int { return &i }
+	text := func(s string) *string { return &s }
+
+	cases := []struct {
+		name          string
+		answers       []Answer
+		expectedState string
+		expectedErr   error
+	}{
+		{
+			name: "choice then text",
+			answers: []Answer{
+				{Question: "What is your favorite dessert?", Choice: choice(0), ChoiceText: "Ice cream"},
+				{Text: text("Alice")},
+			},
+			expectedState: "ice cream for Alice",
+		},
+		{
+			name: "choice only",
+			answers: []Answer{
+				{Choice: choice(1)},
+			},
+			expectedState: "cookie",
+		},
+		{
+			name: "terminated",
+			answers: []Answer{
+				{Choice: choice(2)},
+			},
+			expectedErr: ErrTerminate,
+		},
+		{
+			name:        "no answers",
+			expectedErr: ErrNoMoreAnswers,
+		},
+		{
+			name: "too many answers",
+			answers: []Answer{
+				{Choice: choice(1)},
+				{Text: text("Bob")},
+			},
+			expectedErr: ErrUnusedAnswers,
+		},
+		{
+			name: "wrong question",
+			answers: []Answer{
+				{Question: "What is your favorite color?", Choice: choice(0)},
+			},
+			expectedErr: ErrAnswerMismatch,
+		},
+		{
+			name: "choice text changed",
+			answers: []Answer{
+				{Choice: choice(1), ChoiceText: "Brownie"},
+			},
+			expectedErr: ErrAnswerMismatch,
+		},
+		{
+			name: "choice out of range",
+			answers: []Answer{
+				{Choice: choice(3)},
+			},
+			expectedErr: ErrAnswerMismatch,
+		},
+		{
+			name: "text instead of choice",
+			answers: []Answer{
+				{Text: text("Ice cream")},
+			},
+			expectedErr: ErrAnswerMismatch,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := Replay(qFavoriteDessert, "", tt.answers)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Replay() error = %v, want %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			if state != tt.expectedState {
+				t.Errorf("Replay() state = %q, want %q", state, tt.expectedState)
+			}
+		})
+	}
+
+	t.Run("record and replay", func(t *testing.T) {
+		recorded, transcript := Record(qFavoriteDessert)
+
+		// Answer the recorded interview the way an interactive session would.
+		state, next, err := recorded.Answer.(MultipleChoice[string])[0].Choose("")
+		if err != nil {
+			t.Fatalf("Choose() error = %v", err)
+		}
+		state, next, err = next.Answer.(MessageOnly[string])(state)
+		if err != nil {
+			t.Fatalf("MessageOnly() error = %v", err)
+		}
+		state, _, err = next.Answer.(AcceptText[string])(state, "Carol")
+		if err != nil {
+			t.Fatalf("AcceptText() error = %v", err)
+		}
+
+		expectedAnswers := []Answer{
+			{Question: "What is your favorite dessert?", Choice: choice(0), ChoiceText: "Ice cream"},
+			{Question: "What's your name?", Text: text("Carol")},
+		}
+		if diff := cmp.Diff(expectedAnswers, transcript.Answers); diff != "" {
+			t.Errorf("unexpected recorded answers (-want +got):\n%s", diff)
+		}
+
+		replayed, err := Replay(qFavoriteDessert, "", transcript.Answers)
+		if err != nil {
+			t.Fatalf("Replay() error = %v", err)
+		}
+		if replayed != state {
+			t.Errorf("Replay() state = %q, want %q", replayed, state)
+		}
+	})
+}